@@ -4,10 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/FaaSTools/GoStorage/gostorage"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
 	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	transcribe "github.com/aws/aws-sdk-go-v2/service/transcribe"
 	"github.com/aws/aws-sdk-go-v2/service/transcribe/types"
+	"github.com/aws/aws-sdk-go-v2/service/transcribestreaming"
+	"io"
+	"net/http"
+	"net/url"
 	"reflect"
 	"strings"
 	"time"
@@ -16,8 +23,37 @@ import (
 type S2TAmazonWebServices struct {
 	credentials CredentialsHolder
 	s2tClient   *transcribe.Client
-	region      string
+	// streamingClient is used for streaming transcriptions (see StreamS2T).
+	streamingClient *transcribestreaming.Client
+	// s3Client is used to download (and delete) the transcript files that AWS Transcribe stores on S3.
+	s3Client *s3.Client
+	region   string
 	//sess        client.ConfigProvider
+	// Endpoint overrides the endpoint of the AWS Transcribe service client (e.g. "http://localhost:8080").
+	// This can be used to run the provider against a local emulator (see s2ttest.TranscribeEmulator) or a
+	// VPC endpoint. If empty, the default AWS endpoint of the region is used.
+	// To use a custom endpoint, pass a provider instance to GoS2TClient.WithProviderInstance.
+	Endpoint string
+	// S3Endpoint overrides the endpoint of the AWS S3 service client, which is used to download transcript files.
+	// If an endpoint is set, S3 requests use path-style URLs. If empty, Endpoint is used (if it is set).
+	S3Endpoint string
+	// StreamingEndpoint overrides the endpoint of AWS Transcribe Streaming (e.g. "https://localhost:8443"), which is
+	// used by StreamS2T. If empty, the default AWS endpoint of the region is used.
+	StreamingEndpoint string
+	// HTTPClient is used for the HTTP/2 connections of StreamS2T. If nil, the default HTTP client of the AWS SDK is used.
+	HTTPClient *http.Client
+}
+
+func init() {
+	providers.Register(providers.ProviderAWS, func() interface{} { return S2TAmazonWebServices{} }, []providers.Capability{
+		providers.CapabilityLanguageIdentification,
+		providers.CapabilityContentRedaction,
+		providers.CapabilitySpeakerDiarization,
+		providers.CapabilityProfanityFilter,
+		providers.CapabilityProfanityFilterRemove,
+		providers.CapabilityProfanityFilterTag,
+		providers.CapabilityStreaming,
+	}, gostorage.ProviderAWS)
 }
 
 type CredentialsProvider struct {
@@ -32,24 +68,64 @@ func (b CredentialsProvider) Retrieve(ctx context.Context) (aws.Credentials, err
 	return b.credentials, nil
 }
 
-func (a S2TAmazonWebServices) CreateServiceClient(cred CredentialsHolder, region string) (S2TProvider, error) {
+func (a S2TAmazonWebServices) CreateServiceClient(ctx context.Context, cred CredentialsHolder, region string) (S2TProvider, error) {
+	if cred.AwsCredentials == nil {
+		return a, errors.New("Couldn't create AWS service client, because no AWS credentials are given.")
+	}
 	credProv := CredentialsProvider{
 		credentials: *cred.AwsCredentials,
 	}
 	a.credentials = cred
 	a.region = region
-	a.s2tClient = transcribe.New(transcribe.Options{
+
+	transcribeOptions := transcribe.Options{
 		Credentials: credProv,
 		Region:      region,
-	})
+	}
+	if !strings.EqualFold(a.Endpoint, "") {
+		transcribeOptions.EndpointResolver = transcribe.EndpointResolverFromURL(a.Endpoint)
+	}
+	a.s2tClient = transcribe.New(transcribeOptions)
+
+	streamingOptions := transcribestreaming.Options{
+		Credentials: credProv,
+		Region:      region,
+	}
+	if !strings.EqualFold(a.StreamingEndpoint, "") {
+		streamingOptions.EndpointResolver = transcribestreaming.EndpointResolverFromURL(a.StreamingEndpoint)
+	}
+	if a.HTTPClient != nil {
+		streamingOptions.HTTPClient = a.HTTPClient
+	}
+	a.streamingClient = transcribestreaming.New(streamingOptions)
+
+	s3Options := s3.Options{
+		Credentials: credProv,
+		Region:      region,
+	}
+	s3Endpoint := a.S3Endpoint
+	if strings.EqualFold(s3Endpoint, "") {
+		s3Endpoint = a.Endpoint
+	}
+	if !strings.EqualFold(s3Endpoint, "") {
+		s3Options.EndpointResolver = s3.EndpointResolverFromURL(s3Endpoint)
+		s3Options.UsePathStyle = true
+	}
+	a.s3Client = s3.New(s3Options)
 	return a, nil
 }
 
-func (a S2TAmazonWebServices) TransformOptions(text string, options SpeechToTextOptions) (string, SpeechToTextOptions, error) {
+func (a S2TAmazonWebServices) TransformOptions(ctx context.Context, text string, options SpeechToTextOptions) (string, SpeechToTextOptions, error) {
 	return text, options, nil
 }
 
-func (a S2TAmazonWebServices) executeS2TInternal(sourceUrl string, destination string, options SpeechToTextOptions) (*transcribe.StartTranscriptionJobOutput, error) {
+// executeS2TInternal starts an AWS Transcribe transcription job. If the options contain inline phrases, the custom
+// vocabulary for them is created first (see ensureInlineVocabulary).
+func (a S2TAmazonWebServices) executeS2TInternal(ctx context.Context, sourceUrl string, destination string, options SpeechToTextOptions) (*transcribe.StartTranscriptionJobOutput, error) {
+	if options.AudioFormat.IsRaw() {
+		return nil, errors.New(fmt.Sprintf("Couldn't run transcription, because raw audio with encoding '%s' is not supported by AWS Transcribe.", options.AudioFormat.Encoding))
+	}
+
 	jobName := options.TranscriptionJobName.GetTranscriptionJobName()
 
 	contentRedaction := types.ContentRedaction{}
@@ -85,8 +161,20 @@ func (a S2TAmazonWebServices) executeS2TInternal(sourceUrl string, destination s
 
 	awsContentRedaction := a.getAwsContentRedactionOptions(options)
 
-	fileType := GetFileTypeFromFileName(sourceUrl)
-	mediaFormat := getAwsFileType(fileType)
+	mediaFormat := a.getMediaFormat(ctx, sourceUrl)
+
+	profanityFilters, err := a.getProfanityFilters(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	vocabularyName := options.VocabularyConfig.VocabularyName
+	if strings.EqualFold(vocabularyName, "") && options.VocabularyConfig.HasInlinePhrases() {
+		vocabularyName, err = a.ensureInlineVocabulary(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	jobInput := transcribe.StartTranscriptionJobInput{
 		Media: &types.Media{
@@ -99,12 +187,18 @@ func (a S2TAmazonWebServices) executeS2TInternal(sourceUrl string, destination s
 		LanguageCode:              types.LanguageCode(*languageCode),
 		LanguageOptions:           languageOptions,
 		MediaFormat:               mediaFormat,
+		MediaSampleRateHertz:      getAwsSampleRate(options),
 		OutputBucketName:          &bucket,
-		OutputKey:                 &key,
+		OutputKey:                 aws.String(getAwsOutputKey(key, options)),
+		Settings:                  getAwsSettings(options, vocabularyName, profanityFilters),
+		LanguageIdSettings:        getLanguageIdSettings(options, profanityFilters),
 	}
-	job, err := a.s2tClient.StartTranscriptionJob(context.Background(), &jobInput)
+	job, err := a.s2tClient.StartTranscriptionJob(ctx, &jobInput)
 
 	if err != nil {
+		if ctx.Err() != nil {
+			return job, ctx.Err()
+		}
 		errNew := errors.New("Error while starting transcription job: " + err.Error())
 		fmt.Printf(errNew.Error())
 		return job, errNew
@@ -113,32 +207,124 @@ func (a S2TAmazonWebServices) executeS2TInternal(sourceUrl string, destination s
 	return job, nil
 }
 
+// getAwsSampleRate returns the sample rate of the audio format of the given options.
+// If the sample rate is undefined, nil is returned, so AWS detects it automatically.
+func getAwsSampleRate(options SpeechToTextOptions) *int32 {
+	if options.AudioFormat.SampleRateHertz <= 0 {
+		return nil
+	}
+	sampleRate := options.AudioFormat.SampleRateHertz
+	return &sampleRate
+}
+
 func getTempDestination(sourceUrl string, options SpeechToTextOptions) string {
-	return fmt.Sprintf("s3://%s/%d.%s", options.TempBucket, time.Now().UnixMilli(), options.DefaultTextFileExtension)
+	return fmt.Sprintf("s3://%s/%s", options.TempBucket, GetTempKey(options.DefaultTextFileExtension))
 }
 
 // ExecuteS2T executes Speech-to-Text using AWS Transcribe service. The audio file on the given URL is transcribed into text
 // using the given options. The created text is stored in the file specified at the destination parameter.
 // The source string can either be an AWS S3 URI (starting with "s3://") or AWS S3 Object URL (starting with "https://").
+// If the options contain inline phrases (see VocabularyConfig), ExecuteS2T waits until the custom vocabulary for them
+// is ready before the transcription job is started (see ensureInlineVocabulary).
+// If content is redacted (see ContentRedactionConfig), AWS chooses the names of the transcript files itself. If the
+// files have to be moved (see needsResultFileMove), ExecuteS2T waits for the transcription job to finish, and moves
+// the redacted transcript file to the given destination and the unredacted transcript file (if any) to
+// GetUnredactedDestination(destination). Otherwise, ExecuteS2T returns as soon as the job has been started.
 // If an error occurs, returns empty string and error.
 // If no error occurs, error return value is nil.
-func (a S2TAmazonWebServices) ExecuteS2T(sourceUrl string, destination string, options SpeechToTextOptions) error {
-	_, err := a.executeS2TInternal(sourceUrl, destination, options)
-	return err
+func (a S2TAmazonWebServices) ExecuteS2T(ctx context.Context, sourceUrl string, destination string, options SpeechToTextOptions) error {
+	job, err := a.StartS2T(ctx, sourceUrl, destination, options)
+	if err != nil || !needsResultFileMove(destination, options) {
+		return err
+	}
+	job, err = WaitForJob(ctx, a, job, options)
+	if err != nil {
+		return err
+	}
+	// the unredacted file is moved first, because AWS might have stored it at the destination
+	if !strings.EqualFold(job.UnredactedResultUrl, "") {
+		err = a.moveResultFile(ctx, job.UnredactedResultUrl, GetUnredactedDestination(destination))
+		if err != nil {
+			return err
+		}
+	}
+	return a.moveResultFile(ctx, job.ResultUrl, destination)
+}
+
+// awsRedactedFilePrefix is the prefix that AWS inserts before the file name of the redacted transcript file of a
+// transcription job (e.g. "folder/redacted-transcript.json" for the output key "folder/transcript.json").
+const awsRedactedFilePrefix = "redacted-"
+
+// getAwsOutputKey returns the output key of a transcription job that should store its transcript at the given key.
+// If content is redacted and the file name of the given key starts with awsRedactedFilePrefix, the prefix is removed,
+// so that AWS stores the redacted transcript file at the given key.
+func getAwsOutputKey(key string, options SpeechToTextOptions) string {
+	if options.ContentRedactionConfig.IsEmpty() {
+		return key
+	}
+	index := strings.LastIndex(key, "/") + 1
+	if fileName, found := strings.CutPrefix(key[index:], awsRedactedFilePrefix); found && !strings.EqualFold(fileName, "") {
+		return key[:index] + fileName
+	}
+	return key
+}
+
+// needsResultFileMove returns true if the transcript files of a transcription job with the given destination and
+// options have to be moved after the job has finished. This is the case if content is redacted, and either the
+// redacted transcript file isn't stored at the destination (see getAwsOutputKey) or an unredacted transcript file is
+// stored as well.
+func needsResultFileMove(destination string, options SpeechToTextOptions) bool {
+	if options.ContentRedactionConfig.IsEmpty() {
+		return false
+	}
+	if options.ContentRedactionConfig.RedactionOutput == RedactionOutputRedactedAndUnredacted {
+		return true
+	}
+	_, key, err := GetBucketAndKeyFromAWSDestination(destination)
+	return err != nil || getAwsOutputKey(key, options) == key
+}
+
+// moveResultFile moves the result file at the given TranscriptFileUri (see parseTranscriptFileUri) of a transcription
+// job to the given destination, unless it is already stored there.
+func (a S2TAmazonWebServices) moveResultFile(ctx context.Context, transcriptFileUri string, destination string) error {
+	bucket, key, err := parseTranscriptFileUri(transcriptFileUri)
+	if err != nil {
+		return errors.Join(errors.New(fmt.Sprintf("Couldn't move the transcript file '%s' to '%s'.", transcriptFileUri, destination)), err)
+	}
+	destinationBucket, destinationKey, err := GetBucketAndKeyFromAWSDestination(destination)
+	if err != nil {
+		return errors.Join(errors.New(fmt.Sprintf("Couldn't move the transcript file '%s' to '%s'.", transcriptFileUri, destination)), err)
+	}
+	if bucket == destinationBucket && key == destinationKey {
+		return nil
+	}
+	copySource := (&url.URL{Path: bucket + "/" + key}).EscapedPath()
+	_, err = a.s3Client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     &destinationBucket,
+		Key:        &destinationKey,
+		CopySource: &copySource,
+	})
+	if err != nil {
+		return ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("Couldn't move the transcript file 's3://%s/%s' to '%s'.", bucket, key, destination)), err))
+	}
+	a.deleteResultFile(ctx, bucket, key)
+	return nil
 }
 
 // ExecuteS2TDirect executes Speech-to-Text using AWS Transcribe service. The audio file on the given URL is transcribed into text
 // using the given options. The created text is returned by this function.
 // The source string can either be an AWS S3 URI (starting with "s3://") or AWS S3 Object URL (starting with "https://").
+// AWS Transcribe always stores its result on S3, so the transcript is temporarily stored in options.TempBucket,
+// downloaded after the transcription job has completed and (unless options.KeepTempTextFile is true) deleted again.
 // If an error occurs, returns empty string and error.
 // If no error occurs, error return value is nil.
-func (a S2TAmazonWebServices) ExecuteS2TDirect(sourceUrl string, options SpeechToTextOptions) <-chan S2TDirectResult {
+func (a S2TAmazonWebServices) ExecuteS2TDirect(ctx context.Context, sourceUrl string, options SpeechToTextOptions) <-chan S2TDirectResult {
 	r := make(chan S2TDirectResult)
 
 	go func() {
 		defer close(r)
 
-		originalJob, err := a.executeS2TInternal(sourceUrl, getTempDestination(sourceUrl, options), options)
+		job, err := a.StartS2T(ctx, sourceUrl, "", options)
 		if err != nil {
 			r <- S2TDirectResult{
 				Text: "",
@@ -147,41 +333,275 @@ func (a S2TAmazonWebServices) ExecuteS2TDirect(sourceUrl string, options SpeechT
 			return
 		}
 
-		jobName := originalJob.TranscriptionJob.TranscriptionJobName
-		jobStatus := originalJob.TranscriptionJob.TranscriptionJobStatus
-		var err2 error = nil
-		var job *transcribe.GetTranscriptionJobOutput = nil
-		now := time.Now()
-		lastCheckTime := now.UnixMilli()
-		for jobStatus != "COMPLETED" {
-			if jobStatus == "FAILED" {
-				r <- S2TDirectResult{
-					Text: "",
-					Err:  errors.New(fmt.Sprintf("Error occurred during transcription: %s\n", *job.TranscriptionJob.FailureReason)),
-				}
-				return
-			}
-
-			now = time.Now()
-			if (now.UnixMilli() - lastCheckTime) > options.TranscriptionJobCheckIntervalMs {
-				//fmt.Printf("Check job status of %s\n", *jobName)
-				job, err2 = a.s2tClient.GetTranscriptionJob(context.Background(), &transcribe.GetTranscriptionJobInput{TranscriptionJobName: jobName})
-				if err2 != nil {
-					r <- S2TDirectResult{
-						Text: "",
-						Err:  err2,
-					}
-					return
-				}
-				jobStatus = job.TranscriptionJob.TranscriptionJobStatus
+		job, err = WaitForJob(ctx, a, job, options)
+		if err != nil {
+			r <- S2TDirectResult{
+				Text: "",
+				Err:  err,
 			}
+			return
 		}
-		fmt.Printf("job done\n")
+
+		r <- a.GetS2TResult(ctx, job)
 	}()
 
 	return r
 }
 
+// StartS2T starts an AWS Transcribe transcription job and returns a handle for it, without waiting for the job to finish.
+// The transcript file is stored at the given destination. If destination is empty, the transcript is stored in
+// options.TempBucket and (unless options.KeepTempTextFile is true) deleted after it has been downloaded with GetS2TResult.
+func (a S2TAmazonWebServices) StartS2T(ctx context.Context, sourceUrl string, destination string, options SpeechToTextOptions) (TranscriptionJob, error) {
+	deleteResultFile := false
+	if strings.EqualFold(destination, "") {
+		if strings.EqualFold(options.TempBucket, "") {
+			return TranscriptionJob{}, errors.New("options.TempBucket must be specified to store the transcript temporarily on AWS")
+		}
+		destination = getTempDestination(sourceUrl, options)
+		deleteResultFile = !options.KeepTempTextFile
+	}
+
+	output, err := a.executeS2TInternal(ctx, sourceUrl, destination, options)
+	if err != nil {
+		return TranscriptionJob{}, err
+	}
+
+	job := TranscriptionJob{
+		Provider:         providers.ProviderAWS,
+		Name:             aws.ToString(output.TranscriptionJob.TranscriptionJobName),
+		Region:           a.region,
+		Source:           sourceUrl,
+		Destination:      destination,
+		DeleteResultFile: deleteResultFile,
+		StartTime:        time.Now(),
+	}
+	return updateTranscriptionJob(job, output.TranscriptionJob), nil
+}
+
+// GetS2TStatus checks the status of the given AWS Transcribe transcription job and returns the updated job.
+func (a S2TAmazonWebServices) GetS2TStatus(ctx context.Context, job TranscriptionJob) (TranscriptionJob, error) {
+	output, err := a.s2tClient.GetTranscriptionJob(ctx, &transcribe.GetTranscriptionJobInput{TranscriptionJobName: &job.Name})
+	if err != nil {
+		return job, ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while checking status of transcription job '%s'", job.Name)), err))
+	}
+	return updateTranscriptionJob(job, output.TranscriptionJob), nil
+}
+
+// GetS2TResult downloads the transcript file of the given (completed) AWS Transcribe transcription job and returns
+// the transcribed text. If content has been redacted with RedactionOutputRedactedAndUnredacted, the unredacted
+// transcript file is downloaded as well, and gets the redacted entities of the redacted transcript.
+// If job.DeleteResultFile is true, the transcript files are deleted afterwards.
+func (a S2TAmazonWebServices) GetS2TResult(ctx context.Context, job TranscriptionJob) S2TDirectResult {
+	if job.Status != JobStatusCompleted {
+		return S2TDirectResult{
+			Text: "",
+			Err:  errors.New(fmt.Sprintf("The result of transcription job '%s' isn't available, because the job has status '%s'.", job.Name, job.Status)),
+		}
+	}
+
+	bucket, key, errTranscriptLocation := getTranscriptLocation(job.ResultUrl, job.Destination)
+	if errTranscriptLocation != nil {
+		return S2TDirectResult{
+			Text: "",
+			Err:  errTranscriptLocation,
+		}
+	}
+
+	transcript, errDownload := a.downloadTranscript(ctx, bucket, key)
+	if errDownload != nil {
+		return S2TDirectResult{
+			Text: "",
+			Err:  errDownload,
+		}
+	}
+
+	var unredacted *Transcript = nil
+	if !strings.EqualFold(job.UnredactedResultUrl, "") {
+		unredactedBucket, unredactedKey, errUnredactedLocation := parseTranscriptFileUri(job.UnredactedResultUrl)
+		if errUnredactedLocation != nil {
+			return S2TDirectResult{
+				Text: "",
+				Err:  errUnredactedLocation,
+			}
+		}
+		unredactedTranscript, errUnredactedDownload := a.downloadTranscript(ctx, unredactedBucket, unredactedKey)
+		if errUnredactedDownload != nil {
+			return S2TDirectResult{
+				Text: "",
+				Err:  errUnredactedDownload,
+			}
+		}
+		// the unredacted transcript file doesn't mark the redacted entities
+		unredactedTranscript.Entities = transcript.Entities
+		unredacted = &unredactedTranscript
+		if job.DeleteResultFile {
+			a.deleteResultFile(ctx, unredactedBucket, unredactedKey)
+		}
+	}
+
+	if job.DeleteResultFile {
+		a.deleteResultFile(ctx, bucket, key)
+	}
+
+	return S2TDirectResult{
+		Text:                 transcript.Text,
+		Transcript:           &transcript,
+		UnredactedTranscript: unredacted,
+		Err:                  nil,
+	}
+}
+
+// deleteResultFile deletes the given transcript file. Errors are only printed, since they are not fatal.
+func (a S2TAmazonWebServices) deleteResultFile(ctx context.Context, bucket string, key string) {
+	_, errDelete := a.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &bucket,
+		Key:    &key,
+	})
+	if errDelete != nil {
+		fmt.Printf(errors.Join(errors.New(fmt.Sprintf("A non-fatal error occurred while deleting the transcript file 's3://%s/%s'.", bucket, key)), errDelete).Error())
+	}
+}
+
+// updateTranscriptionJob updates status, failure reason and result URLs of the given job handle with the values
+// from the given AWS transcription job.
+// If content is redacted, the result URL is the URL of the redacted transcript file and the TranscriptFileUri
+// (if any) is the URL of the unredacted transcript file.
+func updateTranscriptionJob(job TranscriptionJob, transcriptionJob *types.TranscriptionJob) TranscriptionJob {
+	if transcriptionJob == nil {
+		return job
+	}
+	job.Status = getJobStatus(transcriptionJob.TranscriptionJobStatus)
+	job.FailureReason = aws.ToString(transcriptionJob.FailureReason)
+	if transcriptionJob.Transcript == nil {
+		return job
+	}
+	if transcriptionJob.Transcript.RedactedTranscriptFileUri != nil {
+		job.ResultUrl = *transcriptionJob.Transcript.RedactedTranscriptFileUri
+		job.UnredactedResultUrl = aws.ToString(transcriptionJob.Transcript.TranscriptFileUri)
+	} else if transcriptionJob.Transcript.TranscriptFileUri != nil {
+		job.ResultUrl = *transcriptionJob.Transcript.TranscriptFileUri
+	}
+	return job
+}
+
+// getJobStatus converts the given AWS transcription job status into the provider-independent JobStatus.
+func getJobStatus(status types.TranscriptionJobStatus) JobStatus {
+	switch status {
+	case types.TranscriptionJobStatusQueued:
+		return JobStatusQueued
+	case types.TranscriptionJobStatusCompleted:
+		return JobStatusCompleted
+	case types.TranscriptionJobStatusFailed:
+		return JobStatusFailed
+	default:
+		return JobStatusInProgress
+	}
+}
+
+// getTranscriptLocation returns the S3 bucket and key of the transcript file of a completed transcription job.
+// The location is taken from the given TranscriptFileUri of the job. If the URI is empty or can't be parsed,
+// the given destination (i.e. the OutputBucketName and OutputKey with which the job was started) is used instead.
+func getTranscriptLocation(transcriptFileUri string, destination string) (string, string, error) {
+	if !strings.EqualFold(transcriptFileUri, "") {
+		bucket, key, err := parseTranscriptFileUri(transcriptFileUri)
+		if err == nil {
+			return bucket, key, nil
+		}
+	}
+	return GetBucketAndKeyFromAWSDestination(destination)
+}
+
+// parseTranscriptFileUri parses the TranscriptFileUri returned by AWS Transcribe into bucket and key.
+// AWS returns path-style URLs (e.g. "https://s3.us-east-1.amazonaws.com/bucket/key"), but virtual-hosted-style
+// URLs and S3 URIs are accepted as well.
+func parseTranscriptFileUri(transcriptFileUri string) (string, string, error) {
+	parsed, err := url.Parse(transcriptFileUri)
+	if err != nil {
+		return "", "", err
+	}
+	if strings.EqualFold(parsed.Scheme, "s3") {
+		return GetBucketAndKeyFromAWSDestination(transcriptFileUri)
+	}
+	path := strings.TrimPrefix(parsed.Path, "/")
+	if strings.HasPrefix(parsed.Host, "s3.") || strings.HasPrefix(parsed.Host, "s3-") {
+		// path-style URL: first path segment is the bucket
+		pathSplits := strings.SplitN(path, "/", 2)
+		if len(pathSplits) < 2 || pathSplits[1] == "" {
+			return "", "", errors.New(fmt.Sprintf("The transcript file URI '%s' doesn't contain a bucket and key.", transcriptFileUri))
+		}
+		return pathSplits[0], pathSplits[1], nil
+	}
+	if path == "" {
+		return "", "", errors.New(fmt.Sprintf("The transcript file URI '%s' doesn't contain a key.", transcriptFileUri))
+	}
+	// virtual-hosted-style URL: bucket is the first part of the host name
+	return strings.SplitN(parsed.Host, ".", 2)[0], path, nil
+}
+
+// downloadTranscript downloads the transcript file that has been created by AWS Transcribe from the given S3 bucket and key,
+// and returns the parsed transcript.
+func (a S2TAmazonWebServices) downloadTranscript(ctx context.Context, bucket string, key string) (Transcript, error) {
+	obj, err := a.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return Transcript{}, ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("Couldn't download the transcript file 's3://%s/%s'.", bucket, key)), err))
+	}
+
+	// close body after function call ended
+	defer func(Body io.ReadCloser) {
+		errClose := Body.Close()
+		if errClose != nil {
+			fmt.Printf(errors.Join(errors.New(fmt.Sprintf("A non-fatal error occurred while closing the transcript file 's3://%s/%s'.", bucket, key)), errClose).Error())
+		}
+	}(obj.Body)
+
+	content, errRead := io.ReadAll(obj.Body)
+	if errRead != nil {
+		return Transcript{}, ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while reading the transcript file 's3://%s/%s'", bucket, key)), errRead))
+	}
+	return ParseTranscriptOutput(content)
+}
+
+// getAwsSettings converts the given options, the name of the custom vocabulary (if any) and the names of the
+// vocabulary filters for profanity filtering (if any, see getProfanityFilters) into AWS transcription job settings.
+// If no setting is needed, nil is returned.
+func getAwsSettings(options SpeechToTextOptions, vocabularyName string, profanityFilters []string) *types.Settings {
+	var settings *types.Settings = nil
+	if options.MaxAlternatives > 1 {
+		settings = &types.Settings{
+			ShowAlternatives: aws.Bool(true),
+			MaxAlternatives:  aws.Int32(options.MaxAlternatives),
+		}
+	}
+	if options.DiarizationConfig.Enabled {
+		if settings == nil {
+			settings = &types.Settings{}
+		}
+		_, maxSpeakerCount := options.DiarizationConfig.GetSpeakerCountRange()
+		settings.ShowSpeakerLabels = aws.Bool(true)
+		settings.MaxSpeakerLabels = aws.Int32(maxSpeakerCount)
+	}
+	if !strings.EqualFold(vocabularyName, "") {
+		if settings == nil {
+			settings = &types.Settings{}
+		}
+		settings.VocabularyName = aws.String(vocabularyName)
+	}
+	if len(profanityFilters) > 0 {
+		if settings == nil {
+			settings = &types.Settings{}
+		}
+		settings.VocabularyFilterMethod = types.VocabularyFilterMethod(options.ProfanityFilterConfig.GetMethod())
+		if !strings.EqualFold(options.LanguageConfig.LanguageCode, "") {
+			// otherwise, the filters are specified per language (see getLanguageIdSettings)
+			settings.VocabularyFilterName = aws.String(profanityFilters[0])
+		}
+	}
+	return settings
+}
+
 // getAwsContentRedactionOptions converts the abstracted GoSpeech2Text content redaction options to AWS content redaction options.
 func (a S2TAmazonWebServices) getAwsContentRedactionOptions(options SpeechToTextOptions) *types.ContentRedaction {
 	var awsContentRedaction *types.ContentRedaction = nil
@@ -239,10 +659,46 @@ func (a S2TAmazonWebServices) SupportsFileType(fileType string) bool {
 	return false
 }
 
+// getMediaFormat returns the media format of the given S3 file. The format is detected from the first bytes of the file
+// (see DetectAudioFileType). If the file can't be read or its format isn't recognized, the file extension is used.
+// If the resulting format isn't supported, an empty format is returned, so AWS detects the format itself.
+func (a S2TAmazonWebServices) getMediaFormat(ctx context.Context, sourceUrl string) types.MediaFormat {
+	fileType := ""
+	if header, err := a.ReadStorageAudioHeader(ctx, sourceUrl); err == nil {
+		fileType = DetectAudioFileType(header)
+	}
+	if strings.EqualFold(fileType, "") {
+		fileType = GetFileTypeFromFileName(sourceUrl)
+	}
+	if !a.SupportsFileType(fileType) {
+		return ""
+	}
+	return getAwsFileType(fileType)
+}
+
+// ReadStorageAudioHeader reads the first AudioHeaderSize bytes of the given file on AWS S3 with a ranged GetObject
+// request.
+func (a S2TAmazonWebServices) ReadStorageAudioHeader(ctx context.Context, url string) ([]byte, error) {
+	if a.s3Client == nil {
+		return nil, errors.New("Couldn't read the audio header, because the service client hasn't been created.")
+	}
+	obj := ParseAWSUrl(url)
+	output, err := a.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &obj.Bucket,
+		Key:    &obj.Key,
+		Range:  aws.String(fmt.Sprintf("bytes=0-%d", AudioHeaderSize-1)),
+	})
+	if err != nil {
+		return nil, ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while reading the header of the file '%s'", url)), err))
+	}
+	defer output.Body.Close()
+	return ReadAudioHeaderFromReader(ctx, output.Body)
+}
+
 // getAwsFileType turns the given fileType string into an AWS media format type.
 // The given fileType must not start with a period.
 func getAwsFileType(fileType string) types.MediaFormat {
-	return types.MediaFormat(fileType)
+	return types.MediaFormat(strings.ToLower(fileType))
 }
 
 func (a S2TAmazonWebServices) SupportsDirectFileInput() bool {
@@ -0,0 +1,655 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/s2ttest"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/transcribe/types"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTranscriptOutput(t *testing.T) {
+	content := `{"jobName":"s2t-1","accountId":"1","results":{"transcripts":[{"transcript":"Hello World."}],"items":[]},"status":"COMPLETED"}`
+	transcript, err := ParseTranscriptOutput([]byte(content))
+	if err != nil {
+		t.Error("unexpected error: ", err)
+	}
+	if !strings.EqualFold(transcript.Text, "Hello World.") {
+		t.Error("wrong transcript: Got ", transcript.Text)
+	}
+
+	_, err = ParseTranscriptOutput([]byte("not json"))
+	if err == nil {
+		t.Error("expected error for invalid transcript file")
+	}
+}
+
+func TestParseTranscriptOutputItems(t *testing.T) {
+	content := `{"results":{"language_code":"en-US","transcripts":[{"transcript":"Hello world. How are you?"}],"items":[
+		{"start_time":"0.1","end_time":"0.5","alternatives":[{"confidence":"0.9","content":"Hello"}],"type":"pronunciation"},
+		{"start_time":"0.5","end_time":"0.9","alternatives":[{"confidence":"0.7","content":"world"}],"type":"pronunciation"},
+		{"alternatives":[{"confidence":"0.0","content":"."}],"type":"punctuation"},
+		{"start_time":"1.2","end_time":"1.4","alternatives":[{"confidence":"1.0","content":"How"}],"type":"pronunciation"},
+		{"start_time":"1.4","end_time":"1.6","alternatives":[{"confidence":"1.0","content":"are"}],"type":"pronunciation"},
+		{"start_time":"1.6","end_time":"1.9","alternatives":[{"confidence":"1.0","content":"you"}],"type":"pronunciation"},
+		{"alternatives":[{"confidence":"0.0","content":"?"}],"type":"punctuation"}
+	]}}`
+	transcript, err := ParseTranscriptOutput([]byte(content))
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if transcript.LanguageCode != "en-US" || len(transcript.Segments) != 2 {
+		t.Fatal("wrong transcript: Got ", transcript)
+	}
+
+	first := transcript.Segments[0]
+	if first.Text != "Hello world." || first.StartTime != 100*time.Millisecond || first.EndTime != 900*time.Millisecond || len(first.Words) != 3 {
+		t.Error("wrong first segment: Got ", first)
+	}
+	if first.Confidence < 0.79 || first.Confidence > 0.81 {
+		t.Error("wrong confidence: Got ", first.Confidence)
+	}
+	if !first.Words[2].IsPunctuation {
+		t.Error("expected punctuation: Got ", first.Words[2])
+	}
+
+	second := transcript.Segments[1]
+	if second.Text != "How are you?" || second.StartTime != 1200*time.Millisecond || second.EndTime != 1900*time.Millisecond {
+		t.Error("wrong second segment: Got ", second)
+	}
+}
+
+func TestParseTranscriptOutputSpeakerLabels(t *testing.T) {
+	content := `{"results":{"transcripts":[{"transcript":"Hello there hi"}],"items":[
+		{"start_time":"0.1","end_time":"0.5","alternatives":[{"confidence":"0.9","content":"Hello"}],"type":"pronunciation"},
+		{"start_time":"0.5","end_time":"0.9","alternatives":[{"confidence":"0.9","content":"there"}],"type":"pronunciation"},
+		{"start_time":"1.2","end_time":"1.4","alternatives":[{"confidence":"0.9","content":"hi"}],"type":"pronunciation"}
+	],"speaker_labels":{"speakers":2,"segments":[
+		{"start_time":"0.1","end_time":"0.9","speaker_label":"spk_0","items":[{"start_time":"0.1","speaker_label":"spk_0"},{"start_time":"0.5","speaker_label":"spk_0"}]},
+		{"start_time":"1.2","end_time":"1.4","speaker_label":"spk_1","items":[{"start_time":"1.2","speaker_label":"spk_1"}]}
+	]}}}`
+	transcript, err := ParseTranscriptOutput([]byte(content))
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if len(transcript.Segments) != 2 {
+		t.Fatal("wrong number of segments: Got ", transcript.Segments)
+	}
+	if transcript.Segments[0].Speaker != "spk_0" || transcript.Segments[0].Text != "Hello there" {
+		t.Error("wrong first segment: Got ", transcript.Segments[0])
+	}
+	if transcript.Segments[1].Speaker != "spk_1" || transcript.Segments[1].Words[0].Speaker != "spk_1" {
+		t.Error("wrong second segment: Got ", transcript.Segments[1])
+	}
+}
+
+func TestParseTranscriptOutputRedactions(t *testing.T) {
+	content := `{"results":{"transcripts":[{"transcript":"my pin is [PII]"}],"items":[
+		{"start_time":"0.1","end_time":"0.5","alternatives":[{"confidence":"0.9","content":"my"}],"type":"pronunciation"},
+		{"start_time":"0.5","end_time":"0.9","alternatives":[{"confidence":"0.9","content":"pin"}],"type":"pronunciation"},
+		{"start_time":"0.9","end_time":"1.1","alternatives":[{"confidence":"0.9","content":"is"}],"type":"pronunciation"},
+		{"start_time":"1.1","end_time":"2.4","alternatives":[{"confidence":"0.0","content":"[PII]","redactions":[{"confidence":"1.0","type":"PIN","category":"PII"}]}],"type":"pronunciation"}
+	]}}`
+	transcript, err := ParseTranscriptOutput([]byte(content))
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if len(transcript.Entities) != 1 || transcript.Entities[0] != (TranscriptEntity{Type: RedactionEntityPin, StartTime: 1100 * time.Millisecond, EndTime: 2400 * time.Millisecond}) {
+		t.Error("wrong entities: Got ", transcript.Entities)
+	}
+	if words := transcript.GetWords(); len(words) != 4 || words[2].IsRedacted || !words[3].IsRedacted {
+		t.Error("wrong redacted words: Got ", words)
+	}
+}
+
+func TestGetAwsSettings(t *testing.T) {
+	if getAwsSettings(SpeechToTextOptions{}, "", nil) != nil {
+		t.Error("expected no settings")
+	}
+	settings := getAwsSettings(SpeechToTextOptions{DiarizationConfig: DiarizationConfig{Enabled: true, MaxSpeakerCount: 3}}, "", nil)
+	if settings == nil || !*settings.ShowSpeakerLabels || *settings.MaxSpeakerLabels != 3 || settings.ShowAlternatives != nil {
+		t.Error("wrong diarization settings: Got ", settings)
+	}
+	settings = getAwsSettings(SpeechToTextOptions{}, "my-vocabulary", nil)
+	if settings == nil || aws.ToString(settings.VocabularyName) != "my-vocabulary" {
+		t.Error("wrong vocabulary settings: Got ", settings)
+	}
+	options := SpeechToTextOptions{ProfanityFilter: true, LanguageConfig: LanguageConfig{LanguageCode: "en-US"}}
+	options.ProfanityFilterConfig.Method = ProfanityFilterMethodRemove
+	settings = getAwsSettings(options, "", []string{"my-filter"})
+	if settings == nil || aws.ToString(settings.VocabularyFilterName) != "my-filter" || settings.VocabularyFilterMethod != "remove" {
+		t.Error("wrong vocabulary filter settings: Got ", settings)
+	}
+}
+
+func TestParseTranscriptFileUri(t *testing.T) {
+	bucket, key, err := parseTranscriptFileUri("https://s3.us-east-1.amazonaws.com/test-bucket/folder/123.txt")
+	if err != nil || bucket != "test-bucket" || key != "folder/123.txt" {
+		t.Error("wrong path-style result: Got ", bucket, key, err)
+	}
+
+	bucket, key, err = parseTranscriptFileUri("https://test-bucket.s3.us-east-1.amazonaws.com/123.txt")
+	if err != nil || bucket != "test-bucket" || key != "123.txt" {
+		t.Error("wrong virtual-hosted-style result: Got ", bucket, key, err)
+	}
+
+	bucket, key, err = parseTranscriptFileUri("s3://test-bucket/123.txt")
+	if err != nil || bucket != "test-bucket" || key != "123.txt" {
+		t.Error("wrong S3 URI result: Got ", bucket, key, err)
+	}
+
+	_, _, err = parseTranscriptFileUri("https://s3.us-east-1.amazonaws.com/test-bucket")
+	if err == nil {
+		t.Error("expected error for URI without key")
+	}
+}
+
+func createEmulatedProvider(t *testing.T, emulator *s2ttest.TranscribeEmulator) S2TProvider {
+	provider, err := S2TAmazonWebServices{Endpoint: emulator.URL}.CreateServiceClient(context.Background(), CredentialsHolder{
+		AwsCredentials: &aws.Credentials{AccessKeyID: "test", SecretAccessKey: "test"},
+	}, "us-east-1")
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	return provider
+}
+
+func getEmulatorTestOptions() SpeechToTextOptions {
+	options := GetDefaultSpeechToTextOptions()
+	options.TempBucket = "temp-bucket"
+	options.LanguageConfig.LanguageCode = "en-US"
+	options.TranscriptionJobCheckIntervalMs = 1
+	options.TranscriptionJobMaxCheckIntervalMs = 5
+	return *options
+}
+
+func TestExecuteS2TDirectEmulator(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("hello emulated world")
+	defer emulator.Close()
+	emulator.Statuses = []string{s2ttest.TranscribeStatusQueued, s2ttest.TranscribeStatusInProgress, s2ttest.TranscribeStatusCompleted}
+	provider := createEmulatedProvider(t, emulator)
+
+	result := <-provider.ExecuteS2TDirect(context.Background(), "s3://audio-bucket/audio.wav", getEmulatorTestOptions())
+	if result.Err != nil {
+		t.Fatal("unexpected error: ", result.Err)
+	}
+	if result.Text != "hello emulated world" || len(result.Transcript.GetWords()) != 3 {
+		t.Error("wrong result: Got ", result)
+	}
+
+	starts := emulator.StartRequests()
+	if len(starts) != 1 || starts[0].MediaFileUri != "s3://audio-bucket/audio.wav" || starts[0].OutputBucketName != "temp-bucket" {
+		t.Fatal("wrong start requests: Got ", starts)
+	}
+	if checks := emulator.StatusChecks(starts[0].TranscriptionJobName); checks != 3 {
+		t.Error("wrong number of status checks: Got ", checks)
+	}
+	if deleted := emulator.DeletedObjects(); len(deleted) != 1 || deleted[0] != "temp-bucket/"+starts[0].OutputKey {
+		t.Error("expected temporary transcript file to be deleted: Got ", deleted)
+	}
+}
+
+func TestExecuteS2TDirectEmulatorKeepTempTextFile(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("hello emulated world")
+	defer emulator.Close()
+	provider := createEmulatedProvider(t, emulator)
+
+	// options without defaults delete the temporary transcript file, too
+	options := SpeechToTextOptions{TempBucket: "temp-bucket", TranscriptionJobCheckIntervalMs: 1}
+	result := <-provider.ExecuteS2TDirect(context.Background(), "s3://audio-bucket/audio.wav", options)
+	if result.Err != nil || len(emulator.DeletedObjects()) != 1 {
+		t.Fatal("expected temporary transcript file to be deleted: Got ", emulator.DeletedObjects(), result.Err)
+	}
+
+	options.KeepTempTextFile = true
+	result = <-provider.ExecuteS2TDirect(context.Background(), "s3://audio-bucket/audio.wav", options)
+	if result.Err != nil || len(emulator.DeletedObjects()) != 1 {
+		t.Error("expected temporary transcript file to be kept: Got ", emulator.DeletedObjects(), result.Err)
+	}
+}
+
+func TestExecuteS2TDirectEmulatorFailed(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("")
+	defer emulator.Close()
+	emulator.Statuses = []string{s2ttest.TranscribeStatusInProgress, s2ttest.TranscribeStatusFailed}
+	emulator.FailureReason = "Unsupported media format"
+	provider := createEmulatedProvider(t, emulator)
+
+	result := <-provider.ExecuteS2TDirect(context.Background(), "s3://audio-bucket/audio.wav", getEmulatorTestOptions())
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "Unsupported media format") {
+		t.Error("expected failure reason in error: Got ", result.Err)
+	}
+}
+
+func TestGetS2TResultEmulatorMissingTranscript(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("")
+	defer emulator.Close()
+	provider := createEmulatedProvider(t, emulator)
+
+	result := provider.(TranscriptionJobProvider).GetS2TResult(context.Background(), TranscriptionJob{
+		Name:        "missing",
+		Status:      JobStatusCompleted,
+		Destination: "s3://temp-bucket/missing.json",
+	})
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "s3://temp-bucket/missing.json") {
+		t.Error("expected download error: Got ", result.Err)
+	}
+}
+
+func TestStartS2TEmulatorConflict(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("")
+	defer emulator.Close()
+	provider := createEmulatedProvider(t, emulator)
+	options := getEmulatorTestOptions()
+	options.TranscriptionJobName = TranscriptionJobNameConfig{TranscriptionJobName: "fixed-name"}
+
+	if _, err := provider.(TranscriptionJobProvider).StartS2T(context.Background(), "s3://audio-bucket/audio.wav", "", options); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if _, err := provider.(TranscriptionJobProvider).StartS2T(context.Background(), "s3://audio-bucket/audio.wav", "", options); err == nil || !strings.Contains(err.Error(), "ConflictException") {
+		t.Error("expected conflict error: Got ", err)
+	}
+}
+
+func TestExecuteS2TDirectEmulatorSampleRate(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("hello")
+	defer emulator.Close()
+	provider := createEmulatedProvider(t, emulator)
+	options := getEmulatorTestOptions()
+	options.AudioFormat = AudioFormat{SampleRateHertz: 8000}
+
+	result := <-provider.ExecuteS2TDirect(context.Background(), "s3://audio-bucket/audio.wav", options)
+	if result.Err != nil {
+		t.Fatal("unexpected error: ", result.Err)
+	}
+	if starts := emulator.StartRequests(); len(starts) != 1 || starts[0].Raw["MediaSampleRateHertz"] != float64(8000) {
+		t.Error("wrong sample rate in start request: Got ", starts)
+	}
+
+	options.AudioFormat.Encoding = AudioEncodingLinear16
+	result = <-provider.ExecuteS2TDirect(context.Background(), "s3://audio-bucket/audio.raw", options)
+	if result.Err == nil {
+		t.Error("expected error because raw audio isn't supported")
+	}
+}
+
+func TestExecuteS2TDirectEmulatorDetectedMediaFormat(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("hello")
+	defer emulator.Close()
+	emulator.PutObject("audio-bucket", "recording", []byte("fLaC\x00\x00\x00\x22"))
+	provider := createEmulatedProvider(t, emulator)
+
+	result := <-provider.ExecuteS2TDirect(context.Background(), "s3://audio-bucket/recording", getEmulatorTestOptions())
+	if result.Err != nil {
+		t.Fatal("unexpected error: ", result.Err)
+	}
+	if starts := emulator.StartRequests(); len(starts) != 1 || starts[0].Raw["MediaFormat"] != "flac" {
+		t.Error("wrong media format in start request: Got ", starts)
+	}
+}
+
+func TestStreamS2TEmulator(t *testing.T) {
+	emulator := s2ttest.NewTranscribeStreamingEmulator(
+		`{"Transcript":{"Results":[{"ResultId":"r1","StartTime":0.1,"EndTime":0.5,"IsPartial":true,"Alternatives":[{"Transcript":"hello","Items":[{"Content":"hello","StartTime":0.1,"EndTime":0.5,"Type":"pronunciation","Stable":true},{"Content":"wor","StartTime":0.5,"EndTime":0.6,"Type":"pronunciation","Stable":false}]}]}]}}`,
+		`{"Transcript":{"Results":[{"ResultId":"r1","StartTime":0.1,"EndTime":0.9,"IsPartial":false,"LanguageCode":"en-US","Alternatives":[{"Transcript":"hello world","Items":[{"Content":"hello","StartTime":0.1,"EndTime":0.5,"Type":"pronunciation"},{"Content":"world","StartTime":0.5,"EndTime":0.9,"Type":"pronunciation"}]}]}]}}`,
+	)
+	defer emulator.Close()
+	provider, err := S2TAmazonWebServices{StreamingEndpoint: emulator.URL, HTTPClient: emulator.Client()}.CreateServiceClient(context.Background(), CredentialsHolder{
+		AwsCredentials: &aws.Credentials{AccessKeyID: "test", SecretAccessKey: "test"},
+	}, "us-east-1")
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	options := getEmulatorTestOptions()
+	options.AudioFormat = AudioFormat{Encoding: AudioEncodingLinear16, SampleRateHertz: 16000}
+	audio := make([]byte, StreamingAudioChunkSize*2+100)
+	audio[0] = 1
+
+	results, err := provider.(StreamingS2TProvider).StreamS2T(context.Background(), bytes.NewReader(audio), options)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	var received []PartialResult
+	for result := range results {
+		received = append(received, result)
+	}
+	if len(received) != 2 || received[0].IsFinal || received[0].Stability != 0.5 || received[0].Text != "hello" {
+		t.Fatal("wrong interim result: Got ", received)
+	}
+	if final := received[1]; !final.IsFinal || final.Stability != 1.0 || final.Text != "hello world" || final.EndTime != 900*time.Millisecond || len(final.Words) != 2 {
+		t.Error("wrong final result: Got ", final)
+	}
+
+	if !bytes.Equal(emulator.Audio(), audio) {
+		t.Error("wrong streamed audio: Got ", len(emulator.Audio()))
+	}
+	headers := emulator.RequestHeaders()
+	if len(headers) != 1 || headers[0].Get("x-amzn-transcribe-media-encoding") != "pcm" || headers[0].Get("x-amzn-transcribe-sample-rate") != "16000" ||
+		headers[0].Get("x-amzn-transcribe-language-code") != "en-US" {
+		t.Error("wrong request headers: Got ", headers)
+	}
+}
+
+func TestStreamS2TEmulatorRedaction(t *testing.T) {
+	emulator := s2ttest.NewTranscribeStreamingEmulator(
+		`{"Transcript":{"Results":[{"ResultId":"r1","StartTime":0.1,"EndTime":1.1,"IsPartial":false,"Alternatives":[{"Transcript":"my pin is 4821","Items":[{"Content":"my","StartTime":0.1,"EndTime":0.3,"Type":"pronunciation"},{"Content":"pin","StartTime":0.3,"EndTime":0.5,"Type":"pronunciation"},{"Content":"is","StartTime":0.5,"EndTime":0.7,"Type":"pronunciation"},{"Content":"4821","StartTime":0.7,"EndTime":1.1,"Type":"pronunciation"}]}]}]}}`,
+	)
+	defer emulator.Close()
+	emulator.RedactedWords = map[string]string{"4821": "PIN"}
+	provider, _ := S2TAmazonWebServices{StreamingEndpoint: emulator.URL, HTTPClient: emulator.Client()}.CreateServiceClient(context.Background(), CredentialsHolder{
+		AwsCredentials: &aws.Credentials{AccessKeyID: "test", SecretAccessKey: "test"},
+	}, "us-east-1")
+	options := getEmulatorRedactionOptions()
+	options.AudioFormat = AudioFormat{Encoding: AudioEncodingLinear16, SampleRateHertz: 16000}
+
+	results, err := provider.(StreamingS2TProvider).StreamS2T(context.Background(), bytes.NewReader([]byte{1, 2}), options)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	result := <-results
+	if result.Err != nil || result.Text != "my pin is [PII]" || len(result.Words) != 4 || !result.Words[3].IsRedacted {
+		t.Error("wrong redacted result: Got ", result)
+	}
+	headers := emulator.RequestHeaders()
+	if len(headers) != 1 || headers[0].Get("x-amzn-transcribe-content-redaction-type") != "PII" || headers[0].Get("x-amzn-transcribe-pii-entity-types") != "PIN" {
+		t.Error("wrong redaction headers: Got ", headers)
+	}
+}
+
+func TestStreamS2TEmulatorException(t *testing.T) {
+	emulator := s2ttest.NewTranscribeStreamingEmulator()
+	defer emulator.Close()
+	emulator.ExceptionType = "BadRequestException"
+	emulator.ExceptionMessage = "unsupported audio"
+	provider, _ := S2TAmazonWebServices{StreamingEndpoint: emulator.URL, HTTPClient: emulator.Client()}.CreateServiceClient(context.Background(), CredentialsHolder{
+		AwsCredentials: &aws.Credentials{AccessKeyID: "test", SecretAccessKey: "test"},
+	}, "us-east-1")
+	options := getEmulatorTestOptions()
+	options.AudioFormat = AudioFormat{Encoding: AudioEncodingLinear16, SampleRateHertz: 16000}
+
+	results, err := provider.(StreamingS2TProvider).StreamS2T(context.Background(), bytes.NewReader([]byte{1, 2}), options)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	result := <-results
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "unsupported audio") {
+		t.Error("expected exception: Got ", result)
+	}
+
+	options.AudioFormat = AudioFormat{SampleRateHertz: 16000}
+	if _, err = provider.(StreamingS2TProvider).StreamS2T(context.Background(), bytes.NewReader([]byte("RIFF")), options); err == nil {
+		t.Error("expected error for unsupported stream format")
+	}
+}
+
+func TestExecuteS2TDirectEmulatorVocabulary(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("hello emulated world")
+	defer emulator.Close()
+	provider := createEmulatedProvider(t, emulator)
+	options := getEmulatorTestOptions()
+	options.VocabularyConfig = VocabularyConfig{
+		Phrases:       []VocabularyPhrase{{Phrase: "GoSpeech2Text"}, {Phrase: "order ${product}", Boost: 10}},
+		CustomClasses: []VocabularyClass{{Id: "product", Items: []string{"Widget Pro"}}},
+	}
+
+	result := <-provider.ExecuteS2TDirect(context.Background(), "s3://audio-bucket/audio.wav", options)
+	if result.Err != nil {
+		t.Fatal("unexpected error: ", result.Err)
+	}
+
+	result = <-provider.ExecuteS2TDirect(context.Background(), "s3://audio-bucket/audio.wav", options)
+	if result.Err != nil {
+		t.Fatal("unexpected error: ", result.Err)
+	}
+
+	vocabularyName := getInlineVocabularyName("en-US", []string{"GoSpeech2Text", "order-Widget-Pro", "Widget-Pro"})
+	starts := emulator.StartRequests()
+	for _, start := range starts {
+		settings, _ := start.Raw["Settings"].(map[string]interface{})
+		if settings["VocabularyName"] != vocabularyName {
+			t.Error("wrong vocabulary name of job: Got ", settings)
+		}
+	}
+	if vocabulary, exists := emulator.Vocabulary(vocabularyName); len(starts) != 2 || !exists || len(vocabulary.Phrases) != 3 {
+		t.Error("expected vocabulary to be created: Got ", vocabulary, starts)
+	}
+	if creations := emulator.VocabularyCreations(); creations != 1 {
+		t.Error("expected vocabulary to be created only once: Got ", creations)
+	}
+	if deleted := emulator.DeletedVocabularies(); len(deleted) != 0 {
+		t.Error("expected vocabulary to be kept: Got ", deleted)
+	}
+}
+
+func TestExecuteS2TDirectEmulatorVocabularyFailed(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("hello emulated world")
+	defer emulator.Close()
+	emulator.VocabularyStates = []string{s2ttest.TranscribeVocabularyStateFailed}
+	provider := createEmulatedProvider(t, emulator)
+	options := getEmulatorTestOptions()
+	options.VocabularyConfig = VocabularyConfig{Phrases: []VocabularyPhrase{{Phrase: "GoSpeech2Text"}}}
+
+	result := <-provider.ExecuteS2TDirect(context.Background(), "s3://audio-bucket/audio.wav", options)
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "emulated failure") {
+		t.Error("expected vocabulary failure: Got ", result.Err)
+	}
+	if len(emulator.StartRequests()) != 0 || len(emulator.DeletedVocabularies()) != 1 {
+		t.Error("expected failed vocabulary to be deleted without starting a job: Got ", emulator.DeletedVocabularies())
+	}
+}
+
+func TestGetAwsVocabularyPhrases(t *testing.T) {
+	phrases := getAwsVocabularyPhrases(VocabularyConfig{
+		Phrases:       []VocabularyPhrase{{Phrase: "order ${product}"}},
+		CustomClasses: []VocabularyClass{{Id: "product", Items: []string{"Widget Pro", "Gadget"}}},
+	})
+	if strings.Join(phrases, ",") != "order-Widget-Pro,order-Gadget,Widget-Pro,Gadget" {
+		t.Error("wrong phrases: Got ", phrases)
+	}
+}
+
+func TestVocabularyEmulator(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("hello emulated world")
+	defer emulator.Close()
+	provider := createEmulatedProvider(t, emulator).(VocabularyManager)
+	ctx := context.Background()
+	vocabulary := Vocabulary{Id: "shop", LanguageCode: "en-US", Phrases: []VocabularyPhrase{{Phrase: "Widget Pro"}}}
+
+	created, err := provider.CreateVocabulary(ctx, vocabulary)
+	if err != nil || created.Name != "shop" || created.State != VocabularyStatePending {
+		t.Fatal("wrong created vocabulary: Got ", created, err)
+	}
+	ready, err := WaitForVocabulary(ctx, provider, created, getEmulatorTestOptions())
+	if err != nil || ready.State != VocabularyStateReady {
+		t.Error("expected vocabulary to be ready: Got ", ready, err)
+	}
+
+	vocabulary.Phrases = []VocabularyPhrase{{Phrase: "Gadget"}}
+	if _, err = provider.UpdateVocabulary(ctx, vocabulary); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if emulated, _ := emulator.Vocabulary("shop"); len(emulated.Phrases) != 1 || emulated.Phrases[0] != "Gadget" {
+		t.Error("wrong phrases: Got ", emulated.Phrases)
+	}
+	if vocabularies, errList := provider.ListVocabularies(ctx); errList != nil || len(vocabularies) != 1 || vocabularies[0].Id != "shop" {
+		t.Error("wrong vocabularies: Got ", vocabularies, errList)
+	}
+
+	if err = provider.DeleteVocabulary(ctx, "shop"); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if _, err = provider.GetVocabulary(ctx, "shop"); !errors.Is(err, ErrVocabularyNotFound) {
+		t.Error("expected ErrVocabularyNotFound: Got ", err)
+	}
+}
+
+func TestIsVocabularyNotFound(t *testing.T) {
+	notFound := &types.BadRequestException{Message: aws.String("The requested vocabulary couldn't be found. Check the vocabulary name and try your request again.")}
+	if !isVocabularyNotFound(errors.Join(errors.New("error"), notFound)) {
+		t.Error("expected BadRequestException to be recognized: Got ", notFound)
+	}
+	if !isVocabularyNotFound(&types.NotFoundException{}) {
+		t.Error("expected NotFoundException to be recognized")
+	}
+	if badRequest := (&types.BadRequestException{Message: aws.String("Invalid phrase.")}); isVocabularyNotFound(badRequest) {
+		t.Error("expected other BadRequestException not to be recognized: Got ", badRequest)
+	}
+}
+
+func TestExecuteS2TDirectEmulatorProfanityFilter(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("well damn that works")
+	defer emulator.Close()
+	provider := createEmulatedProvider(t, emulator)
+	options := getEmulatorTestOptions()
+	options.ProfanityFilter = true
+
+	for i := 0; i < 2; i++ {
+		result := <-provider.ExecuteS2TDirect(context.Background(), "s3://audio-bucket/audio.wav", options)
+		if result.Err != nil || result.Text != "well *** that works" {
+			t.Fatal("wrong result: Got ", result)
+		}
+	}
+	if creations := emulator.VocabularyFilterCreations(); creations != 1 {
+		t.Error("expected vocabulary filter to be created once: Got ", creations)
+	}
+	name := getVocabularyFilterName("en-US", DefaultProfanityWords())
+	if filter, exists := emulator.VocabularyFilter(name); !exists || filter.LanguageCode != "en-US" {
+		t.Error("wrong vocabulary filter: Got ", filter)
+	}
+
+	options.ProfanityFilterConfig.Method = ProfanityFilterMethodTag
+	result := <-provider.ExecuteS2TDirect(context.Background(), "s3://audio-bucket/audio.wav", options)
+	if result.Err != nil || result.Text != "well damn that works" {
+		t.Fatal("wrong result: Got ", result)
+	}
+	words := result.Transcript.GetWords()
+	if len(words) != 4 || words[0].IsProfanity || !words[1].IsProfanity {
+		t.Error("expected profanity to be tagged: Got ", words)
+	}
+}
+
+func TestExecuteS2TDirectEmulatorProfanityFilterWithoutLanguage(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("well damn that works")
+	defer emulator.Close()
+	provider := createEmulatedProvider(t, emulator)
+	options := getEmulatorTestOptions()
+	options.LanguageConfig.LanguageCode = ""
+	options.ProfanityFilter = true
+
+	result := <-provider.ExecuteS2TDirect(context.Background(), "s3://audio-bucket/audio.wav", options)
+	if result.Err == nil {
+		t.Error("expected error because vocabulary filters require a language")
+	}
+
+	german := "de-DE"
+	english := "en-US"
+	options.LanguageConfig.LanguageOptions = []*string{&english, &german}
+	result = <-provider.ExecuteS2TDirect(context.Background(), "s3://audio-bucket/audio.wav", options)
+	if result.Err != nil {
+		t.Fatal("unexpected error: ", result.Err)
+	}
+	starts := emulator.StartRequests()
+	languageIdSettings, _ := starts[len(starts)-1].Raw["LanguageIdSettings"].(map[string]interface{})
+	if len(languageIdSettings) != 2 || emulator.VocabularyFilterCreations() != 2 {
+		t.Error("expected vocabulary filter per language: Got ", languageIdSettings)
+	}
+}
+
+func getEmulatorRedactionOptions() SpeechToTextOptions {
+	options := getEmulatorTestOptions()
+	entityType := RedactionEntityPin
+	options.ContentRedactionConfig = ContentRedactionConfig{
+		ContentRedactionType: RedactionTypePersonallyIdentifiableInformation,
+		RedactionEntityTypes: []*RedactionEntityType{&entityType},
+		RedactionOutput:      RedactionOutputRedactedAndUnredacted,
+	}
+	return options
+}
+
+func TestExecuteS2TDirectEmulatorRedaction(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("my pin is 4821")
+	defer emulator.Close()
+	emulator.RedactedWords = map[string]string{"4821": "PIN"}
+	provider := createEmulatedProvider(t, emulator)
+
+	result := <-provider.ExecuteS2TDirect(context.Background(), "s3://audio-bucket/audio.wav", getEmulatorRedactionOptions())
+	if result.Err != nil {
+		t.Fatal("unexpected error: ", result.Err)
+	}
+	if result.Text != "my pin is [PII]" || len(result.Transcript.Entities) != 1 || result.Transcript.Entities[0].Type != RedactionEntityPin {
+		t.Error("wrong redacted transcript: Got ", result.Transcript)
+	}
+	if result.UnredactedTranscript == nil || result.UnredactedTranscript.Text != "my pin is 4821" || len(result.UnredactedTranscript.Entities) != 1 {
+		t.Error("wrong unredacted transcript: Got ", result.UnredactedTranscript)
+	}
+	if deleted := emulator.DeletedObjects(); len(deleted) != 2 {
+		t.Error("expected both temporary transcript files to be deleted: Got ", deleted)
+	}
+}
+
+func TestExecuteS2TEmulatorRedaction(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("my pin is 4821")
+	defer emulator.Close()
+	emulator.RedactedWords = map[string]string{"4821": "PIN"}
+	provider := createEmulatedProvider(t, emulator)
+
+	err := provider.ExecuteS2T(context.Background(), "s3://audio-bucket/audio.wav", "s3://result-bucket/transcript.json", getEmulatorRedactionOptions())
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if content, exists := emulator.Object("result-bucket", "transcript.json"); !exists || !bytes.Contains(content, []byte("[PII]")) {
+		t.Error("expected redacted transcript at destination: Got ", string(content))
+	}
+	if content, exists := emulator.Object("result-bucket", "transcript.unredacted.json"); !exists || !bytes.Contains(content, []byte("4821")) {
+		t.Error("expected unredacted transcript at unredacted destination: Got ", string(content))
+	}
+	if _, exists := emulator.Object("result-bucket", "redacted-transcript.json"); exists {
+		t.Error("expected redacted transcript file of AWS to be moved")
+	}
+}
+
+func TestExecuteS2TEmulatorRedactionWithoutMove(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("my pin is 4821")
+	defer emulator.Close()
+	emulator.RedactedWords = map[string]string{"4821": "PIN"}
+	provider := createEmulatedProvider(t, emulator)
+	options := getEmulatorRedactionOptions()
+	options.ContentRedactionConfig.RedactionOutput = RedactionOutputRedacted
+
+	err := provider.ExecuteS2T(context.Background(), "s3://audio-bucket/audio.wav", "s3://result-bucket/folder/redacted-transcript.json", options)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	starts := emulator.StartRequests()
+	if len(starts) != 1 || starts[0].OutputKey != "folder/transcript.json" {
+		t.Fatal("wrong start requests: Got ", starts)
+	}
+	if checks := emulator.StatusChecks(starts[0].TranscriptionJobName); checks != 0 {
+		t.Error("expected ExecuteS2T not to wait for the job: Got ", checks, " status checks")
+	}
+}
+
+func TestNeedsResultFileMove(t *testing.T) {
+	options := getEmulatorRedactionOptions()
+	if !needsResultFileMove("s3://bucket/redacted-transcript.json", options) {
+		t.Error("expected unredacted transcript to be moved")
+	}
+	options.ContentRedactionConfig.RedactionOutput = RedactionOutputRedacted
+	if !needsResultFileMove("s3://bucket/transcript.json", options) {
+		t.Error("expected redacted transcript to be moved")
+	}
+	if needsResultFileMove("s3://bucket/redacted-transcript.json", options) {
+		t.Error("expected redacted transcript to be stored at destination")
+	}
+	if needsResultFileMove("s3://bucket/transcript.json", getEmulatorTestOptions()) {
+		t.Error("expected no move without redaction")
+	}
+	if key := getAwsOutputKey("a/redacted-", options); key != "a/redacted-" {
+		t.Error("wrong output key: Got ", key)
+	}
+}
@@ -0,0 +1,245 @@
+package aws
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/transcribestreaming"
+	streamingtypes "github.com/aws/aws-sdk-go-v2/service/transcribestreaming/types"
+	"io"
+	"strings"
+	"time"
+)
+
+// awsStreamingMediaEncodings maps the file types that AWS Transcribe Streaming supports to its media encodings.
+var awsStreamingMediaEncodings = map[string]streamingtypes.MediaEncoding{
+	"flac": streamingtypes.MediaEncodingFlac,
+	"ogg":  streamingtypes.MediaEncodingOggOpus,
+}
+
+// StreamS2T transcribes the given audio stream using AWS Transcribe Streaming with partial results stabilization, so
+// every word of an interim result is marked as stable or not.
+// The audio must either be raw LINEAR16 audio or a FLAC or Ogg Opus stream. In any case, the sample rate must be
+// specified in options.AudioFormat.
+// Only named custom vocabularies (VocabularyConfig.VocabularyName) are used. Inline phrases are ignored, since
+// creating a custom vocabulary for them takes too long for real-time transcription.
+// Profanities are filtered with vocabulary filters, like in ExecuteS2T.
+func (a S2TAmazonWebServices) StreamS2T(ctx context.Context, audio io.Reader, options SpeechToTextOptions) (<-chan PartialResult, error) {
+	if a.streamingClient == nil {
+		return nil, errors.New("Couldn't start AWS streaming transcription, because the service client hasn't been created.")
+	}
+	if options.AudioFormat.SampleRateHertz <= 0 {
+		return nil, errors.New("Couldn't start AWS streaming transcription, because the sample rate of the audio stream isn't specified.")
+	}
+	profanityFilters, errFilters := a.getProfanityFilters(ctx, options)
+	if errFilters != nil {
+		return nil, errFilters
+	}
+	audioReader := bufio.NewReaderSize(audio, StreamingAudioChunkSize)
+	mediaEncoding, errEncoding := getAwsStreamingMediaEncoding(audioReader, options.AudioFormat)
+	if errEncoding != nil {
+		return nil, errEncoding
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	output, err := a.streamingClient.StartStreamTranscription(streamCtx, getAwsStreamingInput(mediaEncoding, options, profanityFilters))
+	if err != nil {
+		cancel()
+		return nil, ContextError(ctx, errors.Join(errors.New("error while starting AWS streaming transcription"), err))
+	}
+	stream := output.GetStream()
+
+	// audio is sent while results are received (full duplex)
+	audioErr := make(chan error, 1)
+	go func() {
+		errAudio := ReadAudioStream(streamCtx, audioReader, func(chunk []byte) error {
+			return stream.Send(streamCtx, &streamingtypes.AudioStreamMemberAudioEvent{
+				Value: streamingtypes.AudioEvent{AudioChunk: chunk},
+			})
+		})
+		if errAudio != nil {
+			audioErr <- errAudio
+			// aborts the transcription
+			cancel()
+			return
+		}
+		// closing the writer sends an empty signed message, which ends the stream
+		_ = stream.Writer.Close()
+	}()
+
+	r := make(chan PartialResult)
+	go func() {
+		defer close(r)
+		defer cancel()
+		defer stream.Close()
+
+		for event := range stream.Events() {
+			transcriptEvent, isTranscriptEvent := event.(*streamingtypes.TranscriptResultStreamMemberTranscriptEvent)
+			if !isTranscriptEvent {
+				continue
+			}
+			for _, result := range convertStreamingResults(transcriptEvent.Value) {
+				if !SendPartialResult(ctx, r, result) {
+					return
+				}
+			}
+		}
+		select {
+		case errAudio := <-audioErr:
+			SendPartialResult(ctx, r, PartialResult{Err: ContextError(ctx, errors.Join(errors.New("error while sending audio to AWS streaming transcription"), errAudio))})
+			return
+		default:
+		}
+		if errStream := stream.Err(); errStream != nil {
+			SendPartialResult(ctx, r, PartialResult{Err: ContextError(ctx, errors.Join(errors.New("AWS streaming transcription failed"), errStream))})
+		}
+	}()
+	return r, nil
+}
+
+// getAwsStreamingMediaEncoding returns the AWS Transcribe Streaming media encoding of the given audio stream.
+// Raw audio must be LINEAR16. Otherwise, the file type is detected from the first bytes of the stream, which are
+// peeked without consuming them.
+func getAwsStreamingMediaEncoding(audio *bufio.Reader, format AudioFormat) (streamingtypes.MediaEncoding, error) {
+	if format.IsRaw() {
+		if format.Encoding != AudioEncodingLinear16 {
+			return "", errors.New(fmt.Sprintf("Couldn't start AWS streaming transcription, because raw audio with encoding '%s' is not supported.", format.Encoding))
+		}
+		return streamingtypes.MediaEncodingPcm, nil
+	}
+	header, _ := audio.Peek(AudioHeaderSize)
+	fileType := DetectAudioFileType(header)
+	mediaEncoding, exists := awsStreamingMediaEncodings[fileType]
+	if !exists {
+		return "", errors.New(fmt.Sprintf("Couldn't start AWS streaming transcription, because the audio stream (file type '%s') is neither raw LINEAR16 audio, FLAC nor Ogg Opus.", fileType))
+	}
+	return mediaEncoding, nil
+}
+
+// getAwsStreamingInput returns the AWS Transcribe Streaming request for the given options and the names of the
+// vocabulary filters for profanity filtering (if any, see getProfanityFilters).
+func getAwsStreamingInput(mediaEncoding streamingtypes.MediaEncoding, options SpeechToTextOptions, profanityFilters []string) *transcribestreaming.StartStreamTranscriptionInput {
+	input := &transcribestreaming.StartStreamTranscriptionInput{
+		MediaEncoding:                     mediaEncoding,
+		MediaSampleRateHertz:              aws.Int32(int32(options.AudioFormat.SampleRateHertz)),
+		EnablePartialResultsStabilization: true,
+		ShowSpeakerLabel:                  options.DiarizationConfig.Enabled,
+	}
+	if strings.EqualFold(options.LanguageConfig.LanguageCode, "") {
+		input.IdentifyLanguage = true
+		var languageOptions []string
+		for _, languageOption := range options.LanguageConfig.LanguageOptions {
+			languageOptions = append(languageOptions, *languageOption)
+		}
+		input.LanguageOptions = aws.String(strings.Join(languageOptions, ","))
+	} else {
+		input.LanguageCode = streamingtypes.LanguageCode(options.LanguageConfig.LanguageCode)
+	}
+	if !strings.EqualFold(options.VocabularyConfig.VocabularyName, "") {
+		input.VocabularyName = aws.String(options.VocabularyConfig.VocabularyName)
+	}
+	if !options.ContentRedactionConfig.IsEmpty() {
+		setAwsStreamingRedaction(input, options.ContentRedactionConfig)
+	}
+	if len(profanityFilters) > 0 {
+		input.VocabularyFilterMethod = streamingtypes.VocabularyFilterMethod(options.ProfanityFilterConfig.GetMethod())
+		if strings.EqualFold(options.LanguageConfig.LanguageCode, "") {
+			input.VocabularyFilterNames = aws.String(strings.Join(profanityFilters, ","))
+		} else {
+			input.VocabularyFilterName = aws.String(profanityFilters[0])
+		}
+	}
+	return input
+}
+
+// setAwsStreamingRedaction sets the content redaction of the given AWS Transcribe Streaming request.
+// If the entity types contain RedactionEntityAll, no entity types are sent, so that all entities are redacted.
+// Streaming transcriptions only return the redacted transcript, even if RedactionOutputRedactedAndUnredacted is
+// specified.
+func setAwsStreamingRedaction(input *transcribestreaming.StartStreamTranscriptionInput, config ContentRedactionConfig) {
+	redactionType := config.ContentRedactionType
+	if strings.EqualFold(string(redactionType), "") {
+		redactionType = RedactionTypePersonallyIdentifiableInformation
+	}
+	input.ContentRedactionType = streamingtypes.ContentRedactionType(redactionType)
+	var entityTypes []string
+	for _, entityType := range config.RedactionEntityTypes {
+		if entityType == nil {
+			continue
+		}
+		if *entityType == RedactionEntityAll {
+			return
+		}
+		entityTypes = append(entityTypes, string(*entityType))
+	}
+	if len(entityTypes) > 0 {
+		input.PiiEntityTypes = aws.String(strings.Join(entityTypes, ","))
+	}
+}
+
+// convertStreamingResults converts the results of the given TranscriptEvent of AWS Transcribe Streaming into partial
+// results.
+func convertStreamingResults(event streamingtypes.TranscriptEvent) []PartialResult {
+	if event.Transcript == nil {
+		return nil
+	}
+	var results []PartialResult
+	for _, result := range event.Transcript.Results {
+		partial := PartialResult{
+			ResultId:     aws.ToString(result.ResultId),
+			IsFinal:      !result.IsPartial,
+			StartTime:    secondsToDuration(result.StartTime),
+			EndTime:      secondsToDuration(result.EndTime),
+			LanguageCode: string(result.LanguageCode),
+		}
+		if len(result.Alternatives) > 0 {
+			partial.Text = aws.ToString(result.Alternatives[0].Transcript)
+			partial.Words, partial.Stability = convertStreamingItems(result.Alternatives[0].Items)
+		}
+		if partial.IsFinal {
+			partial.Stability = 1.0
+		}
+		results = append(results, partial)
+	}
+	return results
+}
+
+// convertStreamingItems converts the given AWS streaming items into transcript words and returns the share of stable
+// items (or 0.0 if the items have no stability flag).
+func convertStreamingItems(items []streamingtypes.Item) ([]TranscriptWord, float32) {
+	var words []TranscriptWord
+	flagged := 0
+	stable := 0
+	for _, item := range items {
+		content := aws.ToString(item.Content)
+		words = append(words, TranscriptWord{
+			Text:          content,
+			StartTime:     secondsToDuration(item.StartTime),
+			EndTime:       secondsToDuration(item.EndTime),
+			Confidence:    float32(aws.ToFloat64(item.Confidence)),
+			IsPunctuation: item.Type == streamingtypes.ItemTypePunctuation,
+			Speaker:       aws.ToString(item.Speaker),
+			IsProfanity:   item.VocabularyFilterMatch,
+			// redacted entities are replaced by a single item (see ContentRedactionConfig)
+			IsRedacted: content == RedactionPlaceholder,
+		})
+		if item.Stable != nil {
+			flagged++
+			if *item.Stable {
+				stable++
+			}
+		}
+	}
+	if flagged < 1 {
+		return words, 0
+	}
+	return words, float32(stable) / float32(flagged)
+}
+
+// secondsToDuration converts the given number of seconds into a duration.
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
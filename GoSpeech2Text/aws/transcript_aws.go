@@ -0,0 +1,299 @@
+package aws
+
+import (
+	"encoding/json"
+	"errors"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// awsTranscriptOutput is the structure of the JSON file that AWS Transcribe stores on S3 after a transcription
+// job has completed. Only the fields that are used by GoSpeech2Text are specified.
+// See AWS docs: https://docs.aws.amazon.com/transcribe/latest/dg/how-input.html#how-output
+type awsTranscriptOutput struct {
+	JobName string `json:"jobName"`
+	Status  string `json:"status"`
+	Results struct {
+		LanguageCode string `json:"language_code"`
+		Transcripts  []struct {
+			Transcript string `json:"transcript"`
+		} `json:"transcripts"`
+		Items         []awsTranscriptItem `json:"items"`
+		AudioSegments []struct {
+			Transcript   string `json:"transcript"`
+			StartTime    string `json:"start_time"`
+			EndTime      string `json:"end_time"`
+			SpeakerLabel string `json:"speaker_label"`
+			Items        []int  `json:"items"`
+		} `json:"audio_segments"`
+		// SpeakerLabels are only contained if speaker diarization has been enabled (see SpeechToTextOptions.DiarizationConfig).
+		SpeakerLabels struct {
+			Segments []struct {
+				Items []struct {
+					StartTime    string `json:"start_time"`
+					SpeakerLabel string `json:"speaker_label"`
+				} `json:"items"`
+			} `json:"segments"`
+		} `json:"speaker_labels"`
+		// Segments are only contained if alternatives have been requested (see SpeechToTextOptions.MaxAlternatives).
+		Segments []struct {
+			StartTime    string `json:"start_time"`
+			EndTime      string `json:"end_time"`
+			Alternatives []struct {
+				Transcript string `json:"transcript"`
+				Items      []struct {
+					StartTime  string `json:"start_time"`
+					EndTime    string `json:"end_time"`
+					Confidence string `json:"confidence"`
+					Content    string `json:"content"`
+					Type       string `json:"type"`
+				} `json:"items"`
+			} `json:"alternatives"`
+		} `json:"segments"`
+	} `json:"results"`
+}
+
+// awsTranscriptItem is a single word or punctuation mark in the AWS Transcribe output.
+type awsTranscriptItem struct {
+	Id           *int   `json:"id"`
+	StartTime    string `json:"start_time"`
+	EndTime      string `json:"end_time"`
+	Type         string `json:"type"`
+	LanguageCode string `json:"language_code"`
+	SpeakerLabel string `json:"speaker_label"`
+	// VocabularyFilterMatch is only set if profanities are tagged (see ProfanityFilterMethodTag).
+	VocabularyFilterMatch bool `json:"vocabulary_filter_match"`
+	Alternatives          []struct {
+		Confidence string `json:"confidence"`
+		Content    string `json:"content"`
+		// Redactions are only contained in redacted transcripts (see ContentRedactionConfig). The content of a
+		// redacted item is "[PII]".
+		Redactions []struct {
+			Type     string `json:"type"`
+			Category string `json:"category"`
+		} `json:"redactions"`
+	} `json:"alternatives"`
+}
+
+// ParseTranscriptOutput parses the contents of a transcript file created by AWS Transcribe and returns the
+// structured transcript. If the file contains multiple transcripts, their texts are concatenated.
+// If the file contains audio segments, they are used as transcript segments. Otherwise, the words are split
+// into segments at sentence-ending punctuation marks and speaker changes.
+func ParseTranscriptOutput(content []byte) (Transcript, error) {
+	var output awsTranscriptOutput
+	if err := json.Unmarshal(content, &output); err != nil {
+		return Transcript{}, errors.Join(errors.New("error while parsing the AWS Transcribe output file"), err)
+	}
+
+	resultText := ""
+	for _, transcript := range output.Results.Transcripts {
+		resultText += transcript.Transcript
+	}
+
+	transcript := Transcript{
+		Text:         resultText,
+		LanguageCode: output.Results.LanguageCode,
+	}
+
+	speakers := getSpeakersByStartTime(output)
+	words := make([]TranscriptWord, len(output.Results.Items))
+	wordsById := make(map[int]int)
+	for i, item := range output.Results.Items {
+		words[i] = convertItem(item)
+		if item.Id != nil {
+			wordsById[*item.Id] = i
+		}
+	}
+	assignSpeakers(words, speakers)
+	transcript.Entities = getEntities(output.Results.Items, words)
+
+	if len(output.Results.Segments) > 0 {
+		// alternatives have been requested -> segments contain alternatives (ordered by likelihood)
+		for _, awsSegment := range output.Results.Segments {
+			segment := TranscriptSegment{
+				StartTime:    parseAwsTime(awsSegment.StartTime),
+				EndTime:      parseAwsTime(awsSegment.EndTime),
+				LanguageCode: output.Results.LanguageCode,
+			}
+			for i, alt := range awsSegment.Alternatives {
+				var altWords []TranscriptWord
+				for _, item := range alt.Items {
+					altWords = append(altWords, TranscriptWord{
+						Text:          item.Content,
+						StartTime:     parseAwsTime(item.StartTime),
+						EndTime:       parseAwsTime(item.EndTime),
+						Confidence:    parseAwsConfidence(item.Confidence),
+						IsPunctuation: strings.EqualFold(item.Type, "punctuation"),
+					})
+				}
+				assignSpeakers(altWords, speakers)
+				if i == 0 {
+					segment.Text = alt.Transcript
+					segment.Words = altWords
+					segment.Confidence = AverageWordConfidence(altWords)
+					segment.Speaker = GetSpeaker(altWords)
+				} else {
+					segment.Alternatives = append(segment.Alternatives, TranscriptAlternative{
+						Text:       alt.Transcript,
+						Confidence: AverageWordConfidence(altWords),
+						Words:      altWords,
+					})
+				}
+			}
+			transcript.Segments = append(transcript.Segments, segment)
+		}
+		return transcript, nil
+	}
+
+	if len(output.Results.AudioSegments) > 0 {
+		for _, audioSegment := range output.Results.AudioSegments {
+			var segmentWords []TranscriptWord
+			for _, itemId := range audioSegment.Items {
+				if wordIndex, exists := wordsById[itemId]; exists {
+					segmentWords = append(segmentWords, words[wordIndex])
+				}
+			}
+			speaker := audioSegment.SpeakerLabel
+			if speaker == "" {
+				speaker = GetSpeaker(segmentWords)
+			}
+			transcript.Segments = append(transcript.Segments, TranscriptSegment{
+				Text:         audioSegment.Transcript,
+				StartTime:    parseAwsTime(audioSegment.StartTime),
+				EndTime:      parseAwsTime(audioSegment.EndTime),
+				Confidence:   AverageWordConfidence(segmentWords),
+				LanguageCode: output.Results.LanguageCode,
+				Speaker:      speaker,
+				Words:        segmentWords,
+			})
+		}
+		return transcript, nil
+	}
+
+	// no audio segments -> split words into speaker turns (if available) and sentences
+	for _, speakerWords := range SplitWordsBySpeaker(words) {
+		segmentStart := 0
+		for i, word := range speakerWords {
+			isLastWord := i == len(speakerWords)-1
+			if isLastWord || (word.IsPunctuation && strings.ContainsAny(word.Text, ".?!")) {
+				transcript.Segments = append(transcript.Segments, createSegment(speakerWords[segmentStart:i+1], output.Results.LanguageCode))
+				segmentStart = i + 1
+			}
+		}
+	}
+	return transcript, nil
+}
+
+// getSpeakersByStartTime returns the speaker labels of the given AWS Transcribe output by the start time of the
+// labeled words. Depending on the output version, the speaker labels are either contained in the items, or in the
+// separate speaker label segments.
+func getSpeakersByStartTime(output awsTranscriptOutput) map[time.Duration]string {
+	speakers := make(map[time.Duration]string)
+	for _, segment := range output.Results.SpeakerLabels.Segments {
+		for _, item := range segment.Items {
+			speakers[parseAwsTime(item.StartTime)] = item.SpeakerLabel
+		}
+	}
+	for _, item := range output.Results.Items {
+		if item.SpeakerLabel != "" && !strings.EqualFold(item.Type, "punctuation") {
+			speakers[parseAwsTime(item.StartTime)] = item.SpeakerLabel
+		}
+	}
+	return speakers
+}
+
+// assignSpeakers sets the speaker labels of the given words (except punctuation marks, which have no timestamps)
+// by their start time.
+func assignSpeakers(words []TranscriptWord, speakers map[time.Duration]string) {
+	if len(speakers) < 1 {
+		return
+	}
+	for i := range words {
+		if words[i].IsPunctuation || words[i].Speaker != "" {
+			continue
+		}
+		words[i].Speaker = speakers[words[i].StartTime]
+	}
+}
+
+// convertItem converts the given AWS transcript item into a TranscriptWord, using its most likely alternative.
+func convertItem(item awsTranscriptItem) TranscriptWord {
+	word := TranscriptWord{
+		StartTime:     parseAwsTime(item.StartTime),
+		EndTime:       parseAwsTime(item.EndTime),
+		IsPunctuation: strings.EqualFold(item.Type, "punctuation"),
+		Speaker:       item.SpeakerLabel,
+		IsProfanity:   item.VocabularyFilterMatch,
+	}
+	if len(item.Alternatives) > 0 {
+		word.Text = item.Alternatives[0].Content
+		word.Confidence = parseAwsConfidence(item.Alternatives[0].Confidence)
+		word.IsRedacted = len(item.Alternatives[0].Redactions) > 0
+	}
+	return word
+}
+
+// getEntities returns the redacted entities of the given AWS transcript items, whose converted words are given as
+// well (see convertItem). Every redacted item is a single entity, which spans the time of the item.
+func getEntities(items []awsTranscriptItem, words []TranscriptWord) []TranscriptEntity {
+	var entities []TranscriptEntity
+	for i, item := range items {
+		if len(item.Alternatives) < 1 {
+			continue
+		}
+		for _, redaction := range item.Alternatives[0].Redactions {
+			entities = append(entities, TranscriptEntity{
+				Type:      RedactionEntityType(redaction.Type),
+				StartTime: words[i].StartTime,
+				EndTime:   words[i].EndTime,
+			})
+		}
+	}
+	return entities
+}
+
+// createSegment creates a transcript segment from the given words.
+// Punctuation marks have no timestamps in the AWS output, so start and end time are taken from the other words.
+func createSegment(words []TranscriptWord, languageCode string) TranscriptSegment {
+	segment := TranscriptSegment{
+		Text:         JoinWords(words),
+		Confidence:   AverageWordConfidence(words),
+		LanguageCode: languageCode,
+		Speaker:      GetSpeaker(words),
+		Words:        words,
+	}
+	first := true
+	for _, word := range words {
+		if word.IsPunctuation {
+			continue
+		}
+		if first {
+			segment.StartTime = word.StartTime
+			first = false
+		}
+		segment.EndTime = word.EndTime
+	}
+	return segment
+}
+
+// parseAwsTime parses a time offset in seconds (e.g. "1.25"), as used in the AWS Transcribe output.
+// If the given string is empty or invalid, 0 is returned.
+func parseAwsTime(seconds string) time.Duration {
+	value, err := strconv.ParseFloat(seconds, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(value * float64(time.Second))
+}
+
+// parseAwsConfidence parses a confidence value (e.g. "0.98"), as used in the AWS Transcribe output.
+// If the given string is empty or invalid, 0 is returned.
+func parseAwsConfidence(confidence string) float32 {
+	value, err := strconv.ParseFloat(confidence, 32)
+	if err != nil {
+		return 0
+	}
+	return float32(value)
+}
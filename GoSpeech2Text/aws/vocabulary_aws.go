@@ -0,0 +1,213 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	transcribe "github.com/aws/aws-sdk-go-v2/service/transcribe"
+	"github.com/aws/aws-sdk-go-v2/service/transcribe/types"
+	"strings"
+	"time"
+)
+
+// CreateVocabulary creates an AWS Transcribe custom vocabulary with the phrases and custom classes of the given
+// vocabulary (see getAwsVocabularyPhrases). The vocabulary is usually ready after a few minutes.
+func (a S2TAmazonWebServices) CreateVocabulary(ctx context.Context, vocabulary Vocabulary) (Vocabulary, error) {
+	if strings.EqualFold(vocabulary.LanguageCode, "") {
+		return vocabulary, errors.New(fmt.Sprintf("Couldn't create custom vocabulary '%s', because AWS vocabularies require a language code.", vocabulary.Id))
+	}
+	output, err := a.s2tClient.CreateVocabulary(ctx, &transcribe.CreateVocabularyInput{
+		VocabularyName: &vocabulary.Id,
+		LanguageCode:   types.LanguageCode(vocabulary.LanguageCode),
+		Phrases:        getAwsVocabularyPhrases(getVocabularyConfig(vocabulary)),
+	})
+	if err != nil {
+		return vocabulary, ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while creating custom vocabulary '%s'", vocabulary.Id)), err))
+	}
+	return updateVocabulary(vocabulary, output.VocabularyName, output.LanguageCode, output.VocabularyState, output.FailureReason, output.LastModifiedTime), nil
+}
+
+// UpdateVocabulary replaces the phrases of the AWS Transcribe custom vocabulary with the ID of the given vocabulary.
+// The vocabulary is pending until the update has been processed.
+func (a S2TAmazonWebServices) UpdateVocabulary(ctx context.Context, vocabulary Vocabulary) (Vocabulary, error) {
+	if strings.EqualFold(vocabulary.LanguageCode, "") {
+		return vocabulary, errors.New(fmt.Sprintf("Couldn't update custom vocabulary '%s', because AWS vocabularies require a language code.", vocabulary.Id))
+	}
+	output, err := a.s2tClient.UpdateVocabulary(ctx, &transcribe.UpdateVocabularyInput{
+		VocabularyName: &vocabulary.Id,
+		LanguageCode:   types.LanguageCode(vocabulary.LanguageCode),
+		Phrases:        getAwsVocabularyPhrases(getVocabularyConfig(vocabulary)),
+	})
+	if err != nil {
+		return vocabulary, getVocabularyError(ctx, vocabulary.Id, "updating", err)
+	}
+	return updateVocabulary(vocabulary, output.VocabularyName, output.LanguageCode, output.VocabularyState, nil, output.LastModifiedTime), nil
+}
+
+// GetVocabulary returns the AWS Transcribe custom vocabulary with the given ID.
+// The phrases aren't returned, but can be downloaded from Vocabulary.DownloadUrl.
+func (a S2TAmazonWebServices) GetVocabulary(ctx context.Context, id string) (Vocabulary, error) {
+	output, err := a.s2tClient.GetVocabulary(ctx, &transcribe.GetVocabularyInput{VocabularyName: &id})
+	if err != nil {
+		return Vocabulary{}, getVocabularyError(ctx, id, "getting", err)
+	}
+	vocabulary := updateVocabulary(Vocabulary{}, output.VocabularyName, output.LanguageCode, output.VocabularyState, output.FailureReason, output.LastModifiedTime)
+	vocabulary.DownloadUrl = aws.ToString(output.DownloadUri)
+	return vocabulary, nil
+}
+
+// ListVocabularies returns all AWS Transcribe custom vocabularies in the region of the service client.
+func (a S2TAmazonWebServices) ListVocabularies(ctx context.Context) ([]Vocabulary, error) {
+	var vocabularies []Vocabulary
+	var nextToken *string = nil
+	for {
+		output, err := a.s2tClient.ListVocabularies(ctx, &transcribe.ListVocabulariesInput{NextToken: nextToken})
+		if err != nil {
+			return nil, ContextError(ctx, errors.Join(errors.New("error while listing custom vocabularies"), err))
+		}
+		for _, info := range output.Vocabularies {
+			vocabularies = append(vocabularies, updateVocabulary(Vocabulary{}, info.VocabularyName, info.LanguageCode, info.VocabularyState, nil, info.LastModifiedTime))
+		}
+		if output.NextToken == nil {
+			return vocabularies, nil
+		}
+		nextToken = output.NextToken
+	}
+}
+
+// DeleteVocabulary deletes the AWS Transcribe custom vocabulary with the given ID.
+func (a S2TAmazonWebServices) DeleteVocabulary(ctx context.Context, id string) error {
+	_, err := a.s2tClient.DeleteVocabulary(ctx, &transcribe.DeleteVocabularyInput{VocabularyName: &id})
+	if err != nil {
+		return getVocabularyError(ctx, id, "deleting", err)
+	}
+	return nil
+}
+
+// updateVocabulary sets the properties of the given vocabulary to the given values of an AWS custom vocabulary.
+func updateVocabulary(vocabulary Vocabulary, name *string, languageCode types.LanguageCode, state types.VocabularyState, failureReason *string, lastModifiedTime *time.Time) Vocabulary {
+	vocabulary.Provider = providers.ProviderAWS
+	vocabulary.Id = aws.ToString(name)
+	vocabulary.Name = vocabulary.Id
+	vocabulary.LanguageCode = string(languageCode)
+	vocabulary.State = getVocabularyState(state)
+	vocabulary.FailureReason = aws.ToString(failureReason)
+	vocabulary.LastModifiedTime = aws.ToTime(lastModifiedTime)
+	return vocabulary
+}
+
+// getVocabularyState converts the given AWS vocabulary state into the provider-independent VocabularyState.
+func getVocabularyState(state types.VocabularyState) VocabularyState {
+	switch state {
+	case types.VocabularyStateReady:
+		return VocabularyStateReady
+	case types.VocabularyStateFailed:
+		return VocabularyStateFailed
+	default:
+		return VocabularyStatePending
+	}
+}
+
+// vocabularyNotFoundMessage is the beginning of the message of the BadRequestException that AWS returns if a custom
+// vocabulary doesn't exist.
+const vocabularyNotFoundMessage = "The requested vocabulary couldn't be found"
+
+// getVocabularyError wraps the given error of the given vocabulary action (e.g. "deleting").
+// If the vocabulary doesn't exist, the error also wraps ErrVocabularyNotFound.
+func getVocabularyError(ctx context.Context, id string, action string, err error) error {
+	err = errors.Join(errors.New(fmt.Sprintf("error while %s custom vocabulary '%s'", action, id)), err)
+	if isVocabularyNotFound(err) {
+		return errors.Join(ErrVocabularyNotFound, err)
+	}
+	return ContextError(ctx, err)
+}
+
+// isVocabularyNotFound returns true if the given error shows that a custom vocabulary doesn't exist.
+// AWS returns a BadRequestException in this case, but a NotFoundException is accepted as well.
+func isVocabularyNotFound(err error) bool {
+	var notFound *types.NotFoundException
+	if errors.As(err, &notFound) {
+		return true
+	}
+	var badRequest *types.BadRequestException
+	return errors.As(err, &badRequest) && strings.HasPrefix(badRequest.ErrorMessage(), vocabularyNotFoundMessage)
+}
+
+// getVocabularyConfig returns a vocabulary config with the phrases and custom classes of the given vocabulary.
+func getVocabularyConfig(vocabulary Vocabulary) VocabularyConfig {
+	return VocabularyConfig{
+		Phrases:       vocabulary.Phrases,
+		CustomClasses: vocabulary.CustomClasses,
+	}
+}
+
+// inlineVocabularyPrefix is the prefix of the names of the custom vocabularies that are created for inline phrases.
+const inlineVocabularyPrefix = "gospeech2text-vocabulary-"
+
+// getInlineVocabularyName returns the name of the custom vocabulary with the given language and phrases, which
+// consists of the language code and a hash of the phrases.
+func getInlineVocabularyName(languageCode string, phrases []string) string {
+	hash := sha256.Sum256([]byte(strings.Join(phrases, "\n")))
+	return inlineVocabularyPrefix + strings.ToLower(languageCode) + "-" + hex.EncodeToString(hash[:])[:16]
+}
+
+// ensureInlineVocabulary creates a custom vocabulary that contains the inline phrases of the vocabulary config of the
+// given options, if it doesn't exist yet, waits until it is ready and returns its name. The name is derived from the
+// language and phrases (see getInlineVocabularyName), so the vocabulary is reused by all transcriptions with the same
+// language and phrases (e.g. the chunks of a file), and is only created once.
+// If the vocabulary has failed, it is deleted again (so that the next transcription creates it again) and an error
+// is returned.
+func (a S2TAmazonWebServices) ensureInlineVocabulary(ctx context.Context, options SpeechToTextOptions) (string, error) {
+	languageCode := options.LanguageConfig.LanguageCode
+	name := getInlineVocabularyName(languageCode, getAwsVocabularyPhrases(options.VocabularyConfig))
+	vocabulary, err := a.GetVocabulary(ctx, name)
+	if errors.Is(err, ErrVocabularyNotFound) {
+		vocabulary, err = a.CreateVocabulary(ctx, Vocabulary{
+			Id:            name,
+			LanguageCode:  languageCode,
+			Phrases:       options.VocabularyConfig.Phrases,
+			CustomClasses: options.VocabularyConfig.CustomClasses,
+		})
+		var conflict *types.ConflictException
+		if errors.As(err, &conflict) { // conflict -> vocabulary has been created concurrently
+			vocabulary, err = a.GetVocabulary(ctx, name)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	vocabulary, err = WaitForVocabulary(ctx, a, vocabulary, options)
+	if err != nil {
+		if vocabulary.State == VocabularyStateFailed {
+			a.deleteFailedVocabulary(name)
+		}
+		return "", err
+	}
+	return name, nil
+}
+
+// deleteFailedVocabulary deletes the custom vocabulary with the given name. Errors are not fatal and are only printed.
+// The vocabulary is deleted even if the context of the transcription is done.
+func (a S2TAmazonWebServices) deleteFailedVocabulary(name string) {
+	err := a.DeleteVocabulary(context.Background(), name)
+	if err != nil {
+		fmt.Printf(errors.Join(errors.New(fmt.Sprintf("A non-fatal error occurred while deleting the failed custom vocabulary '%s'.", name)), err).Error())
+	}
+}
+
+// getAwsVocabularyPhrases converts the inline phrases of the given vocabulary config into the phrases of an AWS
+// custom vocabulary. AWS doesn't support custom classes, so class references are expanded (see
+// VocabularyConfig.GetExpandedPhrases). The words of multi-word phrases are separated by hyphens, as required by AWS.
+func getAwsVocabularyPhrases(config VocabularyConfig) []string {
+	var phrases []string
+	for _, phrase := range config.GetExpandedPhrases() {
+		phrases = append(phrases, strings.Join(strings.Fields(phrase), "-"))
+	}
+	return phrases
+}
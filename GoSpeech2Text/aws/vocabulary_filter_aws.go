@@ -0,0 +1,106 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	transcribe "github.com/aws/aws-sdk-go-v2/service/transcribe"
+	"github.com/aws/aws-sdk-go-v2/service/transcribe/types"
+	"strings"
+)
+
+// vocabularyFilterPrefix is the prefix of the names of the vocabulary filters that are created for profanity filtering.
+const vocabularyFilterPrefix = "gospeech2text-profanity-"
+
+// getProfanityFilterLanguages returns the languages for which a vocabulary filter is needed, i.e. the language code
+// of the given options or (if the language is identified automatically) the language options.
+// Vocabulary filters can't be used if the language is identified automatically without language options.
+func getProfanityFilterLanguages(options SpeechToTextOptions) ([]string, error) {
+	if !strings.EqualFold(options.LanguageConfig.LanguageCode, "") {
+		return []string{options.LanguageConfig.LanguageCode}, nil
+	}
+	var languages []string
+	for _, languageOption := range options.LanguageConfig.LanguageOptions {
+		languages = append(languages, *languageOption)
+	}
+	if len(languages) < 1 {
+		return nil, errors.New("Couldn't filter profanities, because AWS vocabulary filters require a language code or language options.")
+	}
+	return languages, nil
+}
+
+// getProfanityFilters returns the names of the vocabulary filters that are used to filter profanities, one for every
+// language of getProfanityFilterLanguages (in the same order). If ProfanityFilterConfig.VocabularyFilterName is
+// set, this filter is used for every language. Otherwise, the filters are created if they don't exist yet.
+// If profanities aren't filtered, nil is returned.
+func (a S2TAmazonWebServices) getProfanityFilters(ctx context.Context, options SpeechToTextOptions) ([]string, error) {
+	if !options.ProfanityFilter {
+		return nil, nil
+	}
+	languages, err := getProfanityFilterLanguages(options)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, language := range languages {
+		name := options.ProfanityFilterConfig.VocabularyFilterName
+		if strings.EqualFold(name, "") {
+			name, err = a.ensureVocabularyFilter(ctx, language, options.ProfanityFilterConfig.GetWords())
+			if err != nil {
+				return nil, err
+			}
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// ensureVocabularyFilter creates a vocabulary filter with the given language and words, if it doesn't exist yet, and
+// returns its name. The name is derived from the language and words (see getVocabularyFilterName), so the filter is
+// reused by all transcriptions with the same language and words. Vocabulary filters are ready immediately.
+func (a S2TAmazonWebServices) ensureVocabularyFilter(ctx context.Context, languageCode string, words []string) (string, error) {
+	name := getVocabularyFilterName(languageCode, words)
+	_, err := a.s2tClient.GetVocabularyFilter(ctx, &transcribe.GetVocabularyFilterInput{VocabularyFilterName: &name})
+	if err == nil {
+		return name, nil
+	}
+	var notFound *types.NotFoundException
+	if !errors.As(err, &notFound) {
+		return "", ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while getting vocabulary filter '%s'", name)), err))
+	}
+
+	_, err = a.s2tClient.CreateVocabularyFilter(ctx, &transcribe.CreateVocabularyFilterInput{
+		VocabularyFilterName: &name,
+		LanguageCode:         types.LanguageCode(languageCode),
+		Words:                words,
+	})
+	var conflict *types.ConflictException
+	if err != nil && !errors.As(err, &conflict) { // conflict -> filter has been created concurrently
+		return "", ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while creating vocabulary filter '%s'", name)), err))
+	}
+	return name, nil
+}
+
+// getVocabularyFilterName returns the name of the vocabulary filter with the given language and words, which consists
+// of the language code and a hash of the words.
+func getVocabularyFilterName(languageCode string, words []string) string {
+	hash := sha256.Sum256([]byte(strings.Join(words, "\n")))
+	return vocabularyFilterPrefix + strings.ToLower(languageCode) + "-" + hex.EncodeToString(hash[:])[:16]
+}
+
+// getLanguageIdSettings returns the language-specific settings with the given vocabulary filters, if the language is
+// identified automatically. Otherwise, the filter is specified in the job settings (see getAwsSettings), and nil is
+// returned.
+func getLanguageIdSettings(options SpeechToTextOptions, profanityFilters []string) map[string]types.LanguageIdSettings {
+	if len(profanityFilters) < 1 || !strings.EqualFold(options.LanguageConfig.LanguageCode, "") {
+		return nil
+	}
+	languageIdSettings := make(map[string]types.LanguageIdSettings)
+	for i, languageOption := range options.LanguageConfig.LanguageOptions {
+		languageIdSettings[*languageOption] = types.LanguageIdSettings{VocabularyFilterName: &profanityFilters[i]}
+	}
+	return languageIdSettings
+}
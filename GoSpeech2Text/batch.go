@@ -0,0 +1,175 @@
+package GoText2Speech
+
+import (
+	"context"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BatchItem is a single transcription of a batch (see S2TBatch).
+type BatchItem struct {
+	_ struct{}
+	// Source is the location of the audio file. Sources are handled in the same way as in S2T.
+	Source string
+	// Destination is the location where the transcript is stored. If it is empty, the transcript is returned
+	// via BatchItemResult.Result instead (like S2TDirect).
+	Destination string
+	Options     SpeechToTextOptions
+}
+
+// BatchOptions configures the execution of a batch (see S2TBatch).
+type BatchOptions struct {
+	_ struct{}
+	// MaxConcurrency is the maximum number of items that are transcribed at the same time.
+	// Default value is DefaultBatchMaxConcurrency.
+	MaxConcurrency int
+	// RateLimits specifies for each provider the maximum number of transcriptions that are started per second.
+	// Providers without a (positive) rate limit are not limited.
+	RateLimits map[providers.Provider]float64
+}
+
+const DefaultBatchMaxConcurrency = 8
+
+// GetMaxConcurrency returns the maximum number of concurrent transcriptions, using the default value if it is undefined.
+func (o BatchOptions) GetMaxConcurrency() int {
+	if o.MaxConcurrency <= 0 {
+		return DefaultBatchMaxConcurrency
+	}
+	return o.MaxConcurrency
+}
+
+// BatchItemResult is the result of a single item of a batch.
+type BatchItemResult struct {
+	// Index is the index of the item in the slice that has been passed to S2TBatch.
+	Index int
+	Item  BatchItem
+	// Result contains the transcript of the item if it has no destination. If the transcription of the item failed,
+	// Result.Err contains the error.
+	Result S2TDirectResult
+}
+
+// S2TBatch transcribes the given items with at most opts.MaxConcurrency transcriptions at the same time.
+// The results are delivered via the returned channel in the order in which the transcriptions complete (see
+// BatchItemResult.Index). The channel is closed as soon as the results of all items have been delivered and must be
+// drained by the caller.
+// If the transcription of an item fails, the error is reported in its result and the remaining items are still
+// transcribed.
+// Service clients are cached per provider and region, so items can run in different regions concurrently.
+func (a GoS2TClient) S2TBatch(items []BatchItem, opts BatchOptions) <-chan BatchItemResult {
+	return a.S2TBatchWithContext(context.Background(), items, opts)
+}
+
+// S2TBatchWithContext works like S2TBatch, but all transcriptions are bound to the given context.
+// If the context is cancelled or its deadline is exceeded, the results of all items that haven't completed yet
+// contain ctx.Err().
+func (a GoS2TClient) S2TBatchWithContext(ctx context.Context, items []BatchItem, opts BatchOptions) <-chan BatchItemResult {
+	maxConcurrency := opts.GetMaxConcurrency()
+	r := make(chan BatchItemResult, maxConcurrency)
+
+	limiters := make(map[providers.Provider]*rateLimiter)
+	for provider, rate := range opts.RateLimits {
+		if rate > 0 {
+			limiters[provider] = newRateLimiter(rate)
+		}
+	}
+
+	indexes := make(chan int)
+	go func() {
+		defer close(indexes)
+		for i := range items {
+			indexes <- i
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < maxConcurrency && w < len(items); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				r <- BatchItemResult{
+					Index:  i,
+					Item:   items[i],
+					Result: a.transcribeBatchItem(ctx, i, items[i], limiters),
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(r)
+	}()
+	return r
+}
+
+// transcribeBatchItem transcribes the given item after waiting for the rate limiter of its provider (if any).
+// Since the items of a batch may share their options, the job name is made unique with the index of the item.
+func (a GoS2TClient) transcribeBatchItem(ctx context.Context, index int, item BatchItem, limiters map[providers.Provider]*rateLimiter) S2TDirectResult {
+	if err := ctx.Err(); err != nil {
+		return S2TDirectResult{Err: err}
+	}
+
+	options := item.Options
+	options.TranscriptionJobName = options.TranscriptionJobName.WithIndex(index)
+	if options.Provider == providers.ProviderUnspecified {
+		var err error
+		options, err = a.determineProvider(ctx, options, item.Source)
+		if err != nil {
+			return S2TDirectResult{Err: err}
+		}
+	}
+	if limiter, ok := limiters[options.Provider]; ok {
+		if err := limiter.wait(ctx); err != nil {
+			return S2TDirectResult{Err: err}
+		}
+	}
+
+	if strings.EqualFold(item.Destination, "") {
+		return (<-a.S2TDirectWithContext(ctx, item.Source, options)).Result
+	}
+	_, err := a.S2TWithContext(ctx, item.Source, item.Destination, options)
+	return S2TDirectResult{Err: err}
+}
+
+// rateLimiter spaces out events such that at most a given number of events happen per second.
+type rateLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	// next is the earliest time at which the next event may happen.
+	next time.Time
+}
+
+func newRateLimiter(eventsPerSecond float64) *rateLimiter {
+	return &rateLimiter{
+		interval: time.Duration(float64(time.Second) / eventsPerSecond),
+	}
+}
+
+// wait blocks until the next event may happen or the context is done (in which case ctx.Err() is returned).
+// The time slot is reserved even if the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mutex.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mutex.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
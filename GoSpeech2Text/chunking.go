@@ -0,0 +1,224 @@
+package GoText2Speech
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	"os"
+	"strings"
+	"sync"
+)
+
+// shouldChunk returns true if the given source should be transcribed in chunks, i.e. if chunking is enabled
+// (see SpeechToTextOptions.ChunkingConfig) and the source is a local file or file from some other URL that can be
+// decoded locally.
+func (a GoS2TClient) shouldChunk(ctx context.Context, source string, options SpeechToTextOptions) bool {
+	if !options.ChunkingConfig.Enabled || a.IsProviderStorageUrl(source) {
+		return false
+	}
+	return CanDecodeAudio(GetAudioFileType(ctx, source), options.AudioFormat)
+}
+
+// s2tChunked works like S2TWithContext, but transcribes the given source in chunks (see s2tDirectChunked).
+// Since there is no result file of the provider, the transcript is stored as JSON if no output format is specified.
+func (a GoS2TClient) s2tChunked(ctx context.Context, source string, destination string, options SpeechToTextOptions) (GoS2TClient, error) {
+	var result S2TDirectResult
+	a, result = a.s2tDirectChunked(ctx, source, destination, options)
+	if result.Err != nil {
+		return a, result.Err
+	}
+
+	format := ResolveOutputFormat(options, destination)
+	if format == OutputFormatUnspecified {
+		format = OutputFormatJson
+	}
+	content, err := FormatTranscript(*result.Transcript, format)
+	if err != nil {
+		return a, err
+	}
+	err = a.writeToDestination(ctx, content, destination)
+	if err != nil || result.UnredactedTranscript == nil {
+		return a, err
+	}
+	return a, a.writeUnredactedTranscript(ctx, *result.UnredactedTranscript, format, destination)
+}
+
+// s2tDirectChunked transcribes the given source in chunks (see SplitAudio), which are stored as local temporary WAV
+// files and transcribed concurrently, as configured by options.ChunkingConfig.
+// The transcripts of the chunks are merged into a single transcript (see MergeChunkTranscripts), which is redacted
+// locally if the provider can't redact content natively.
+// If the transcription of any chunk fails, the transcription of the remaining chunks is cancelled and the error is
+// returned.
+func (a GoS2TClient) s2tDirectChunked(ctx context.Context, source string, destination string, options SpeechToTextOptions) (GoS2TClient, S2TDirectResult) {
+	chunks, err := splitSource(ctx, source, options)
+	if err != nil {
+		return a, S2TDirectResult{Err: err}
+	}
+	chunkFiles, err := writeChunkFiles(chunks)
+	defer func() {
+		for _, chunkFile := range chunkFiles {
+			removeTempLocalFile(chunkFile)
+		}
+	}()
+	if err != nil {
+		return a, S2TDirectResult{Err: err}
+	}
+
+	// the chunks are WAV files that have already been converted
+	chunkOptions := options
+	chunkOptions.AudioFormat = AudioFormat{}
+	chunkOptions.TranscodingConfig.SampleRateHertz = 0
+	chunkOptions.TranscodingConfig.ConvertToMono = false
+	chunkOptions.ChunkingConfig = ChunkingConfig{}
+	if chunkOptions.Provider == providers.ProviderUnspecified {
+		chunkOptions, err = a.determineProvider(ctx, chunkOptions, chunkFiles[0])
+		if err != nil {
+			return a, S2TDirectResult{Err: err}
+		}
+	}
+	// local redaction is applied to the merged transcript, so that entities that span multiple chunks are found
+	redaction := getLocalRedaction(chunkOptions)
+	if redaction != nil {
+		chunkOptions.ContentRedactionConfig = ContentRedactionConfig{}
+	}
+
+	// The first chunk is transcribed on its own, which sets the region and creates the service client.
+	// Afterwards, the client is only read by the concurrent transcriptions of the remaining chunks.
+	transcripts := make([]Transcript, len(chunks))
+	unredactedTranscripts := make([]*Transcript, len(chunks))
+	a, transcripts[0], unredactedTranscripts[0], err = a.transcribeChunk(ctx, 0, chunks[0], chunkFiles[0], destination, chunkOptions)
+	if err != nil {
+		return a, S2TDirectResult{Err: err}
+	}
+
+	chunkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var firstErr error = nil
+	var errOnce sync.Once
+	var waitGroup sync.WaitGroup
+	semaphore := make(chan struct{}, options.ChunkingConfig.GetMaxConcurrency())
+	for i := 1; i < len(chunks); i++ {
+		waitGroup.Add(1)
+		go func(i int) {
+			defer waitGroup.Done()
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-chunkCtx.Done():
+				return
+			}
+			var errChunk error
+			_, transcripts[i], unredactedTranscripts[i], errChunk = a.transcribeChunk(chunkCtx, i, chunks[i], chunkFiles[i], destination, chunkOptions)
+			if errChunk != nil {
+				errOnce.Do(func() {
+					firstErr = errors.Join(errors.New(fmt.Sprintf("error while transcribing chunk %d of the source file '%s'", i+1, source)), errChunk)
+					cancel()
+				})
+			}
+		}(i)
+	}
+	waitGroup.Wait()
+	if firstErr != nil {
+		return a, S2TDirectResult{Err: ContextError(ctx, firstErr)}
+	}
+	// chunks that were still waiting for the semaphore when the context was done have not been transcribed
+	if err = ctx.Err(); err != nil {
+		return a, S2TDirectResult{Err: err}
+	}
+
+	merged := MergeChunkTranscripts(transcripts, chunks)
+	return a, redactResult(S2TDirectResult{
+		Text:                 merged.Text,
+		Transcript:           &merged,
+		UnredactedTranscript: mergeUnredactedChunkTranscripts(unredactedTranscripts, chunks),
+	}, redaction)
+}
+
+// mergeUnredactedChunkTranscripts merges the unredacted transcripts of the given chunks, if the provider has
+// returned them for all chunks (see S2TDirectResult.UnredactedTranscript). Otherwise, nil is returned.
+func mergeUnredactedChunkTranscripts(unredactedTranscripts []*Transcript, chunks []AudioChunk) *Transcript {
+	transcripts := make([]Transcript, len(unredactedTranscripts))
+	for i, transcript := range unredactedTranscripts {
+		if transcript == nil {
+			return nil
+		}
+		transcripts[i] = *transcript
+	}
+	merged := MergeChunkTranscripts(transcripts, chunks)
+	return &merged
+}
+
+// splitSource decodes the given local file or file from some other URL and splits it into chunks.
+// If transcoding is enabled, the audio is converted before it is split (see SpeechToTextOptions.TranscodingConfig).
+func splitSource(ctx context.Context, source string, options SpeechToTextOptions) ([]AudioChunk, error) {
+	content, err := ReadSourceContent(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	fileType := DetectAudioFileType(content)
+	if strings.EqualFold(fileType, "") {
+		fileType = GetFileTypeFromFileName(source)
+	}
+	audio, err := DecodeAudio(content, fileType, options.AudioFormat)
+	if err != nil {
+		return nil, errors.Join(errors.New(fmt.Sprintf("Couldn't split the source file '%s' into chunks.", source)), err)
+	}
+	if options.TranscodingConfig.Enabled {
+		audio = ConvertAudio(audio, options.TranscodingConfig)
+	}
+	return SplitAudio(audio, options.ChunkingConfig), nil
+}
+
+// writeChunkFiles stores the given chunks in local temporary WAV files and returns their paths.
+// If an error occurs, the paths of the files that have already been written are returned as well.
+func writeChunkFiles(chunks []AudioChunk) ([]string, error) {
+	var chunkFiles []string
+	for _, chunk := range chunks {
+		content, errEncode := EncodeAudio(chunk.Audio, "wav")
+		if errEncode != nil {
+			return chunkFiles, errEncode
+		}
+		tmpFile, errTmpFile := os.CreateTemp("", "s2t-chunk-*.wav")
+		if errTmpFile != nil {
+			return chunkFiles, errTmpFile
+		}
+		chunkFiles = append(chunkFiles, tmpFile.Name())
+		_, errWrite := tmpFile.Write(content)
+		errClose := tmpFile.Close()
+		if errWrite != nil || errClose != nil {
+			return chunkFiles, errors.Join(errors.New("error while writing audio chunk to temporary file"), errWrite, errClose)
+		}
+	}
+	return chunkFiles, nil
+}
+
+// transcribeChunk transcribes the given chunk, which is stored in the given local file, in the same way as S2TDirect.
+// If the provider only returns the text, the transcript consists of a single segment that spans the whole chunk.
+// The unredacted transcript of the chunk is returned as well (see S2TDirectResult.UnredactedTranscript).
+// The transcription job of the chunk is named after the given index of the chunk, since job names must be unique
+// (see TranscriptionJobNameConfig.WithIndex).
+func (a GoS2TClient) transcribeChunk(ctx context.Context, index int, chunk AudioChunk, chunkFile string, destination string, options SpeechToTextOptions) (GoS2TClient, Transcript, *Transcript, error) {
+	options.TranscriptionJobName = options.TranscriptionJobName.WithIndex(index + 1)
+	a, prepared, err := a.prepareSource(ctx, chunkFile, destination, options, false)
+	if err != nil {
+		return a, Transcript{}, nil, err
+	}
+	result := prepared.processResult(<-prepared.provider.ExecuteS2TDirect(ctx, prepared.source, prepared.options))
+	a.deleteTempFiles(prepared)
+	if result.Err != nil {
+		return a, Transcript{}, nil, result.Err
+	}
+	if result.Transcript != nil {
+		return a, *result.Transcript, result.UnredactedTranscript, nil
+	}
+	return a, Transcript{
+		Text: result.Text,
+		Segments: []TranscriptSegment{{
+			Text:      result.Text,
+			StartTime: 0,
+			EndTime:   chunk.Audio.GetDuration(),
+		}},
+	}, nil, nil
+}
@@ -0,0 +1,133 @@
+package aws
+
+import (
+	speechpb "cloud.google.com/go/speech/apiv2/speechpb"
+	"context"
+	"errors"
+	"fmt"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"sync"
+)
+
+// implicitRecognizerId is the ID of the implicit recognizer, which exists in every location and is configured
+// entirely by the request.
+const implicitRecognizerId = "_"
+
+// recognizerCache contains the names of the recognizers that are known to exist.
+// It is shared by all copies of a provider instance, so recognizers are only looked up once per GoS2TClient.
+type recognizerCache struct {
+	mutex sync.Mutex
+	names map[string]bool
+}
+
+func newRecognizerCache() *recognizerCache {
+	return &recognizerCache{names: make(map[string]bool)}
+}
+
+func (c *recognizerCache) contains(name string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.names[name]
+}
+
+func (c *recognizerCache) add(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.names[name] = true
+}
+
+// usesImplicitRecognizer returns true if no recognizer ID is specified in the given options. In that case, the model
+// and language are passed in the recognition config of every request (see getRecognitionConfig).
+func usesImplicitRecognizer(options SpeechToTextOptions) bool {
+	return strings.EqualFold(options.RecognizerConfig.RecognizerId, "")
+}
+
+// getRecognizerModelAndLanguage returns the model and language code specified in the given options.
+// If no model is specified, DefaultRecognizerModel is returned. If no language code is specified in
+// options.RecognizerConfig, options.LanguageConfig.LanguageCode is returned, which might be empty.
+func getRecognizerModelAndLanguage(options SpeechToTextOptions) (string, string) {
+	config := options.RecognizerConfig
+	model := config.Model
+	if strings.EqualFold(model, "") {
+		model = DefaultRecognizerModel
+	}
+	languageCode := config.LanguageCode
+	if strings.EqualFold(languageCode, "") {
+		languageCode = options.LanguageConfig.LanguageCode
+	}
+	return model, languageCode
+}
+
+// getRecognizerName returns the full name of the recognizer specified in the given options, i.e.
+// "projects/{project}/locations/{location}/recognizers/{id}". Undefined properties of options.RecognizerConfig are
+// replaced by the project and location of the service client and the implicit recognizer.
+func (a S2TGoogleCloudPlatform) getRecognizerName(options SpeechToTextOptions) string {
+	config := options.RecognizerConfig
+	projectId := a.projectId
+	if !strings.EqualFold(config.ProjectId, "") {
+		projectId = config.ProjectId
+	}
+	location := a.location
+	if !strings.EqualFold(config.Location, "") {
+		location = config.Location
+	}
+	recognizerId := implicitRecognizerId
+	if !strings.EqualFold(config.RecognizerId, "") {
+		recognizerId = config.RecognizerId
+	}
+	return fmt.Sprintf("projects/%s/locations/%s/recognizers/%s", projectId, location, recognizerId)
+}
+
+// getRecognizer returns the name of the recognizer that should be used for the given options.
+// If no recognizer ID is specified, the implicit recognizer is used. Otherwise, the specified recognizer is
+// created if it doesn't exist yet. Existing recognizers are cached, so they are only looked up once.
+func (a S2TGoogleCloudPlatform) getRecognizer(ctx context.Context, options SpeechToTextOptions) (string, error) {
+	name := a.getRecognizerName(options)
+	if usesImplicitRecognizer(options) || a.recognizers.contains(name) {
+		return name, nil
+	}
+
+	_, err := a.s2tClient.GetRecognizer(ctx, &speechpb.GetRecognizerRequest{Name: name})
+	if err == nil {
+		a.recognizers.add(name)
+		return name, nil
+	}
+	if status.Code(err) != codes.NotFound {
+		return "", ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while looking up recognizer '%s'", name)), err))
+	}
+
+	if err = a.createRecognizer(ctx, name, options); err != nil {
+		return "", err
+	}
+	a.recognizers.add(name)
+	return name, nil
+}
+
+// createRecognizer creates the recognizer with the given name, using the model and language of the given options.
+// It waits for the long-running operation to finish.
+func (a S2TGoogleCloudPlatform) createRecognizer(ctx context.Context, name string, options SpeechToTextOptions) error {
+	model, languageCode := getRecognizerModelAndLanguage(options)
+	if strings.EqualFold(languageCode, "") {
+		return errors.New(fmt.Sprintf("Couldn't create recognizer '%s', because no language code is specified.", name))
+	}
+
+	parent, _, _ := strings.Cut(name, "/recognizers/")
+	op, err := a.s2tClient.CreateRecognizer(ctx, &speechpb.CreateRecognizerRequest{
+		Parent:       parent,
+		RecognizerId: options.RecognizerConfig.RecognizerId,
+		Recognizer: &speechpb.Recognizer{
+			Model:         model,
+			LanguageCodes: []string{languageCode},
+		},
+	})
+	if err == nil {
+		_, err = op.Wait(ctx)
+	}
+	if err != nil && status.Code(err) != codes.AlreadyExists { // recognizer might have been created concurrently
+		return ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while creating recognizer '%s'", name)), err))
+	}
+	return nil
+}
@@ -7,31 +7,124 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/FaaSTools/GoStorage/gostorage"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
 	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	"google.golang.org/api/option"
+	"google.golang.org/protobuf/encoding/protojson"
 	"io"
-	"os"
+	"regexp"
 	"strings"
+	"time"
 )
 
 type S2TGoogleCloudPlatform struct {
 	s2tClient *speech.Client
+	region    string
+	// projectId and location are the project and location of the Speech-to-Text requests.
+	projectId string
+	location  string
+	// recognizers caches the names of recognizers that are known to exist.
+	recognizers *recognizerCache
+	// storageOptions are the options of the Google Cloud Storage client (credentials and StorageClientOptions).
+	storageOptions []option.ClientOption
+	// ProjectId is the GCP project that is used for Speech-to-Text requests.
+	// If it is empty, the project of CredentialsHolder.GoogleCredentials is used.
+	ProjectId string
+	// ClientOptions are passed to the GCP Speech-to-Text client, e.g. option.WithEndpoint to use a local emulator
+	// (see s2ttest.SpeechEmulator) or option.WithCredentialsFile to use specific credentials.
+	// To use client options, pass a provider instance to GoS2TClient.WithProviderInstance.
+	ClientOptions []option.ClientOption
+	// StorageClientOptions are passed to the Google Cloud Storage client, which is used to store results and
+	// temporary audio files.
+	StorageClientOptions []option.ClientOption
 }
 
+func init() {
+	providers.Register(providers.ProviderGCP, func() interface{} { return S2TGoogleCloudPlatform{} }, []providers.Capability{
+		providers.CapabilityAutomaticPunctuation,
+		providers.CapabilitySpokenPunctuation,
+		providers.CapabilitySpokenEmojis,
+		providers.CapabilityProfanityFilter,
+		providers.CapabilitySpeakerDiarization,
+		providers.CapabilityRawAudio,
+		providers.CapabilityStreaming,
+	}, gostorage.ProviderGoogle)
+}
+
+// GetDefaultRegion returns an empty region, i.e. the global endpoint and location are used (see CreateServiceClient).
 func (a S2TGoogleCloudPlatform) GetDefaultRegion() string {
-	return "us-east1"
+	return ""
 }
 
-func (a S2TGoogleCloudPlatform) CreateServiceClient(credentials CredentialsHolder, region string) (S2TProvider, error) {
-	ctx := context.Background()
-	client, err := speech.NewClient(ctx)
+// CreateServiceClient creates a GCP Speech-to-Text client that uses the given Google credentials (if they are set,
+// otherwise Application Default Credentials are used) and the endpoint of the given region.
+// Recognition is executed in the location of the given region (e.g. "europe-west4" or the multi-region "eu").
+// If region is empty, "global" or not a GCP location (e.g. an AWS region like "eu-central-1"), the global endpoint
+// and location are used.
+// ClientOptions and StorageClientOptions are applied after the default options, so they can override them.
+func (a S2TGoogleCloudPlatform) CreateServiceClient(ctx context.Context, credentials CredentialsHolder, region string) (S2TProvider, error) {
+	projectId := a.ProjectId
+	if strings.EqualFold(projectId, "") && credentials.GoogleCredentials != nil {
+		projectId = credentials.GoogleCredentials.ProjectID
+	}
+	if strings.EqualFold(projectId, "") {
+		return a, errors.New("Couldn't create GCP service client, because no project ID is specified. Either set ProjectId or use Google credentials that contain a project ID.")
+	}
+
+	location := getLocation(region)
+	var defaultOptions []option.ClientOption
+	if credentials.GoogleCredentials != nil {
+		defaultOptions = append(defaultOptions, option.WithCredentials(credentials.GoogleCredentials))
+	}
+	a.storageOptions = append(append([]option.ClientOption{}, defaultOptions...), a.StorageClientOptions...)
+	if !strings.EqualFold(location, globalLocation) {
+		defaultOptions = append(defaultOptions, option.WithEndpoint(getRegionalEndpoint(location)))
+	}
+
+	client, err := speech.NewClient(ctx, append(defaultOptions, a.ClientOptions...)...)
 	if err != nil {
-		return a, err
+		return a, ContextError(ctx, err)
 	}
 	a.s2tClient = client
+	a.region = region
+	a.projectId = projectId
+	a.location = location
+	if a.recognizers == nil {
+		a.recognizers = newRecognizerCache()
+	}
 	return a, nil
 }
 
-func (a S2TGoogleCloudPlatform) TransformOptions(text string, options SpeechToTextOptions) (string, SpeechToTextOptions, error) {
+// globalLocation is the location of the global Speech-to-Text endpoint.
+const globalLocation = "global"
+
+// locationPattern matches the names of GCP locations, i.e. regions (e.g. "europe-west4") and multi-regions
+// (e.g. "eu"). Regions of other providers (e.g. the AWS region "eu-central-1") don't match.
+var locationPattern = regexp.MustCompile(`^[a-z]+(-[a-z]+[0-9]+)?$`)
+
+// getLocation returns the Speech-to-Text location of the given region. Empty regions and regions that aren't
+// GCP locations (see locationPattern) are mapped to globalLocation.
+func getLocation(region string) string {
+	location := strings.ToLower(region)
+	if !locationPattern.MatchString(location) {
+		return globalLocation
+	}
+	return location
+}
+
+// getRegionalEndpoint returns the endpoint of the Speech-to-Text service in the given location,
+// e.g. "eu-speech.googleapis.com:443" for location "eu".
+func getRegionalEndpoint(location string) string {
+	return location + "-speech.googleapis.com:443"
+}
+
+// TransformOptions checks that the given options can be used on GCP. Raw audio (see AudioFormat) requires a sample
+// rate, since it is decoded with an explicit decoding config (see setDecodingConfig).
+func (a S2TGoogleCloudPlatform) TransformOptions(ctx context.Context, text string, options SpeechToTextOptions) (string, SpeechToTextOptions, error) {
+	if options.AudioFormat.IsRaw() && options.AudioFormat.SampleRateHertz <= 0 {
+		return text, options, errors.New(fmt.Sprintf("Couldn't run transcription, because the sample rate of the raw audio with encoding '%s' isn't specified.", options.AudioFormat.Encoding))
+	}
 	return text, options, nil
 }
 
@@ -40,22 +133,22 @@ func (a S2TGoogleCloudPlatform) TransformOptions(text string, options SpeechToTe
 // The source string can either be an AWS S3 URI (starting with "s3://") or AWS S3 Object URL (starting with "https://").
 // If an error occurs, returns empty string and error.
 // If no error occurs, error return value is nil.
-func (a S2TGoogleCloudPlatform) ExecuteS2T(sourceUrl string, destination string, options SpeechToTextOptions) error {
-	r := <-a.ExecuteS2TDirect(sourceUrl, options)
+func (a S2TGoogleCloudPlatform) ExecuteS2T(ctx context.Context, sourceUrl string, destination string, options SpeechToTextOptions) error {
+	r := <-a.ExecuteS2TDirect(ctx, sourceUrl, options)
 	if r.Err != nil {
 		return r.Err
 	}
 
 	// store file on destination
-	storageClient, err3 := storage.NewClient(context.Background())
+	storageClient, err3 := storage.NewClient(ctx, a.storageOptions...)
 	if err3 != nil {
 		fmt.Println(err3)
-		return err3
+		return ContextError(ctx, err3)
 	}
 
 	obj := ParseGoogleUrl(destination)
 	cloudObj := storageClient.Bucket(obj.Bucket).Object(obj.Key)
-	wc := cloudObj.NewWriter(context.Background())
+	wc := cloudObj.NewWriter(ctx)
 
 	outTextReader, strToReaderErr := StringToReader(r.Text)
 	if strToReaderErr != nil {
@@ -63,85 +156,64 @@ func (a S2TGoogleCloudPlatform) ExecuteS2T(sourceUrl string, destination string,
 	}
 
 	if _, err4 := io.Copy(wc, outTextReader); err4 != nil {
-		return fmt.Errorf("io.Copy: %w", err4)
+		return ContextError(ctx, fmt.Errorf("io.Copy: %w", err4))
 	}
 	if err5 := wc.Close(); err5 != nil {
-		return fmt.Errorf("Writer.Close: %w", err5)
+		return ContextError(ctx, fmt.Errorf("Writer.Close: %w", err5))
 	}
 	defer storageClient.Close()
 	return nil
 }
 
-// ExecuteS2TDirect executes Speech-to-Text using AWS Transcribe service. The audio file on the given URL is transcribed into text
+// ExecuteS2TDirect executes Speech-to-Text using GCP Speech-to-Text service. The audio file on the given URL is transcribed into text
 // using the given options. The created text is returned by this function.
-// The source string can either be an AWS S3 URI (starting with "s3://") or AWS S3 Object URL (starting with "https://").
+// The source string can either be a Google Cloud Storage URL, a public URL or a local file path.
+// Depending on options.RecognitionMode, the synchronous Recognize method or the long-running BatchRecognize method is used.
 // If an error occurs, returns empty string and error.
 // If no error occurs, error return value is nil.
-func (a S2TGoogleCloudPlatform) ExecuteS2TDirect(sourceUrl string, options SpeechToTextOptions) <-chan S2TDirectResult {
+func (a S2TGoogleCloudPlatform) ExecuteS2TDirect(ctx context.Context, sourceUrl string, options SpeechToTextOptions) <-chan S2TDirectResult {
 	r := make(chan S2TDirectResult)
 
 	go func() {
 		defer close(r)
 
 		req := &speechpb.RecognizeRequest{
-			Recognizer: "",
-			Config: &speechpb.RecognitionConfig{
-				DecodingConfig: nil,
-				Features: &speechpb.RecognitionFeatures{
-					EnableSpokenEmojis:         options.EnableSpokenEmojis,
-					EnableSpokenPunctuation:    options.EnableSpokenPunctuation,
-					EnableAutomaticPunctuation: options.EnableAutomaticPunctuation,
-					ProfanityFilter:            options.ProfanityFilter,
-				},
-				Adaptation: nil,
-			},
+			Config:     getRecognitionConfig(options),
 			ConfigMask: nil,
 		}
 
 		if IsGoogleUrl(sourceUrl) {
+			if options.RecognitionMode != RecognitionModeSync {
+				r <- a.executeBatchRecognize(ctx, sourceUrl, options)
+				return
+			}
 			req.AudioSource = &speechpb.RecognizeRequest_Uri{
 				Uri: sourceUrl,
 			}
 		} else {
-			var content []byte = nil
-
-			if strings.HasPrefix(sourceUrl, "http") { // file somewhere else online
-				reader, errDownload := ReadFromUrl(sourceUrl)
-				if errDownload != nil {
-					r <- S2TDirectResult{
-						Text: "",
-						Err:  errDownload,
-					}
-					return
+			content, errContent := ReadSourceContent(ctx, sourceUrl)
+			if errContent != nil {
+				r <- S2TDirectResult{
+					Text: "",
+					Err:  errContent,
 				}
+				return
+			}
 
-				// close reader after function call ended
-				defer func(Reader io.ReadCloser) {
-					errClose := Reader.Close()
-					if errClose != nil {
-						fmt.Printf(errors.Join(errors.New(fmt.Sprintf("A non-fatal error occurred while closing the HTTP response for the source file '%s'.", sourceUrl)), errClose).Error())
-					}
-				}(reader)
-
-				var errReader error = nil
-				content, errReader = io.ReadAll(reader)
-				if errReader != nil {
-					r <- S2TDirectResult{
-						Text: "",
-						Err:  errors.Join(errors.New("error while reading contents of file reader from URL"), errReader),
-					}
-					return
-				}
-			} else { // local file
-				var errReadFile error = nil
-				content, errReadFile = os.ReadFile(sourceUrl)
-				if errReadFile != nil {
+			if useBatchRecognize(options, content) {
+				// BatchRecognize only works with files on Google Cloud Storage -> upload content temporarily
+				tempUrl, errUpload := a.uploadTempAudio(ctx, content, sourceUrl, options)
+				if errUpload != nil {
 					r <- S2TDirectResult{
 						Text: "",
-						Err:  errors.Join(errors.New("error while reading contents of local file"), errReadFile),
+						Err:  errUpload,
 					}
 					return
 				}
+				defer a.deleteTempAudio(tempUrl)
+
+				r <- a.executeBatchRecognize(ctx, tempUrl, options)
+				return
 			}
 
 			req.AudioSource = &speechpb.RecognizeRequest_Content{
@@ -149,11 +221,38 @@ func (a S2TGoogleCloudPlatform) ExecuteS2TDirect(sourceUrl string, options Speec
 			}
 		}
 
-		resp, err := a.s2tClient.Recognize(context.Background(), req)
+		if ctx.Err() != nil {
+			r <- S2TDirectResult{
+				Text: "",
+				Err:  ctx.Err(),
+			}
+			return
+		}
+
+		recognizer, errRecognizer := a.getRecognizer(ctx, options)
+		if errRecognizer != nil {
+			r <- S2TDirectResult{
+				Text: "",
+				Err:  errRecognizer,
+			}
+			return
+		}
+		req.Recognizer = recognizer
 
+		resp, err := a.s2tClient.Recognize(ctx, req)
+		if err != nil {
+			r <- S2TDirectResult{
+				Text: "",
+				Err:  ContextError(ctx, err),
+			}
+			return
+		}
+
+		transcript := GetTranscript(resp.GetResults())
 		r <- S2TDirectResult{
-			Text: StitchResultsTogether(resp),
-			Err:  err,
+			Text:       transcript.Text,
+			Transcript: &transcript,
+			Err:        nil,
 		}
 		return
 	}()
@@ -161,18 +260,399 @@ func (a S2TGoogleCloudPlatform) ExecuteS2TDirect(sourceUrl string, options Speec
 	return r
 }
 
+// syncRecognitionMaxDuration is the maximum duration of audio that can be transcribed with synchronous recognition.
+// See GCP docs: https://cloud.google.com/speech-to-text/quotas
+const syncRecognitionMaxDuration = time.Minute
+
+// syncRecognitionMaxContentSize is the maximum size of inline audio content in bytes for synchronous recognition.
+const syncRecognitionMaxContentSize = 10 * 1024 * 1024
+
+// useBatchRecognize returns true if the given audio content should be transcribed using BatchRecognize instead of
+// the synchronous Recognize method, based on options.RecognitionMode and the limits of synchronous recognition.
+// If the duration of the audio content can't be determined, only its size is considered.
+func useBatchRecognize(options SpeechToTextOptions, content []byte) bool {
+	switch options.RecognitionMode {
+	case RecognitionModeSync:
+		return false
+	case RecognitionModeBatch:
+		return true
+	}
+	if len(content) > syncRecognitionMaxContentSize {
+		return true
+	}
+	duration, err := GetAudioDuration(content)
+	if options.AudioFormat.IsRaw() {
+		duration, err = GetRawAudioDuration(content, options.AudioFormat)
+	}
+	return err == nil && duration > syncRecognitionMaxDuration
+}
+
+// uploadTempAudio uploads the given audio content to options.TempBucket on Google Cloud Storage and returns the
+// URL of the uploaded file. The file name has the file type that is detected from the content, or the file extension
+// of the given source URL if the content isn't recognized.
+func (a S2TGoogleCloudPlatform) uploadTempAudio(ctx context.Context, content []byte, sourceUrl string, options SpeechToTextOptions) (string, error) {
+	if strings.EqualFold(options.TempBucket, "") {
+		return "", errors.New("audio file can only be transcribed using BatchRecognize, which requires the file to be stored on Google Cloud Storage, but no TempBucket is specified")
+	}
+
+	fileType := DetectAudioFileType(content)
+	if strings.EqualFold(fileType, "") {
+		fileType = GetFileTypeFromFileName(sourceUrl)
+	}
+	key := GetTempKey(fileType)
+
+	storageClient, err := storage.NewClient(ctx, a.storageOptions...)
+	if err != nil {
+		return "", ContextError(ctx, err)
+	}
+	defer storageClient.Close()
+
+	wc := storageClient.Bucket(options.TempBucket).Object(key).NewWriter(ctx)
+	if _, err = wc.Write(content); err != nil {
+		_ = wc.Close()
+		return "", ContextError(ctx, errors.Join(errors.New("error while uploading temporary audio file"), err))
+	}
+	if err = wc.Close(); err != nil {
+		return "", ContextError(ctx, errors.Join(errors.New("error while uploading temporary audio file"), err))
+	}
+	return "gs://" + options.TempBucket + "/" + key, nil
+}
+
+// deleteTempAudio deletes the given temporarily uploaded audio file from Google Cloud Storage.
+// Errors are printed, but not returned.
+func (a S2TGoogleCloudPlatform) deleteTempAudio(url string) {
+	ctx := context.Background()
+	storageClient, err := storage.NewClient(ctx, a.storageOptions...)
+	if err == nil {
+		defer storageClient.Close()
+		obj := ParseGoogleUrl(url)
+		err = storageClient.Bucket(obj.Bucket).Object(obj.Key).Delete(ctx)
+	}
+	if err != nil {
+		fmt.Printf(errors.Join(errors.New(fmt.Sprintf("A non-fatal error occurred while deleting the temporary audio file '%s'.", url)), err).Error())
+	}
+}
+
+// executeBatchRecognize transcribes the given Google Cloud Storage file using BatchRecognize.
+// It waits for the long-running operation to finish and returns the stitched transcript.
+func (a S2TGoogleCloudPlatform) executeBatchRecognize(ctx context.Context, sourceUrl string, options SpeechToTextOptions) S2TDirectResult {
+	job, err := a.StartS2T(ctx, sourceUrl, "", options)
+	if err != nil {
+		return S2TDirectResult{
+			Text: "",
+			Err:  err,
+		}
+	}
+
+	job, err = WaitForJob(ctx, a, job, options)
+	if err != nil {
+		return S2TDirectResult{
+			Text: "",
+			Err:  err,
+		}
+	}
+
+	return a.GetS2TResult(ctx, job)
+}
+
+// getRecognitionConfig converts the given options into a GCP recognition config.
+// If the implicit recognizer is used, the config also contains the model and language, since the implicit recognizer
+// is configured entirely by the request.
+func getRecognitionConfig(options SpeechToTextOptions) *speechpb.RecognitionConfig {
+	config := &speechpb.RecognitionConfig{
+		Features: &speechpb.RecognitionFeatures{
+			EnableSpokenEmojis:         options.EnableSpokenEmojis,
+			EnableSpokenPunctuation:    options.EnableSpokenPunctuation,
+			EnableAutomaticPunctuation: options.EnableAutomaticPunctuation,
+			ProfanityFilter:            options.ProfanityFilter && options.ProfanityFilterConfig.GetMethod() == ProfanityFilterMethodMask,
+			EnableWordTimeOffsets:      true,
+			EnableWordConfidence:       true,
+			MaxAlternatives:            options.MaxAlternatives,
+			DiarizationConfig:          getDiarizationConfig(options),
+		},
+		Adaptation: getAdaptation(options.VocabularyConfig),
+	}
+	if usesImplicitRecognizer(options) {
+		model, languageCode := getRecognizerModelAndLanguage(options)
+		config.Model = model
+		if !strings.EqualFold(languageCode, "") {
+			config.LanguageCodes = []string{languageCode}
+		}
+	}
+	setDecodingConfig(config, options.AudioFormat)
+	return config
+}
+
+// getAdaptation converts the given vocabulary config into a GCP speech adaptation with an inline PhraseSet and
+// inline CustomClasses. If the vocabulary config is empty, nil is returned.
+func getAdaptation(config VocabularyConfig) *speechpb.SpeechAdaptation {
+	if config.IsEmpty() {
+		return nil
+	}
+	adaptation := &speechpb.SpeechAdaptation{}
+	if !strings.EqualFold(config.VocabularyName, "") {
+		adaptation.PhraseSets = append(adaptation.PhraseSets, &speechpb.SpeechAdaptation_AdaptationPhraseSet{
+			Value: &speechpb.SpeechAdaptation_AdaptationPhraseSet_PhraseSet{PhraseSet: config.VocabularyName},
+		})
+	}
+	if len(config.Phrases) > 0 {
+		phraseSet := &speechpb.PhraseSet{}
+		for _, phrase := range config.Phrases {
+			phraseSet.Phrases = append(phraseSet.Phrases, &speechpb.PhraseSet_Phrase{
+				Value: phrase.Phrase,
+				Boost: phrase.Boost,
+			})
+		}
+		adaptation.PhraseSets = append(adaptation.PhraseSets, &speechpb.SpeechAdaptation_AdaptationPhraseSet{
+			Value: &speechpb.SpeechAdaptation_AdaptationPhraseSet_InlinePhraseSet{InlinePhraseSet: phraseSet},
+		})
+	}
+	for _, class := range config.CustomClasses {
+		customClass := &speechpb.CustomClass{Name: class.Id}
+		for _, item := range class.Items {
+			customClass.Items = append(customClass.Items, &speechpb.CustomClass_ClassItem{Value: item})
+		}
+		adaptation.CustomClasses = append(adaptation.CustomClasses, customClass)
+	}
+	return adaptation
+}
+
+// gcpAudioEncodings maps the raw audio encodings to the encodings of the GCP explicit decoding config.
+var gcpAudioEncodings = map[AudioEncoding]speechpb.ExplicitDecodingConfig_AudioEncoding{
+	AudioEncodingLinear16: speechpb.ExplicitDecodingConfig_LINEAR16,
+	AudioEncodingMulaw:    speechpb.ExplicitDecodingConfig_MULAW,
+	AudioEncodingAlaw:     speechpb.ExplicitDecodingConfig_ALAW,
+}
+
+// setDecodingConfig sets the decoding config of the given recognition config according to the given audio format.
+// Raw audio uses an explicit decoding config. Otherwise, the audio format is detected automatically.
+func setDecodingConfig(config *speechpb.RecognitionConfig, format AudioFormat) {
+	if !format.IsRaw() {
+		config.DecodingConfig = &speechpb.RecognitionConfig_AutoDecodingConfig{
+			AutoDecodingConfig: &speechpb.AutoDetectDecodingConfig{},
+		}
+		return
+	}
+	config.DecodingConfig = &speechpb.RecognitionConfig_ExplicitDecodingConfig{
+		ExplicitDecodingConfig: &speechpb.ExplicitDecodingConfig{
+			Encoding:          gcpAudioEncodings[format.Encoding],
+			SampleRateHertz:   format.SampleRateHertz,
+			AudioChannelCount: format.GetChannelCount(),
+		},
+	}
+}
+
+// getDiarizationConfig converts the diarization config of the given options into a GCP speaker diarization config.
+// If speaker diarization is disabled, nil is returned.
+func getDiarizationConfig(options SpeechToTextOptions) *speechpb.SpeakerDiarizationConfig {
+	if !options.DiarizationConfig.Enabled {
+		return nil
+	}
+	minSpeakerCount, maxSpeakerCount := options.DiarizationConfig.GetSpeakerCountRange()
+	return &speechpb.SpeakerDiarizationConfig{
+		MinSpeakerCount: minSpeakerCount,
+		MaxSpeakerCount: maxSpeakerCount,
+	}
+}
+
+// StartS2T starts a GCP BatchRecognize operation and returns a handle for it, without waiting for the operation to finish.
+// The source must be a Google Cloud Storage URL.
+// If destination is not empty, GCP stores the result file(s) at the given Google Cloud Storage URL (prefix).
+// Otherwise, the result is returned inline in the operation and can be retrieved with GetS2TResult.
+func (a S2TGoogleCloudPlatform) StartS2T(ctx context.Context, sourceUrl string, destination string, options SpeechToTextOptions) (TranscriptionJob, error) {
+	if !IsGoogleUrl(sourceUrl) {
+		return TranscriptionJob{}, errors.New(fmt.Sprintf("Couldn't start transcription job, because source '%s' is not a Google Cloud Storage URL.", sourceUrl))
+	}
+
+	outputConfig := &speechpb.RecognitionOutputConfig{
+		Output: &speechpb.RecognitionOutputConfig_InlineResponseConfig{
+			InlineResponseConfig: &speechpb.InlineOutputConfig{},
+		},
+	}
+	if !strings.EqualFold(destination, "") {
+		outputConfig.Output = &speechpb.RecognitionOutputConfig_GcsOutputConfig{
+			GcsOutputConfig: &speechpb.GcsOutputConfig{
+				Uri: destination,
+			},
+		}
+	}
+
+	recognizer, err := a.getRecognizer(ctx, options)
+	if err != nil {
+		return TranscriptionJob{}, err
+	}
+
+	req := &speechpb.BatchRecognizeRequest{
+		Recognizer: recognizer,
+		Config:     getRecognitionConfig(options),
+		ConfigMask: nil,
+		Files: []*speechpb.BatchRecognizeFileMetadata{
+			{
+				AudioSource: &speechpb.BatchRecognizeFileMetadata_Uri{
+					Uri: sourceUrl,
+				},
+			},
+		},
+		RecognitionOutputConfig: outputConfig,
+	}
+
+	op, err := a.s2tClient.BatchRecognize(ctx, req)
+	if err != nil {
+		return TranscriptionJob{}, ContextError(ctx, errors.Join(errors.New("error while starting batch recognition"), err))
+	}
+
+	job := TranscriptionJob{
+		Provider:    providers.ProviderGCP,
+		Name:        op.Name(),
+		Region:      a.region,
+		Source:      sourceUrl,
+		Destination: destination,
+		Status:      JobStatusInProgress,
+		StartTime:   time.Now(),
+	}
+	if op.Done() {
+		job.Status = JobStatusCompleted
+	}
+	return job, nil
+}
+
+// GetS2TStatus checks the status of the long-running operation of the given job and returns the updated job.
+func (a S2TGoogleCloudPlatform) GetS2TStatus(ctx context.Context, job TranscriptionJob) (TranscriptionJob, error) {
+	op := a.s2tClient.BatchRecognizeOperation(job.Name)
+	resp, err := op.Poll(ctx)
+	if err != nil {
+		if !op.Done() {
+			return job, ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while checking status of operation '%s'", job.Name)), err))
+		}
+		// operation is done, but has failed
+		job.Status = JobStatusFailed
+		job.FailureReason = err.Error()
+		return job, nil
+	}
+	if resp == nil {
+		job.Status = JobStatusInProgress
+		return job, nil
+	}
+
+	job.Status = JobStatusCompleted
+	for _, fileResult := range resp.GetResults() {
+		if fileResult.GetError() != nil && fileResult.GetError().GetCode() != 0 {
+			job.Status = JobStatusFailed
+			job.FailureReason = fileResult.GetError().GetMessage()
+		}
+		if !strings.EqualFold(fileResult.GetUri(), "") {
+			job.ResultUrl = fileResult.GetUri()
+		}
+	}
+	return job, nil
+}
+
+// GetS2TResult returns the transcribed text of the given (completed) job.
+// If the job has stored its result on Google Cloud Storage, the result file is downloaded.
+// Otherwise, the inline result of the operation is used.
+func (a S2TGoogleCloudPlatform) GetS2TResult(ctx context.Context, job TranscriptionJob) S2TDirectResult {
+	if job.Status != JobStatusCompleted {
+		return S2TDirectResult{
+			Text: "",
+			Err:  errors.New(fmt.Sprintf("The result of operation '%s' isn't available, because the operation has status '%s'.", job.Name, job.Status)),
+		}
+	}
+
+	resp, err := a.s2tClient.BatchRecognizeOperation(job.Name).Poll(ctx)
+	if err != nil {
+		return S2TDirectResult{
+			Text: "",
+			Err:  ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while retrieving the result of operation '%s'", job.Name)), err)),
+		}
+	}
+	if resp == nil {
+		return S2TDirectResult{
+			Text: "",
+			Err:  errors.New(fmt.Sprintf("The result of operation '%s' isn't available yet.", job.Name)),
+		}
+	}
+
+	var results []*speechpb.SpeechRecognitionResult
+	for _, fileResult := range resp.GetResults() {
+		if fileResult.GetTranscript() != nil {
+			results = append(results, fileResult.GetTranscript().GetResults()...)
+		} else if !strings.EqualFold(fileResult.GetUri(), "") {
+			batchResults, errDownload := a.downloadBatchRecognizeResults(ctx, fileResult.GetUri())
+			if errDownload != nil {
+				return S2TDirectResult{
+					Text: "",
+					Err:  errDownload,
+				}
+			}
+			results = append(results, batchResults.GetResults()...)
+		}
+	}
+
+	transcript := GetTranscript(results)
+	return S2TDirectResult{
+		Text:       transcript.Text,
+		Transcript: &transcript,
+		Err:        nil,
+	}
+}
+
+// downloadBatchRecognizeResults downloads and parses a result file that BatchRecognize stored on Google Cloud Storage.
+func (a S2TGoogleCloudPlatform) downloadBatchRecognizeResults(ctx context.Context, url string) (*speechpb.BatchRecognizeResults, error) {
+	storageClient, err := storage.NewClient(ctx, a.storageOptions...)
+	if err != nil {
+		return nil, ContextError(ctx, err)
+	}
+	defer storageClient.Close()
+
+	obj := ParseGoogleUrl(url)
+	reader, err := storageClient.Bucket(obj.Bucket).Object(obj.Key).NewReader(ctx)
+	if err != nil {
+		return nil, ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("Couldn't download the result file '%s'.", url)), err))
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while reading the result file '%s'", url)), err))
+	}
+
+	results := &speechpb.BatchRecognizeResults{}
+	if err = protojson.Unmarshal(content, results); err != nil {
+		return nil, errors.Join(errors.New(fmt.Sprintf("error while parsing the result file '%s'", url)), err)
+	}
+	return results, nil
+}
+
+// ReadStorageAudioHeader reads the first AudioHeaderSize bytes of the given file on Google Cloud Storage with a ranged
+// read.
+func (a S2TGoogleCloudPlatform) ReadStorageAudioHeader(ctx context.Context, url string) ([]byte, error) {
+	storageClient, err := storage.NewClient(ctx, a.storageOptions...)
+	if err != nil {
+		return nil, ContextError(ctx, err)
+	}
+	defer storageClient.Close()
+
+	obj := ParseGoogleUrl(url)
+	reader, err := storageClient.Bucket(obj.Bucket).Object(obj.Key).NewRangeReader(ctx, 0, AudioHeaderSize)
+	if err != nil {
+		return nil, ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while reading the header of the file '%s'", url)), err))
+	}
+	defer reader.Close()
+	return ReadAudioHeaderFromReader(ctx, reader)
+}
+
 func StitchResultsTogether(resp *speechpb.RecognizeResponse) string {
+	return stitchResults(resp.GetResults())
+}
+
+// stitchResults concatenates the alternative with the highest confidence of every given result.
+func stitchResults(results []*speechpb.SpeechRecognitionResult) string {
 	resultText := ""
-	for _, res := range resp.Results {
-		var highestConfScore float32 = 0.0
-		highestConfVal := ""
-		for _, alt := range res.GetAlternatives() {
-			if alt.GetConfidence() > highestConfScore {
-				highestConfScore = alt.GetConfidence()
-				highestConfVal = alt.GetTranscript()
-			}
+	for _, res := range results {
+		alternatives := res.GetAlternatives()
+		if len(alternatives) > 0 {
+			resultText += alternatives[getBestAlternativeIndex(alternatives)].GetTranscript()
 		}
-		resultText += highestConfVal
 	}
 	return resultText
 }
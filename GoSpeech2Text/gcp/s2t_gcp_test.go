@@ -0,0 +1,524 @@
+package aws
+
+import (
+	"bytes"
+	speechpb "cloud.google.com/go/speech/apiv2/speechpb"
+	"context"
+	"errors"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/s2ttest"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUseBatchRecognize(t *testing.T) {
+	options := SpeechToTextOptions{}
+	if useBatchRecognize(options, []byte("short")) {
+		t.Error("expected sync recognition for short content")
+	}
+	if !useBatchRecognize(options, make([]byte, syncRecognitionMaxContentSize+1)) {
+		t.Error("expected batch recognition for large content")
+	}
+
+	options.RecognitionMode = RecognitionModeBatch
+	if !useBatchRecognize(options, []byte("short")) {
+		t.Error("expected batch recognition if RecognitionModeBatch is set")
+	}
+
+	options.RecognitionMode = RecognitionModeSync
+	if useBatchRecognize(options, make([]byte, syncRecognitionMaxContentSize+1)) {
+		t.Error("expected sync recognition if RecognitionModeSync is set")
+	}
+}
+
+func TestGetTranscript(t *testing.T) {
+	results := []*speechpb.SpeechRecognitionResult{
+		{
+			Alternatives: []*speechpb.SpeechRecognitionAlternative{
+				{
+					Transcript: "hello world",
+					Confidence: 0.9,
+					Words: []*speechpb.WordInfo{
+						{Word: "hello", StartOffset: durationpb.New(100 * time.Millisecond), EndOffset: durationpb.New(500 * time.Millisecond), Confidence: 0.9},
+						{Word: "world", StartOffset: durationpb.New(500 * time.Millisecond), EndOffset: durationpb.New(900 * time.Millisecond), Confidence: 0.9},
+					},
+				},
+				{Transcript: "yellow world", Confidence: 0.4},
+			},
+			ResultEndOffset: durationpb.New(time.Second),
+			LanguageCode:    "en-us",
+		},
+		{
+			// no confidences -> first alternative is chosen
+			Alternatives: []*speechpb.SpeechRecognitionAlternative{
+				{Transcript: " how are you"},
+				{Transcript: " who are you"},
+			},
+			ResultEndOffset: durationpb.New(2 * time.Second),
+		},
+	}
+
+	transcript := GetTranscript(results)
+	if transcript.Text != "hello world how are you" || transcript.LanguageCode != "en-us" || len(transcript.Segments) != 2 {
+		t.Fatal("wrong transcript: Got ", transcript)
+	}
+
+	first := transcript.Segments[0]
+	if first.StartTime != 100*time.Millisecond || first.EndTime != time.Second || len(first.Words) != 2 || len(first.Alternatives) != 1 {
+		t.Error("wrong first segment: Got ", first)
+	}
+	if first.Alternatives[0].Text != "yellow world" {
+		t.Error("wrong alternative: Got ", first.Alternatives[0])
+	}
+
+	second := transcript.Segments[1]
+	if second.Text != " how are you" || second.StartTime != time.Second || second.EndTime != 2*time.Second {
+		t.Error("wrong second segment: Got ", second)
+	}
+}
+
+func TestGetTranscriptSpeakerLabels(t *testing.T) {
+	results := []*speechpb.SpeechRecognitionResult{
+		{
+			Alternatives: []*speechpb.SpeechRecognitionAlternative{
+				{
+					Transcript: "hello there hi",
+					Words: []*speechpb.WordInfo{
+						{Word: "hello", StartOffset: durationpb.New(100 * time.Millisecond), EndOffset: durationpb.New(500 * time.Millisecond), SpeakerLabel: "1"},
+						{Word: "there", StartOffset: durationpb.New(500 * time.Millisecond), EndOffset: durationpb.New(900 * time.Millisecond), SpeakerLabel: "1"},
+						{Word: "hi", StartOffset: durationpb.New(1200 * time.Millisecond), EndOffset: durationpb.New(1400 * time.Millisecond), SpeakerLabel: "2"},
+					},
+				},
+			},
+			ResultEndOffset: durationpb.New(1500 * time.Millisecond),
+		},
+	}
+
+	transcript := GetTranscript(results)
+	if len(transcript.Segments) != 2 {
+		t.Fatal("wrong number of segments: Got ", transcript.Segments)
+	}
+	if transcript.Segments[0].Text != "hello there" || transcript.Segments[0].Speaker != "1" {
+		t.Error("wrong first segment: Got ", transcript.Segments[0])
+	}
+	if transcript.Segments[1].Text != "hi" || transcript.Segments[1].Speaker != "2" || transcript.Segments[1].StartTime != 1200*time.Millisecond {
+		t.Error("wrong second segment: Got ", transcript.Segments[1])
+	}
+
+	config := getRecognitionConfig(SpeechToTextOptions{DiarizationConfig: DiarizationConfig{Enabled: true}})
+	if config.GetFeatures().GetDiarizationConfig().GetMaxSpeakerCount() != DefaultMaxSpeakerCount {
+		t.Error("wrong diarization config: Got ", config.GetFeatures().GetDiarizationConfig())
+	}
+}
+
+func createEmulatorTestResults() []*speechpb.SpeechRecognitionResult {
+	return []*speechpb.SpeechRecognitionResult{
+		{Alternatives: []*speechpb.SpeechRecognitionAlternative{{Transcript: "hello", Confidence: 0.9}}},
+		{Alternatives: []*speechpb.SpeechRecognitionAlternative{{Transcript: " emulated world", Confidence: 0.8}}},
+	}
+}
+
+func createEmulatedProvider(t *testing.T) (*s2ttest.SpeechEmulator, S2TProvider) {
+	emulator, err := s2ttest.NewSpeechEmulator(createEmulatorTestResults()...)
+	if err != nil {
+		t.Fatal("couldn't start emulator: ", err)
+	}
+	t.Cleanup(emulator.Close)
+
+	provider, err := S2TGoogleCloudPlatform{
+		ClientOptions:        emulator.ClientOptions(),
+		StorageClientOptions: emulator.StorageClientOptions(),
+		ProjectId:            "emulator-project",
+	}.CreateServiceClient(context.Background(), CredentialsHolder{}, "europe-west4")
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	t.Cleanup(func() { _ = provider.CloseServiceClient() })
+	return emulator, provider
+}
+
+func getEmulatorTestOptions() SpeechToTextOptions {
+	options := GetDefaultSpeechToTextOptions()
+	options.LanguageConfig.LanguageCode = "en-US"
+	options.TranscriptionJobCheckIntervalMs = 1
+	options.TranscriptionJobMaxCheckIntervalMs = 5
+	return *options
+}
+
+func TestExecuteS2TDirectEmulatorSync(t *testing.T) {
+	emulator, provider := createEmulatedProvider(t)
+	source := filepath.Join(t.TempDir(), "audio.wav")
+	if err := os.WriteFile(source, []byte("audio"), 0644); err != nil {
+		t.Fatal("couldn't create audio file: ", err)
+	}
+
+	result := <-provider.ExecuteS2TDirect(context.Background(), source, getEmulatorTestOptions())
+	if result.Err != nil || result.Text != "hello emulated world" {
+		t.Fatal("wrong result: Got ", result)
+	}
+
+	requests := emulator.RecognizeRequests()
+	if len(requests) != 1 || string(requests[0].GetContent()) != "audio" ||
+		requests[0].GetRecognizer() != "projects/emulator-project/locations/europe-west4/recognizers/_" {
+		t.Error("wrong recognize requests: Got ", requests)
+	}
+	if text := StitchResultsTogether(&speechpb.RecognizeResponse{Results: createEmulatorTestResults()}); text != result.Text {
+		t.Error("wrong stitched text: Got ", text)
+	}
+}
+
+func TestExecuteS2TDirectEmulatorBatch(t *testing.T) {
+	emulator, provider := createEmulatedProvider(t)
+	emulator.OperationPolls = 3
+
+	result := <-provider.ExecuteS2TDirect(context.Background(), "gs://audio-bucket/audio.wav", getEmulatorTestOptions())
+	if result.Err != nil || result.Text != "hello emulated world" {
+		t.Fatal("wrong result: Got ", result)
+	}
+
+	requests := emulator.BatchRecognizeRequests()
+	if len(requests) != 1 || requests[0].GetFiles()[0].GetUri() != "gs://audio-bucket/audio.wav" {
+		t.Error("wrong batch recognize requests: Got ", requests)
+	}
+	// one status check per poll of WaitForJob, plus one to retrieve the result
+	if polls := emulator.OperationPollCount("projects/emulator-project/locations/europe-west4/operations/1"); polls != 4 {
+		t.Error("wrong number of status checks: Got ", polls)
+	}
+}
+
+func TestExecuteS2TEmulator(t *testing.T) {
+	emulator, provider := createEmulatedProvider(t)
+
+	err := provider.ExecuteS2T(context.Background(), "gs://audio-bucket/audio.wav", "gs://result-bucket/result.txt", getEmulatorTestOptions())
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if content, _ := emulator.Object("result-bucket", "result.txt"); string(content) != "hello emulated world" {
+		t.Error("wrong result file: Got ", string(content))
+	}
+}
+
+func TestStartS2TEmulatorGcsOutput(t *testing.T) {
+	emulator, provider := createEmulatedProvider(t)
+	options := getEmulatorTestOptions()
+
+	job, err := provider.(TranscriptionJobProvider).StartS2T(context.Background(), "gs://audio-bucket/audio.wav", "gs://result-bucket/results/", options)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	job, err = WaitForJob(context.Background(), provider.(TranscriptionJobProvider), job, options)
+	if err != nil || job.ResultUrl != "gs://result-bucket/results/audio_transcript.json" {
+		t.Fatal("wrong job: Got ", job, err)
+	}
+	if _, exists := emulator.Object("result-bucket", "results/audio_transcript.json"); !exists {
+		t.Error("expected result file on storage")
+	}
+
+	result := provider.(TranscriptionJobProvider).GetS2TResult(context.Background(), job)
+	if result.Err != nil || result.Text != "hello emulated world" {
+		t.Error("wrong result: Got ", result)
+	}
+}
+
+func TestExecuteS2TDirectEmulatorOperationError(t *testing.T) {
+	emulator, provider := createEmulatedProvider(t)
+	emulator.OperationError = &rpcstatus.Status{Code: 3, Message: "invalid audio"}
+
+	result := <-provider.ExecuteS2TDirect(context.Background(), "gs://audio-bucket/audio.wav", getEmulatorTestOptions())
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "invalid audio") {
+		t.Error("expected operation error: Got ", result.Err)
+	}
+}
+
+func TestGetLocation(t *testing.T) {
+	if location := getLocation(""); location != "global" {
+		t.Error("wrong location: Got ", location)
+	}
+	if location := getLocation("europe-west4"); location != "europe-west4" {
+		t.Error("wrong location: Got ", location)
+	}
+	if location := getLocation("eu"); location != "eu" {
+		t.Error("wrong location: Got ", location)
+	}
+	if location := getLocation("eu-central-1"); location != "global" {
+		t.Error("wrong location for AWS region: Got ", location)
+	}
+	if endpoint := getRegionalEndpoint("eu"); endpoint != "eu-speech.googleapis.com:443" {
+		t.Error("wrong endpoint: Got ", endpoint)
+	}
+}
+
+func TestCreateServiceClientWithoutProjectId(t *testing.T) {
+	_, err := S2TGoogleCloudPlatform{}.CreateServiceClient(context.Background(), CredentialsHolder{}, "eu")
+	if err == nil {
+		t.Error("expected error because of missing project ID")
+	}
+}
+
+func TestGetRecognizerCreatesMissingRecognizer(t *testing.T) {
+	emulator, provider := createEmulatedProvider(t)
+	options := getEmulatorTestOptions()
+	options.RecognizerConfig = RecognizerConfig{RecognizerId: "my-recognizer", Model: "chirp"}
+	source := filepath.Join(t.TempDir(), "audio.wav")
+	if err := os.WriteFile(source, []byte("audio"), 0644); err != nil {
+		t.Fatal("couldn't create audio file: ", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		result := <-provider.ExecuteS2TDirect(context.Background(), source, options)
+		if result.Err != nil || result.Text != "hello emulated world" {
+			t.Fatal("wrong result: Got ", result)
+		}
+	}
+
+	name := "projects/emulator-project/locations/europe-west4/recognizers/my-recognizer"
+	if requests := emulator.CreateRecognizerRequests(); len(requests) != 1 {
+		t.Error("wrong number of created recognizers: Got ", len(requests))
+	}
+	recognizer, exists := emulator.Recognizer(name)
+	if !exists || recognizer.GetModel() != "chirp" || len(recognizer.GetLanguageCodes()) != 1 || recognizer.GetLanguageCodes()[0] != "en-US" {
+		t.Error("wrong recognizer: Got ", recognizer)
+	}
+	for _, request := range emulator.RecognizeRequests() {
+		if request.GetRecognizer() != name {
+			t.Error("wrong recognizer in request: Got ", request.GetRecognizer())
+		}
+	}
+}
+
+func TestGetRecognizerExistingRecognizer(t *testing.T) {
+	emulator, provider := createEmulatedProvider(t)
+	name := "projects/other-project/locations/global/recognizers/existing"
+	emulator.AddRecognizer(&speechpb.Recognizer{Name: name})
+	options := getEmulatorTestOptions()
+	options.RecognizerConfig = RecognizerConfig{RecognizerId: "existing", ProjectId: "other-project", Location: "global"}
+
+	result := <-provider.ExecuteS2TDirect(context.Background(), "gs://audio-bucket/audio.wav", options)
+	if result.Err != nil {
+		t.Fatal("unexpected error: ", result.Err)
+	}
+	if requests := emulator.CreateRecognizerRequests(); len(requests) != 0 {
+		t.Error("expected no created recognizers: Got ", requests)
+	}
+	if requests := emulator.BatchRecognizeRequests(); len(requests) != 1 || requests[0].GetRecognizer() != name {
+		t.Error("wrong batch recognize requests: Got ", requests)
+	}
+}
+
+func TestGetRecognizerWithoutLanguage(t *testing.T) {
+	_, provider := createEmulatedProvider(t)
+	options := getEmulatorTestOptions()
+	options.LanguageConfig.LanguageCode = ""
+	options.RecognizerConfig = RecognizerConfig{RecognizerId: "my-recognizer"}
+
+	_, err := provider.(TranscriptionJobProvider).StartS2T(context.Background(), "gs://audio-bucket/audio.wav", "", options)
+	if err == nil || !strings.Contains(err.Error(), "no language code") {
+		t.Error("expected error because of missing language code: Got ", err)
+	}
+}
+
+func TestImplicitRecognizerModelAndLanguage(t *testing.T) {
+	emulator, provider := createEmulatedProvider(t)
+	options := getEmulatorTestOptions()
+	options.RecognizerConfig = RecognizerConfig{Model: "chirp"}
+	if options.RecognizerConfig.IsEmpty() {
+		t.Error("expected non-empty recognizer config")
+	}
+
+	result := <-provider.ExecuteS2TDirect(context.Background(), "gs://audio-bucket/audio.wav", options)
+	if result.Err != nil {
+		t.Fatal("unexpected error: ", result.Err)
+	}
+	if requests := emulator.CreateRecognizerRequests(); len(requests) != 0 {
+		t.Error("expected no created recognizers: Got ", requests)
+	}
+	requests := emulator.BatchRecognizeRequests()
+	if len(requests) != 1 || !strings.HasSuffix(requests[0].GetRecognizer(), "/recognizers/_") {
+		t.Fatal("wrong batch recognize requests: Got ", requests)
+	}
+	config := requests[0].GetConfig()
+	if config.GetModel() != "chirp" || len(config.GetLanguageCodes()) != 1 || config.GetLanguageCodes()[0] != "en-US" {
+		t.Error("wrong recognition config: Got ", config)
+	}
+
+	options.RecognizerConfig = RecognizerConfig{}
+	if config = getRecognitionConfig(options); config.GetModel() != DefaultRecognizerModel {
+		t.Error("wrong default model: Got ", config.GetModel())
+	}
+	options.RecognizerConfig = RecognizerConfig{RecognizerId: "my-recognizer"}
+	if config = getRecognitionConfig(options); config.GetModel() != "" || len(config.GetLanguageCodes()) != 0 {
+		t.Error("expected model and language of the recognizer: Got ", config)
+	}
+}
+
+func TestReadStorageAudioHeader(t *testing.T) {
+	emulator, provider := createEmulatedProvider(t)
+	emulator.PutObject("audio-bucket", "recording", append([]byte("fLaC\x00\x00\x00\x22"), make([]byte, 2*AudioHeaderSize)...))
+
+	header, err := provider.(StorageAudioHeaderReader).ReadStorageAudioHeader(context.Background(), "gs://audio-bucket/recording")
+	if err != nil || len(header) != AudioHeaderSize || DetectAudioFileType(header) != "flac" {
+		t.Error("wrong header: Got ", header, err)
+	}
+	if _, err = provider.(StorageAudioHeaderReader).ReadStorageAudioHeader(context.Background(), "gs://audio-bucket/missing"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestGetRecognitionConfigDecoding(t *testing.T) {
+	options := getEmulatorTestOptions()
+	if config := getRecognitionConfig(options); config.GetAutoDecodingConfig() == nil {
+		t.Error("expected auto-detect decoding config: Got ", config.GetDecodingConfig())
+	}
+
+	options.AudioFormat = AudioFormat{Encoding: AudioEncodingMulaw, SampleRateHertz: 8000}
+	decoding := getRecognitionConfig(options).GetExplicitDecodingConfig()
+	if decoding.GetEncoding() != speechpb.ExplicitDecodingConfig_MULAW || decoding.GetSampleRateHertz() != 8000 || decoding.GetAudioChannelCount() != 1 {
+		t.Error("wrong explicit decoding config: Got ", decoding)
+	}
+}
+
+func TestGetRecognitionConfigAdaptation(t *testing.T) {
+	options := getEmulatorTestOptions()
+	if config := getRecognitionConfig(options); config.GetAdaptation() != nil {
+		t.Error("expected no adaptation: Got ", config.GetAdaptation())
+	}
+
+	options.VocabularyConfig = VocabularyConfig{
+		Phrases:        []VocabularyPhrase{{Phrase: "order ${product}", Boost: 10}},
+		CustomClasses:  []VocabularyClass{{Id: "product", Items: []string{"Widget Pro", "Gadget"}}},
+		VocabularyName: "projects/p/locations/global/phraseSets/s",
+	}
+	adaptation := getRecognitionConfig(options).GetAdaptation()
+	phraseSets := adaptation.GetPhraseSets()
+	if len(phraseSets) != 2 || phraseSets[0].GetPhraseSet() != options.VocabularyConfig.VocabularyName {
+		t.Fatal("wrong phrase sets: Got ", phraseSets)
+	}
+	phrases := phraseSets[1].GetInlinePhraseSet().GetPhrases()
+	if len(phrases) != 1 || phrases[0].GetValue() != "order ${product}" || phrases[0].GetBoost() != 10 {
+		t.Error("wrong inline phrases: Got ", phrases)
+	}
+	classes := adaptation.GetCustomClasses()
+	if len(classes) != 1 || classes[0].GetName() != "product" || len(classes[0].GetItems()) != 2 {
+		t.Error("wrong custom classes: Got ", classes)
+	}
+}
+
+func TestStreamS2TEmulator(t *testing.T) {
+	emulator, provider := createEmulatedProvider(t)
+	emulator.StreamingResponses = []*speechpb.StreamingRecognizeResponse{
+		{Results: []*speechpb.StreamingRecognitionResult{
+			{Alternatives: []*speechpb.SpeechRecognitionAlternative{{Transcript: "hello"}}, Stability: 0.9},
+			{Alternatives: []*speechpb.SpeechRecognitionAlternative{{Transcript: " wor"}}, Stability: 0.1},
+		}},
+		{Results: []*speechpb.StreamingRecognitionResult{
+			{Alternatives: []*speechpb.SpeechRecognitionAlternative{{Transcript: "hello world"}}, IsFinal: true, ResultEndOffset: durationpb.New(2 * time.Second)},
+		}},
+		{Results: []*speechpb.StreamingRecognitionResult{
+			{Alternatives: []*speechpb.SpeechRecognitionAlternative{{Transcript: "again"}}, Stability: 0.5},
+		}},
+	}
+	options := getEmulatorTestOptions()
+	options.AudioFormat = AudioFormat{Encoding: AudioEncodingLinear16, SampleRateHertz: 16000}
+	audio := bytes.Repeat([]byte{1, 2, 3}, StreamingAudioChunkSize)
+
+	results, err := provider.(StreamingS2TProvider).StreamS2T(context.Background(), bytes.NewReader(audio), options)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	var received []PartialResult
+	for result := range results {
+		received = append(received, result)
+	}
+	if len(received) != 3 {
+		t.Fatal("wrong number of results: Got ", received)
+	}
+	if interim := received[0]; interim.IsFinal || interim.Text != "hello wor" || interim.Stability != 0.1 || interim.ResultId != "0" {
+		t.Error("wrong interim result: Got ", interim)
+	}
+	if final := received[1]; !final.IsFinal || final.Text != "hello world" || final.Stability != 1.0 || final.EndTime != 2*time.Second {
+		t.Error("wrong final result: Got ", final)
+	}
+	if next := received[2]; next.ResultId != "1" || next.StartTime != 2*time.Second {
+		t.Error("wrong result of next utterance: Got ", next)
+	}
+
+	if !bytes.Equal(emulator.StreamingAudio(), audio) {
+		t.Error("wrong streamed audio: Got ", len(emulator.StreamingAudio()))
+	}
+	requests := emulator.StreamingConfigRequests()
+	if len(requests) != 1 || !requests[0].GetStreamingConfig().GetStreamingFeatures().GetInterimResults() ||
+		requests[0].GetStreamingConfig().GetConfig().GetExplicitDecodingConfig().GetSampleRateHertz() != 16000 {
+		t.Error("wrong streaming config: Got ", requests)
+	}
+}
+
+func TestVocabularyEmulator(t *testing.T) {
+	emulator, s2tProvider := createEmulatedProvider(t)
+	provider := s2tProvider.(VocabularyManager)
+	ctx := context.Background()
+	vocabulary := Vocabulary{
+		Id:            "shop",
+		Phrases:       []VocabularyPhrase{{Phrase: "order ${product}", Boost: 10}},
+		CustomClasses: []VocabularyClass{{Id: "product", Items: []string{"Widget Pro", "Gadget"}}},
+	}
+
+	created, err := provider.CreateVocabulary(ctx, vocabulary)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	name := "projects/emulator-project/locations/europe-west4/phraseSets/shop"
+	if created.Name != name || created.State != VocabularyStateReady {
+		t.Error("wrong created vocabulary: Got ", created)
+	}
+	className := "projects/emulator-project/locations/europe-west4/customClasses/shop-product"
+	if customClass, exists := emulator.CustomClass(className); !exists || len(customClass.GetItems()) != 2 {
+		t.Error("wrong custom class: Got ", customClass)
+	}
+	if phraseSet, _ := emulator.PhraseSet(name); phraseSet.GetPhrases()[0].GetValue() != "order ${"+className+"}" {
+		t.Error("wrong phrases: Got ", phraseSet.GetPhrases())
+	}
+
+	got, err := provider.GetVocabulary(ctx, "shop")
+	if err != nil || len(got.Phrases) != 1 || got.Phrases[0].Phrase != "order ${product}" || got.Phrases[0].Boost != 10 ||
+		len(got.CustomClasses) != 1 || got.CustomClasses[0].Id != "product" || got.CustomClasses[0].Items[1] != "Gadget" {
+		t.Error("wrong vocabulary: Got ", got, err)
+	}
+
+	vocabulary.Phrases = []VocabularyPhrase{{Phrase: "GoSpeech2Text"}}
+	vocabulary.CustomClasses = nil
+	if _, err = provider.UpdateVocabulary(ctx, vocabulary); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if _, exists := emulator.CustomClass(className); exists {
+		t.Error("expected removed custom class to be deleted")
+	}
+	if vocabularies, errList := provider.ListVocabularies(ctx); errList != nil || len(vocabularies) != 1 ||
+		vocabularies[0].Phrases[0].Phrase != "GoSpeech2Text" {
+		t.Error("wrong vocabularies: Got ", vocabularies, errList)
+	}
+
+	if err = provider.DeleteVocabulary(ctx, "shop"); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if _, err = provider.GetVocabulary(ctx, "shop"); !errors.Is(err, ErrVocabularyNotFound) {
+		t.Error("expected ErrVocabularyNotFound: Got ", err)
+	}
+}
+
+func TestTransformOptionsRawAudioWithoutSampleRate(t *testing.T) {
+	options := getEmulatorTestOptions()
+	options.AudioFormat = AudioFormat{Encoding: AudioEncodingLinear16}
+	if _, _, err := (S2TGoogleCloudPlatform{}).TransformOptions(context.Background(), "", options); err == nil || !strings.Contains(err.Error(), "sample rate") {
+		t.Error("expected error because of missing sample rate: Got ", err)
+	}
+
+	options.AudioFormat.SampleRateHertz = 16000
+	if _, _, err := (S2TGoogleCloudPlatform{}).TransformOptions(context.Background(), "", options); err != nil {
+		t.Error("unexpected error: ", err)
+	}
+}
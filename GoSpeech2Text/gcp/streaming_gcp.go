@@ -0,0 +1,158 @@
+package aws
+
+import (
+	speechpb "cloud.google.com/go/speech/apiv2/speechpb"
+	"context"
+	"errors"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StreamS2T transcribes the given audio stream using GCP StreamingRecognize with interim results.
+// Raw audio (e.g. LINEAR16 from a microphone) must be described by options.AudioFormat. Otherwise, the audio format
+// is detected automatically.
+// GCP returns the interim transcript of an utterance as multiple results with decreasing stability, which are
+// combined into a single interim result with the lowest stability.
+func (a S2TGoogleCloudPlatform) StreamS2T(ctx context.Context, audio io.Reader, options SpeechToTextOptions) (<-chan PartialResult, error) {
+	recognizer, errRecognizer := a.getRecognizer(ctx, options)
+	if errRecognizer != nil {
+		return nil, errRecognizer
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream, err := a.s2tClient.StreamingRecognize(streamCtx)
+	if err != nil {
+		cancel()
+		return nil, ContextError(ctx, errors.Join(errors.New("error while starting GCP streaming recognition"), err))
+	}
+	err = stream.Send(&speechpb.StreamingRecognizeRequest{
+		Recognizer: recognizer,
+		StreamingRequest: &speechpb.StreamingRecognizeRequest_StreamingConfig{
+			StreamingConfig: &speechpb.StreamingRecognitionConfig{
+				Config: getRecognitionConfig(options),
+				StreamingFeatures: &speechpb.StreamingRecognitionFeatures{
+					InterimResults: true,
+				},
+			},
+		},
+	})
+	if err != nil {
+		cancel()
+		return nil, ContextError(ctx, errors.Join(errors.New("error while sending GCP streaming recognition config"), err))
+	}
+
+	// audioErr receives the error that occurred while reading or sending the audio (if any)
+	audioErr := make(chan error, 1)
+	go func() {
+		errAudio := ReadAudioStream(streamCtx, audio, func(chunk []byte) error {
+			return stream.Send(&speechpb.StreamingRecognizeRequest{
+				StreamingRequest: &speechpb.StreamingRecognizeRequest_Audio{Audio: chunk},
+			})
+		})
+		// io.EOF means that the stream has been aborted by the server -> error is returned by Recv
+		if errAudio != nil && !errors.Is(errAudio, io.EOF) {
+			audioErr <- errAudio
+			cancel()
+			return
+		}
+		_ = stream.CloseSend()
+	}()
+
+	r := make(chan PartialResult)
+	go func() {
+		defer close(r)
+		defer cancel()
+
+		utterance := 0
+		var previousEndTime time.Duration = 0
+		for {
+			resp, errRecv := stream.Recv()
+			if errors.Is(errRecv, io.EOF) {
+				return
+			}
+			if errRecv != nil {
+				select {
+				case errAudio := <-audioErr:
+					errRecv = errAudio
+				default:
+					errRecv = ContextError(ctx, errors.Join(errors.New("error while receiving GCP streaming recognition results"), errRecv))
+				}
+				SendPartialResult(ctx, r, PartialResult{Err: errRecv})
+				return
+			}
+
+			var interimResults []*speechpb.StreamingRecognitionResult
+			for _, result := range resp.GetResults() {
+				if !result.GetIsFinal() {
+					interimResults = append(interimResults, result)
+					continue
+				}
+				partial := convertFinalStreamingResult(result, strconv.Itoa(utterance), previousEndTime)
+				if !SendPartialResult(ctx, r, partial) {
+					return
+				}
+				utterance++
+				previousEndTime = partial.EndTime
+			}
+			if len(interimResults) > 0 {
+				partial := combineInterimStreamingResults(interimResults, strconv.Itoa(utterance), previousEndTime)
+				if !SendPartialResult(ctx, r, partial) {
+					return
+				}
+			}
+		}
+	}()
+	return r, nil
+}
+
+// convertFinalStreamingResult converts the given final GCP streaming result into a partial result.
+func convertFinalStreamingResult(result *speechpb.StreamingRecognitionResult, resultId string, startTime time.Duration) PartialResult {
+	partial := PartialResult{
+		ResultId:     resultId,
+		IsFinal:      true,
+		Stability:    1.0,
+		StartTime:    startTime,
+		EndTime:      result.GetResultEndOffset().AsDuration(),
+		LanguageCode: result.GetLanguageCode(),
+	}
+	if alternatives := result.GetAlternatives(); len(alternatives) > 0 {
+		best := alternatives[getBestAlternativeIndex(alternatives)]
+		partial.Text = strings.TrimSpace(best.GetTranscript())
+		partial.Words = convertWords(best.GetWords())
+	}
+	if len(partial.Words) > 0 {
+		partial.StartTime = partial.Words[0].StartTime
+	}
+	return partial
+}
+
+// combineInterimStreamingResults combines the given interim GCP streaming results of the same utterance into a single
+// partial result, which has the lowest stability of the given results.
+func combineInterimStreamingResults(results []*speechpb.StreamingRecognitionResult, resultId string, startTime time.Duration) PartialResult {
+	partial := PartialResult{
+		ResultId:  resultId,
+		IsFinal:   false,
+		Stability: 1.0,
+		StartTime: startTime,
+	}
+	var texts []string
+	for _, result := range results {
+		if alternatives := result.GetAlternatives(); len(alternatives) > 0 {
+			texts = append(texts, strings.TrimSpace(alternatives[0].GetTranscript()))
+		}
+		if result.GetStability() < partial.Stability {
+			partial.Stability = result.GetStability()
+		}
+		if endTime := result.GetResultEndOffset().AsDuration(); endTime > partial.EndTime {
+			partial.EndTime = endTime
+		}
+		if strings.EqualFold(partial.LanguageCode, "") {
+			partial.LanguageCode = result.GetLanguageCode()
+		}
+	}
+	partial.Text = strings.Join(texts, " ")
+	return partial
+}
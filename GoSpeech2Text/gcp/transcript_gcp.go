@@ -0,0 +1,113 @@
+package aws
+
+import (
+	speechpb "cloud.google.com/go/speech/apiv2/speechpb"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	"time"
+)
+
+// GetTranscript converts the given GCP recognition results into a structured transcript.
+// Every result becomes one segment. The alternative with the highest confidence is used as the segment text,
+// the other alternatives are listed in the segment alternatives.
+// Word timestamps are only available if they have been enabled in the recognition features.
+func GetTranscript(results []*speechpb.SpeechRecognitionResult) Transcript {
+	transcript := Transcript{
+		Text: stitchResults(results),
+	}
+
+	var previousEndTime time.Duration = 0
+	for _, res := range results {
+		alternatives := res.GetAlternatives()
+		if len(alternatives) < 1 {
+			continue
+		}
+		bestIndex := getBestAlternativeIndex(alternatives)
+		best := alternatives[bestIndex]
+
+		segment := TranscriptSegment{
+			Text:         best.GetTranscript(),
+			StartTime:    previousEndTime,
+			EndTime:      res.GetResultEndOffset().AsDuration(),
+			Confidence:   best.GetConfidence(),
+			LanguageCode: res.GetLanguageCode(),
+			Words:        convertWords(best.GetWords()),
+		}
+		if len(segment.Words) > 0 {
+			segment.StartTime = segment.Words[0].StartTime
+		}
+		if res.GetResultEndOffset() == nil && len(segment.Words) > 0 {
+			segment.EndTime = segment.Words[len(segment.Words)-1].EndTime
+		}
+
+		for i, alt := range alternatives {
+			if i == bestIndex {
+				continue
+			}
+			segment.Alternatives = append(segment.Alternatives, TranscriptAlternative{
+				Text:       alt.GetTranscript(),
+				Confidence: alt.GetConfidence(),
+				Words:      convertWords(alt.GetWords()),
+			})
+		}
+
+		if transcript.LanguageCode == "" {
+			transcript.LanguageCode = res.GetLanguageCode()
+		}
+		transcript.Segments = append(transcript.Segments, splitSegmentBySpeaker(segment)...)
+		previousEndTime = segment.EndTime
+	}
+	return transcript
+}
+
+// splitSegmentBySpeaker splits the given segment into one segment per speaker turn, if its words have speaker labels
+// (i.e. if speaker diarization is enabled). The alternatives of the original segment are kept in the first segment.
+func splitSegmentBySpeaker(segment TranscriptSegment) []TranscriptSegment {
+	groups := SplitWordsBySpeaker(segment.Words)
+	if len(groups) < 2 {
+		segment.Speaker = GetSpeaker(segment.Words)
+		return []TranscriptSegment{segment}
+	}
+
+	segments := make([]TranscriptSegment, len(groups))
+	for i, words := range groups {
+		segments[i] = TranscriptSegment{
+			Text:         JoinWords(words),
+			StartTime:    words[0].StartTime,
+			EndTime:      words[len(words)-1].EndTime,
+			Confidence:   AverageWordConfidence(words),
+			LanguageCode: segment.LanguageCode,
+			Speaker:      GetSpeaker(words),
+			Words:        words,
+		}
+	}
+	segments[0].Alternatives = segment.Alternatives
+	return segments
+}
+
+// getBestAlternativeIndex returns the index of the alternative with the highest confidence.
+// If multiple alternatives have the same confidence (e.g. because GCP didn't return any confidences),
+// the first one is chosen, since GCP orders alternatives by accuracy.
+func getBestAlternativeIndex(alternatives []*speechpb.SpeechRecognitionAlternative) int {
+	bestIndex := 0
+	for i, alt := range alternatives {
+		if alt.GetConfidence() > alternatives[bestIndex].GetConfidence() {
+			bestIndex = i
+		}
+	}
+	return bestIndex
+}
+
+// convertWords converts the given GCP word infos into transcript words.
+func convertWords(wordInfos []*speechpb.WordInfo) []TranscriptWord {
+	var words []TranscriptWord
+	for _, wordInfo := range wordInfos {
+		words = append(words, TranscriptWord{
+			Text:       wordInfo.GetWord(),
+			StartTime:  wordInfo.GetStartOffset().AsDuration(),
+			EndTime:    wordInfo.GetEndOffset().AsDuration(),
+			Confidence: wordInfo.GetConfidence(),
+			Speaker:    wordInfo.GetSpeakerLabel(),
+		})
+	}
+	return words
+}
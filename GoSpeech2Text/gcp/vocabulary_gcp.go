@@ -0,0 +1,353 @@
+package aws
+
+import (
+	speechpb "cloud.google.com/go/speech/apiv2/speechpb"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"regexp"
+	"strings"
+)
+
+// classReferencePattern matches references to custom classes in phrases (e.g. "${product}").
+var classReferencePattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// CreateVocabulary creates a GCP PhraseSet with the phrases of the given vocabulary, and a CustomClass for every
+// custom class of the vocabulary. It waits for the long-running operations to finish, so the returned vocabulary is
+// ready.
+func (a S2TGoogleCloudPlatform) CreateVocabulary(ctx context.Context, vocabulary Vocabulary) (Vocabulary, error) {
+	classes := make(map[string]*speechpb.CustomClass)
+	for _, class := range vocabulary.CustomClasses {
+		customClass, err := a.createCustomClass(ctx, vocabulary.Id, class)
+		if err != nil {
+			return vocabulary, err
+		}
+		classes[customClass.GetName()] = customClass
+	}
+
+	op, err := a.s2tClient.CreatePhraseSet(ctx, &speechpb.CreatePhraseSetRequest{
+		Parent:      a.getLocationName(),
+		PhraseSetId: vocabulary.Id,
+		PhraseSet:   &speechpb.PhraseSet{Phrases: a.getGcpPhrases(vocabulary)},
+	})
+	var phraseSet *speechpb.PhraseSet
+	if err == nil {
+		phraseSet, err = op.Wait(ctx)
+	}
+	if err != nil {
+		return vocabulary, getVocabularyError(ctx, vocabulary.Id, "creating", err)
+	}
+	return a.convertPhraseSet(phraseSet, classes), nil
+}
+
+// UpdateVocabulary replaces the phrases of the GCP PhraseSet with the ID of the given vocabulary. Custom classes are
+// updated, created or deleted, so that they match the custom classes of the given vocabulary.
+// It waits for the long-running operations to finish, so the returned vocabulary is ready.
+func (a S2TGoogleCloudPlatform) UpdateVocabulary(ctx context.Context, vocabulary Vocabulary) (Vocabulary, error) {
+	existing, err := a.GetVocabulary(ctx, vocabulary.Id)
+	if err != nil {
+		return vocabulary, err
+	}
+	existingClasses := make(map[string]bool)
+	for _, class := range existing.CustomClasses {
+		existingClasses[class.Id] = true
+	}
+
+	classes := make(map[string]*speechpb.CustomClass)
+	for _, class := range vocabulary.CustomClasses {
+		var customClass *speechpb.CustomClass
+		if existingClasses[class.Id] {
+			customClass, err = a.updateCustomClass(ctx, vocabulary.Id, class)
+			delete(existingClasses, class.Id)
+		} else {
+			customClass, err = a.createCustomClass(ctx, vocabulary.Id, class)
+		}
+		if err != nil {
+			return vocabulary, err
+		}
+		classes[customClass.GetName()] = customClass
+	}
+
+	op, err := a.s2tClient.UpdatePhraseSet(ctx, &speechpb.UpdatePhraseSetRequest{
+		PhraseSet: &speechpb.PhraseSet{
+			Name:    a.getPhraseSetName(vocabulary.Id),
+			Phrases: a.getGcpPhrases(vocabulary),
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"phrases"}},
+	})
+	var phraseSet *speechpb.PhraseSet
+	if err == nil {
+		phraseSet, err = op.Wait(ctx)
+	}
+	if err != nil {
+		return vocabulary, getVocabularyError(ctx, vocabulary.Id, "updating", err)
+	}
+
+	// classes that have been removed are only deleted once they aren't referenced anymore
+	for classId := range existingClasses {
+		if err = a.deleteCustomClass(ctx, vocabulary.Id, classId); err != nil {
+			return vocabulary, err
+		}
+	}
+	return a.convertPhraseSet(phraseSet, classes), nil
+}
+
+// GetVocabulary returns the GCP PhraseSet with the given ID together with its custom classes.
+func (a S2TGoogleCloudPlatform) GetVocabulary(ctx context.Context, id string) (Vocabulary, error) {
+	phraseSet, err := a.s2tClient.GetPhraseSet(ctx, &speechpb.GetPhraseSetRequest{Name: a.getPhraseSetName(id)})
+	if err != nil {
+		return Vocabulary{}, getVocabularyError(ctx, id, "getting", err)
+	}
+	if phraseSet.GetState() == speechpb.PhraseSet_DELETED {
+		return Vocabulary{}, errors.Join(ErrVocabularyNotFound, errors.New(fmt.Sprintf("PhraseSet '%s' has been deleted", phraseSet.GetName())))
+	}
+
+	classes := make(map[string]*speechpb.CustomClass)
+	for _, className := range a.getVocabularyClassReferences(phraseSet) {
+		customClass, errClass := a.s2tClient.GetCustomClass(ctx, &speechpb.GetCustomClassRequest{Name: className})
+		if status.Code(errClass) == codes.NotFound {
+			continue
+		}
+		if errClass != nil {
+			return Vocabulary{}, ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while getting CustomClass '%s'", className)), errClass))
+		}
+		classes[className] = customClass
+	}
+	return a.convertPhraseSet(phraseSet, classes), nil
+}
+
+// ListVocabularies returns all GCP PhraseSets in the location of the service client together with their custom
+// classes.
+func (a S2TGoogleCloudPlatform) ListVocabularies(ctx context.Context) ([]Vocabulary, error) {
+	classes := make(map[string]*speechpb.CustomClass)
+	classIterator := a.s2tClient.ListCustomClasses(ctx, &speechpb.ListCustomClassesRequest{Parent: a.getLocationName()})
+	for {
+		customClass, err := classIterator.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, ContextError(ctx, errors.Join(errors.New("error while listing CustomClasses"), err))
+		}
+		classes[customClass.GetName()] = customClass
+	}
+
+	var vocabularies []Vocabulary
+	phraseSetIterator := a.s2tClient.ListPhraseSets(ctx, &speechpb.ListPhraseSetsRequest{Parent: a.getLocationName()})
+	for {
+		phraseSet, err := phraseSetIterator.Next()
+		if errors.Is(err, iterator.Done) {
+			return vocabularies, nil
+		}
+		if err != nil {
+			return nil, ContextError(ctx, errors.Join(errors.New("error while listing PhraseSets"), err))
+		}
+		vocabularies = append(vocabularies, a.convertPhraseSet(phraseSet, classes))
+	}
+}
+
+// DeleteVocabulary deletes the GCP PhraseSet with the given ID and its custom classes.
+func (a S2TGoogleCloudPlatform) DeleteVocabulary(ctx context.Context, id string) error {
+	vocabulary, err := a.GetVocabulary(ctx, id)
+	if err != nil {
+		return err
+	}
+	op, err := a.s2tClient.DeletePhraseSet(ctx, &speechpb.DeletePhraseSetRequest{Name: a.getPhraseSetName(id)})
+	if err == nil {
+		_, err = op.Wait(ctx)
+	}
+	if err != nil {
+		return getVocabularyError(ctx, id, "deleting", err)
+	}
+	for _, class := range vocabulary.CustomClasses {
+		if err = a.deleteCustomClass(ctx, id, class.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createCustomClass creates the GCP CustomClass of the given class of the vocabulary with the given ID and waits
+// for the long-running operation to finish.
+func (a S2TGoogleCloudPlatform) createCustomClass(ctx context.Context, vocabularyId string, class VocabularyClass) (*speechpb.CustomClass, error) {
+	op, err := a.s2tClient.CreateCustomClass(ctx, &speechpb.CreateCustomClassRequest{
+		Parent:        a.getLocationName(),
+		CustomClassId: getCustomClassId(vocabularyId, class.Id),
+		CustomClass:   &speechpb.CustomClass{Items: getGcpClassItems(class)},
+	})
+	var customClass *speechpb.CustomClass
+	if err == nil {
+		customClass, err = op.Wait(ctx)
+	}
+	if err != nil {
+		return nil, ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while creating CustomClass '%s'", a.getCustomClassName(vocabularyId, class.Id))), err))
+	}
+	return customClass, nil
+}
+
+// updateCustomClass replaces the items of the GCP CustomClass of the given class of the vocabulary with the given ID
+// and waits for the long-running operation to finish.
+func (a S2TGoogleCloudPlatform) updateCustomClass(ctx context.Context, vocabularyId string, class VocabularyClass) (*speechpb.CustomClass, error) {
+	op, err := a.s2tClient.UpdateCustomClass(ctx, &speechpb.UpdateCustomClassRequest{
+		CustomClass: &speechpb.CustomClass{
+			Name:  a.getCustomClassName(vocabularyId, class.Id),
+			Items: getGcpClassItems(class),
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"items"}},
+	})
+	var customClass *speechpb.CustomClass
+	if err == nil {
+		customClass, err = op.Wait(ctx)
+	}
+	if err != nil {
+		return nil, ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while updating CustomClass '%s'", a.getCustomClassName(vocabularyId, class.Id))), err))
+	}
+	return customClass, nil
+}
+
+// deleteCustomClass deletes the GCP CustomClass with the given class ID of the vocabulary with the given ID and waits
+// for the long-running operation to finish. Classes that don't exist are ignored.
+func (a S2TGoogleCloudPlatform) deleteCustomClass(ctx context.Context, vocabularyId string, classId string) error {
+	name := a.getCustomClassName(vocabularyId, classId)
+	op, err := a.s2tClient.DeleteCustomClass(ctx, &speechpb.DeleteCustomClassRequest{Name: name})
+	if err == nil {
+		_, err = op.Wait(ctx)
+	}
+	if err != nil && status.Code(err) != codes.NotFound {
+		return ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("error while deleting CustomClass '%s'", name)), err))
+	}
+	return nil
+}
+
+// getLocationName returns the name of the location of the service client, i.e. "projects/{project}/locations/{location}".
+func (a S2TGoogleCloudPlatform) getLocationName() string {
+	return fmt.Sprintf("projects/%s/locations/%s", a.projectId, a.location)
+}
+
+// getPhraseSetName returns the resource name of the PhraseSet of the vocabulary with the given ID.
+func (a S2TGoogleCloudPlatform) getPhraseSetName(vocabularyId string) string {
+	return a.getLocationName() + "/phraseSets/" + vocabularyId
+}
+
+// getCustomClassName returns the resource name of the CustomClass with the given class ID of the vocabulary with the
+// given ID.
+func (a S2TGoogleCloudPlatform) getCustomClassName(vocabularyId string, classId string) string {
+	return a.getLocationName() + "/customClasses/" + getCustomClassId(vocabularyId, classId)
+}
+
+// getCustomClassId returns the ID of the CustomClass with the given class ID of the vocabulary with the given ID.
+func getCustomClassId(vocabularyId string, classId string) string {
+	return vocabularyId + "-" + classId
+}
+
+// getGcpPhrases converts the phrases of the given vocabulary into the phrases of a GCP PhraseSet. References to the
+// custom classes of the vocabulary are replaced by references to their CustomClass resources. Custom classes that
+// aren't referenced by any phrase are added as phrase.
+func (a S2TGoogleCloudPlatform) getGcpPhrases(vocabulary Vocabulary) []*speechpb.PhraseSet_Phrase {
+	var phrases []*speechpb.PhraseSet_Phrase
+	referenced := make(map[string]bool)
+	for _, phrase := range vocabulary.Phrases {
+		value := phrase.Phrase
+		for _, class := range vocabulary.CustomClasses {
+			reference := "${" + class.Id + "}"
+			if strings.Contains(value, reference) {
+				value = strings.ReplaceAll(value, reference, "${"+a.getCustomClassName(vocabulary.Id, class.Id)+"}")
+				referenced[class.Id] = true
+			}
+		}
+		phrases = append(phrases, &speechpb.PhraseSet_Phrase{Value: value, Boost: phrase.Boost})
+	}
+	for _, class := range vocabulary.CustomClasses {
+		if !referenced[class.Id] {
+			phrases = append(phrases, &speechpb.PhraseSet_Phrase{Value: "${" + a.getCustomClassName(vocabulary.Id, class.Id) + "}"})
+		}
+	}
+	return phrases
+}
+
+// getGcpClassItems converts the items of the given custom class into the items of a GCP CustomClass.
+func getGcpClassItems(class VocabularyClass) []*speechpb.CustomClass_ClassItem {
+	var items []*speechpb.CustomClass_ClassItem
+	for _, item := range class.Items {
+		items = append(items, &speechpb.CustomClass_ClassItem{Value: item})
+	}
+	return items
+}
+
+// getVocabularyClassReferences returns the resource names of the CustomClasses of the vocabulary that are referenced
+// in the phrases of the given PhraseSet, in the order of their first occurrence.
+func (a S2TGoogleCloudPlatform) getVocabularyClassReferences(phraseSet *speechpb.PhraseSet) []string {
+	prefix := a.getCustomClassName(getPhraseSetId(phraseSet), "")
+	var names []string
+	seen := make(map[string]bool)
+	for _, phrase := range phraseSet.GetPhrases() {
+		for _, match := range classReferencePattern.FindAllStringSubmatch(phrase.GetValue(), -1) {
+			if strings.HasPrefix(match[1], prefix) && !seen[match[1]] {
+				seen[match[1]] = true
+				names = append(names, match[1])
+			}
+		}
+	}
+	return names
+}
+
+// convertPhraseSet converts the given GCP PhraseSet into a vocabulary. References to the CustomClasses of the
+// vocabulary, which must be contained in the given classes (by resource name), are converted into custom classes.
+func (a S2TGoogleCloudPlatform) convertPhraseSet(phraseSet *speechpb.PhraseSet, classes map[string]*speechpb.CustomClass) Vocabulary {
+	id := getPhraseSetId(phraseSet)
+	vocabulary := Vocabulary{
+		Provider: providers.ProviderGCP,
+		Id:       id,
+		Name:     phraseSet.GetName(),
+		State:    VocabularyStatePending,
+	}
+	if phraseSet.GetState() == speechpb.PhraseSet_ACTIVE {
+		vocabulary.State = VocabularyStateReady
+	}
+	if updateTime := phraseSet.GetUpdateTime(); updateTime != nil {
+		vocabulary.LastModifiedTime = updateTime.AsTime()
+	} else if createTime := phraseSet.GetCreateTime(); createTime != nil {
+		vocabulary.LastModifiedTime = createTime.AsTime()
+	}
+
+	prefix := a.getCustomClassName(id, "")
+	for _, className := range a.getVocabularyClassReferences(phraseSet) {
+		if customClass, exists := classes[className]; exists {
+			class := VocabularyClass{Id: strings.TrimPrefix(className, prefix)}
+			for _, item := range customClass.GetItems() {
+				class.Items = append(class.Items, item.GetValue())
+			}
+			vocabulary.CustomClasses = append(vocabulary.CustomClasses, class)
+		}
+	}
+	for _, phrase := range phraseSet.GetPhrases() {
+		value := classReferencePattern.ReplaceAllStringFunc(phrase.GetValue(), func(reference string) string {
+			return strings.Replace(reference, prefix, "", 1)
+		})
+		vocabulary.Phrases = append(vocabulary.Phrases, VocabularyPhrase{Phrase: value, Boost: phrase.GetBoost()})
+	}
+	return vocabulary
+}
+
+// getPhraseSetId returns the ID of the given PhraseSet, which is the last part of its resource name.
+func getPhraseSetId(phraseSet *speechpb.PhraseSet) string {
+	name := phraseSet.GetName()
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// getVocabularyError wraps the given error of the given vocabulary action (e.g. "deleting").
+// If the PhraseSet doesn't exist, the error also wraps ErrVocabularyNotFound.
+func getVocabularyError(ctx context.Context, id string, action string, err error) error {
+	notFound := status.Code(err) == codes.NotFound
+	err = errors.Join(errors.New(fmt.Sprintf("error while %s PhraseSet '%s'", action, id)), err)
+	if notFound {
+		return errors.Join(ErrVocabularyNotFound, err)
+	}
+	return ContextError(ctx, err)
+}
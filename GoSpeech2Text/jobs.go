@@ -0,0 +1,116 @@
+package GoText2Speech
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	"strings"
+)
+
+// StartS2T starts a transcription job for the given source file and returns a handle for the job, without waiting
+// for the job to finish. Sources are handled in the same way as in S2T, except that local files and files from other
+// URLs are always uploaded to the storage service of the provider (options.TempBucket).
+// If destination is not empty, the provider stores its result file at the given destination.
+// The provider must support transcription jobs (see TranscriptionJobProvider).
+//
+// The returned TranscriptionJob can be serialized to JSON, so that the job can be collected in another function
+// invocation using GetS2TStatus, WaitS2T and GetS2TResult.
+func (a GoS2TClient) StartS2T(ctx context.Context, source string, destination string, options SpeechToTextOptions) (TranscriptionJob, error) {
+	a, prepared, err := a.prepareSource(ctx, source, destination, options, true)
+	if err != nil {
+		return TranscriptionJob{}, err
+	}
+
+	provider, ok := prepared.provider.(TranscriptionJobProvider)
+	if !ok {
+		a.deleteTempFiles(prepared)
+		return TranscriptionJob{}, errors.New(fmt.Sprintf("The provider '%s' doesn't support transcription jobs.", prepared.options.Provider))
+	}
+	job, err := provider.StartS2T(ctx, prepared.source, destination, prepared.options)
+	// the converted local file (if any) has already been uploaded
+	removeTempLocalFile(prepared.tmpLocalFile)
+	if err != nil {
+		a.deleteTempUploadedFile(prepared.tmpUploadedFile)
+		return job, err
+	}
+	if a.DeleteTempFile {
+		job.TempSourceFile = prepared.tmpUploadedFile
+	}
+	job.Redaction = prepared.redaction
+	job.ProfanityFilter = prepared.profanityFilter
+	return a.cleanUpJob(job), nil
+}
+
+// GetS2TStatus checks the status of the given transcription job and returns the updated job.
+// As soon as the job is done, the temporarily uploaded source file (if any) is deleted.
+func (a GoS2TClient) GetS2TStatus(ctx context.Context, job TranscriptionJob) (TranscriptionJob, error) {
+	provider, err := a.getJobServiceClient(ctx, job)
+	if err != nil {
+		return job, err
+	}
+
+	job, err = provider.GetS2TStatus(ctx, job)
+	if err != nil {
+		return job, err
+	}
+	return a.cleanUpJob(job), nil
+}
+
+// WaitS2T waits until the given transcription job is done and returns the updated job.
+// The job status is checked periodically, as configured by the transcription job options in the given options
+// (see SpeechToTextOptions.TranscriptionJobCheckIntervalMs).
+// If the job has failed, the returned error contains the failure reason.
+func (a GoS2TClient) WaitS2T(ctx context.Context, job TranscriptionJob, options SpeechToTextOptions) (TranscriptionJob, error) {
+	provider, err := a.getJobServiceClient(ctx, job)
+	if err != nil {
+		return job, err
+	}
+
+	job, err = WaitForJob(ctx, provider, job, options)
+	return a.cleanUpJob(job), err
+}
+
+// GetS2TResult returns the result of the given transcription job. The job must have completed
+// (see GetS2TStatus and WaitS2T). If the provider of the job can't redact content or filter profanities natively,
+// the result is processed locally (see TranscriptionJob.Redaction and TranscriptionJob.ProfanityFilter).
+func (a GoS2TClient) GetS2TResult(ctx context.Context, job TranscriptionJob) S2TDirectResult {
+	provider, err := a.getJobServiceClient(ctx, job)
+	if err != nil {
+		return S2TDirectResult{
+			Text: "",
+			Err:  err,
+		}
+	}
+	return filterResultProfanity(redactResult(provider.GetS2TResult(ctx, job), job.Redaction), job.ProfanityFilter)
+}
+
+// getJobServiceClient returns the provider instance with a service client for the provider and region of the given job.
+// The provider must support transcription jobs (see TranscriptionJobProvider).
+func (a GoS2TClient) getJobServiceClient(ctx context.Context, job TranscriptionJob) (TranscriptionJobProvider, error) {
+	if a.getProviderInstance(job.Provider) == nil {
+		return nil, errors.New(fmt.Sprintf("The provider '%s' of transcription job '%s' is not supported.", job.Provider, job.Name))
+	}
+	region := job.Region
+	if strings.EqualFold(region, "") {
+		region = a.getProviderInstance(job.Provider).GetDefaultRegion()
+	}
+	instance, err := a.getServiceClient(ctx, job.Provider, region)
+	if err != nil {
+		return nil, ContextError(ctx, errors.Join(errors.New("error while creating S2T service client"), err))
+	}
+	provider, ok := instance.(TranscriptionJobProvider)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("The provider '%s' of transcription job '%s' doesn't support transcription jobs.", job.Provider, job.Name))
+	}
+	return provider, nil
+}
+
+// cleanUpJob deletes the temporarily uploaded source file of the given job, if the job is done.
+func (a GoS2TClient) cleanUpJob(job TranscriptionJob) TranscriptionJob {
+	if job.Status.IsDone() && job.TempSourceFile != nil {
+		a.deleteTempUploadedFile(job.TempSourceFile)
+		job.TempSourceFile = nil
+	}
+	return job
+}
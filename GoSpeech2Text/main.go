@@ -1,28 +1,35 @@
 package GoText2Speech
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/FaaSTools/GoStorage/gostorage"
-	s2t_aws "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/aws"
-	s2t_gcp "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/gcp"
+	// import built-in providers, which register themselves (see providers.Register)
+	_ "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/aws"
+	_ "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/gcp"
 	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
 	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"io"
 	"os"
-	"strconv"
+	"sort"
 	"strings"
-	"time"
+	"sync"
 )
 
 type GoS2TClient struct {
-	providerInstances  map[providers.Provider]*S2TProvider
-	region             *string
-	credentials        *CredentialsHolder
-	redactedFileSuffix string
-	DeleteTempFile     bool
-	gostorageClient    *gostorage.GoStorage
+	providerInstances map[providers.Provider]*S2TProvider
+	// serviceClients contains the service clients that have been created, per provider and region.
+	serviceClients map[serviceClientKey]*serviceClient
+	// providerMutex guards providerInstances and serviceClients, which are shared by all copies of the client
+	// and may be used concurrently (e.g. by S2TBatch).
+	providerMutex  *sync.Mutex
+	region         *string
+	credentials    *CredentialsHolder
+	DeleteTempFile bool
+	// storage is used to upload, copy and delete files on the storage services of the providers.
+	storage Storage
 }
 
 func CreateGoS2TClient(credentials *CredentialsHolder, region string) GoS2TClient {
@@ -45,6 +52,8 @@ func CreateGoS2TClient(credentials *CredentialsHolder, region string) GoS2TClien
 
 	s2tClient := GoS2TClient{
 		providerInstances: make(map[providers.Provider]*S2TProvider),
+		serviceClients:    make(map[serviceClientKey]*serviceClient),
+		providerMutex:     &sync.Mutex{},
 		credentials:       credentials,
 		region:            regionPtr,
 		DeleteTempFile:    true,
@@ -53,34 +62,135 @@ func CreateGoS2TClient(credentials *CredentialsHolder, region string) GoS2TClien
 	return s2tClient
 }
 
+// getProviderInstance returns the instance of the given provider. If the provider hasn't been registered, nil is returned.
 func (a GoS2TClient) getProviderInstance(provider providers.Provider) S2TProvider {
+	a.providerMutex.Lock()
+	defer a.providerMutex.Unlock()
+	return a.getProviderInstanceLocked(provider)
+}
+
+// getProviderInstanceLocked works like getProviderInstance, but the provider mutex must already be held.
+func (a GoS2TClient) getProviderInstanceLocked(provider providers.Provider) S2TProvider {
 	if a.providerInstances[provider] == nil {
 		prov := CreateProviderInstance(provider)
+		if prov == nil {
+			return nil
+		}
 		a.providerInstances[provider] = &prov
 	}
 	return *a.providerInstances[provider]
 }
 
-func (a GoS2TClient) CloseProviderClient(provider providers.Provider) error {
-	return a.getProviderInstance(provider).CloseServiceClient()
+// serviceClientKey identifies the service client of a provider in a region.
+type serviceClientKey struct {
+	provider providers.Provider
+	region   string
 }
 
-func (a GoS2TClient) CloseAllProviderClients() error {
+// serviceClient is a service client that is created (or has been created) for a provider and region.
+// ready is closed as soon as the creation has finished. Afterwards, instance and err are set.
+type serviceClient struct {
+	ready    chan struct{}
+	instance S2TProvider
+	err      error
+}
+
+// getServiceClient returns the provider instance with a service client for the given region.
+// Service clients are cached per provider and region, so transcriptions in different regions (e.g. in S2TBatch)
+// don't replace each other's service client. The service client is created if it doesn't exist yet. It is created
+// outside the provider mutex, so that creating a service client doesn't block other providers and regions.
+func (a GoS2TClient) getServiceClient(ctx context.Context, provider providers.Provider, region string) (S2TProvider, error) {
+	key := serviceClientKey{provider: provider, region: region}
+	a.providerMutex.Lock()
+	client, exists := a.serviceClients[key]
+	if exists {
+		a.providerMutex.Unlock()
+		select {
+		case <-client.ready:
+			return client.instance, client.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	instance := a.getProviderInstanceLocked(provider)
+	if instance == nil {
+		a.providerMutex.Unlock()
+		return nil, errors.New(fmt.Sprintf("The provider '%s' is not supported. Register it with providers.Register.", provider))
+	}
+	client = &serviceClient{ready: make(chan struct{})}
+	a.serviceClients[key] = client
+	a.providerMutex.Unlock()
+
+	client.instance, client.err = instance.CreateServiceClient(ctx, *a.credentials, region)
+	if client.err != nil {
+		// failed clients aren't cached, so that the next transcription tries again
+		a.providerMutex.Lock()
+		if a.serviceClients[key] == client {
+			delete(a.serviceClients, key)
+		}
+		a.providerMutex.Unlock()
+	}
+	close(client.ready)
+	return client.instance, client.err
+}
+
+// closeServiceClientsLocked closes and removes all service clients of the given provider that have been created
+// successfully, and returns the errors that occurred. The provider mutex must already be held.
+// Service clients that are still being created are only removed.
+func (a GoS2TClient) closeServiceClientsLocked(provider providers.Provider) error {
 	var allErrors error = nil
-	for _, instance := range a.providerInstances {
-		err := (*instance).CloseServiceClient()
-		if err != nil {
-			if allErrors == nil {
-				allErrors = err
-			} else {
-				allErrors = errors.Join(allErrors, err)
+	for key, client := range a.serviceClients {
+		if key.provider != provider {
+			continue
+		}
+		delete(a.serviceClients, key)
+		select {
+		case <-client.ready:
+			if client.err == nil {
+				allErrors = errors.Join(allErrors, client.instance.CloseServiceClient())
 			}
+		default:
 		}
 	}
 	return allErrors
 }
 
+func (a GoS2TClient) CloseProviderClient(provider providers.Provider) error {
+	a.providerMutex.Lock()
+	defer a.providerMutex.Unlock()
+	return a.closeServiceClientsLocked(provider)
+}
+
+func (a GoS2TClient) CloseAllProviderClients() error {
+	a.providerMutex.Lock()
+	defer a.providerMutex.Unlock()
+	var allErrors error = nil
+	for provider := range a.providerInstances {
+		allErrors = errors.Join(allErrors, a.closeServiceClientsLocked(provider))
+	}
+	return allErrors
+}
+
 // S2T Transforms the source file audio into text and stores the file in destination.
+// The format of the stored file is specified by options.OutputFormat, or inferred from the file extension of
+// destination (e.g. "transcript.srt" for SRT subtitles). See SpeechToTextOptions.OutputFormat.
+// If an output format is specified or inferred (including plain text for "txt" destinations), S2T waits for the
+// transcription to finish and formats the result, which requires options.TempBucket on AWS. Otherwise (e.g. for
+// destinations without file extension), the provider writes its native result file, which doesn't block on AWS.
+// If content is redacted or profanities are filtered locally (see SpeechToTextOptions.ContentRedactionConfig and
+// SpeechToTextOptions.ProfanityFilter) and no output format is specified,
+// the transcript is stored as JSON instead of the native result file of the provider.
+// If content is redacted with RedactionOutputRedactedAndUnredacted (see ContentRedactionConfig), the redacted
+// transcript is stored at destination and the unredacted transcript at GetUnredactedDestination(destination)
+// (e.g. "transcript.unredacted.json" for "transcript.json"). Both transcripts are formatted (as JSON if no output
+// format is specified), so that both list the redacted entities.
+// When only the redacted transcript is stored and content is redacted natively on AWS, AWS chooses the name of the
+// transcript file itself (e.g. "redacted-transcript.json"), so S2T waits for the transcription to finish and moves
+// the file to destination, unless the file name of destination starts with "redacted-" (i.e. the file is already
+// stored at destination).
+// If the options contain inline phrases (see VocabularyConfig), S2T on AWS blocks until the custom vocabulary for
+// them is ready. The vocabulary is created by the first transcription with these phrases, which can take several
+// minutes, and is reused afterwards (e.g. by all chunks of a file).
 // The given source parameter specifies the location of the file. The file can have one of the following locations:
 // * AWS S3
 // * Google Cloud Storage
@@ -91,19 +201,241 @@ func (a GoS2TClient) CloseAllProviderClients() error {
 // If the given options specify a provider, this provider will be used.
 // If the given options don't specify a provider, a provider will be chosen based on heuristics.
 func (a GoS2TClient) S2T(source string, destination string, options SpeechToTextOptions) (GoS2TClient, error) {
+	return a.S2TWithContext(context.Background(), source, destination, options)
+}
+
+// S2TWithContext works like S2T, but all requests to the provider and storage services are bound to the given context.
+// If the context is cancelled or its deadline is exceeded, ctx.Err() is returned.
+// Since GoStorage doesn't support contexts, a file upload that is already running when the context is done
+// is not aborted, but S2TWithContext returns without waiting for it. A temporarily uploaded source file is deleted
+// in the background as soon as the upload has finished.
+func (a GoS2TClient) S2TWithContext(ctx context.Context, source string, destination string, options SpeechToTextOptions) (GoS2TClient, error) {
+	if a.shouldChunk(ctx, source, options) {
+		return a.s2tChunked(ctx, source, destination, options)
+	}
+
+	var prepared preparedSource
+	var err error
+	a, prepared, err = a.prepareSource(ctx, source, destination, options, false)
+	if err != nil {
+		return a, err
+	}
+	// Delete temporary files (if they should be deleted and if they exist)
+	defer a.deleteTempFiles(prepared)
+
+	format := ResolveOutputFormat(prepared.options, destination)
+	if format == OutputFormatUnspecified && (prepared.redaction != nil || prepared.profanityFilter != nil ||
+		prepared.options.ContentRedactionConfig.RedactionOutput == RedactionOutputRedactedAndUnredacted) {
+		// the native result file of the provider can't be filtered locally, and the native unredacted result file
+		// doesn't list the redacted entities
+		format = OutputFormatJson
+	}
+	if format == OutputFormatUnspecified {
+		// no output format -> provider writes its native result file
+		err = prepared.provider.ExecuteS2T(ctx, prepared.source, destination, prepared.options)
+		if err != nil {
+			return a, err
+		}
+		return a, nil
+	}
+
+	result := prepared.processResult(<-prepared.provider.ExecuteS2TDirect(ctx, prepared.source, prepared.options))
+	if result.Err != nil {
+		return a, result.Err
+	}
+	transcript := Transcript{Text: result.Text}
+	if result.Transcript != nil {
+		transcript = *result.Transcript
+	}
+
+	content, err := FormatTranscript(transcript, format)
+	if err != nil {
+		return a, err
+	}
+	err = a.writeToDestination(ctx, content, destination)
+	if err != nil || result.UnredactedTranscript == nil {
+		return a, err
+	}
+	return a, a.writeUnredactedTranscript(ctx, *result.UnredactedTranscript, format, destination)
+}
+
+// writeToDestination stores the given content at the given destination, which can either be a storage URL
+// (AWS S3 or Google Cloud Storage) or a local file path.
+func (a GoS2TClient) writeToDestination(ctx context.Context, content []byte, destination string) error {
+	if !IsAWSUrl(destination) && !IsGoogleUrl(destination) { // local file
+		errWrite := os.WriteFile(destination, content, 0644)
+		if errWrite != nil {
+			return errors.Join(errors.New(fmt.Sprintf("error while writing result to local file '%s'", destination)), errWrite)
+		}
+		return nil
+	}
+
+	tmpFile, errTmpFile := os.CreateTemp("", "s2t-result")
+	if errTmpFile != nil {
+		return errTmpFile
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, errWrite := tmpFile.Write(content)
+	errClose := tmpFile.Close()
+	if errWrite != nil || errClose != nil {
+		return errors.Join(errors.New("error while writing result to temporary file"), errWrite, errClose)
+	}
+
+	storageObj := ParseUrlToGoStorageObject(destination)
+	storageObj.IsLocal = true
+	storageObj.LocalFilePath = tmpFile.Name()
+	if strings.EqualFold(storageObj.Region, "") && a.region != nil {
+		storageObj.Region = *a.region
+	}
+	return runWithContext(ctx, func() error {
+		return a.storage.UploadFile(storageObj)
+	}, nil)
+}
+
+type S2TDirectResultWrapper struct {
+	Result S2TDirectResult
+	Client GoS2TClient
+}
+
+// S2TDirect transforms the source file audio into text and returns the text via the returned channel.
+// Sources are handled in the same way as in S2T.
+// If content is redacted with RedactionOutputRedactedAndUnredacted (see ContentRedactionConfig), the result contains
+// the unredacted transcript as well (see S2TDirectResult.UnredactedTranscript).
+func (a GoS2TClient) S2TDirect(source string, options SpeechToTextOptions) <-chan S2TDirectResultWrapper {
+	return a.S2TDirectWithContext(context.Background(), source, options)
+}
+
+// S2TDirectWithContext works like S2TDirect, but all requests to the provider and storage services are bound to the
+// given context. If the context is cancelled or its deadline is exceeded, the result contains ctx.Err().
+func (a GoS2TClient) S2TDirectWithContext(ctx context.Context, source string, options SpeechToTextOptions) <-chan S2TDirectResultWrapper {
+	r := make(chan S2TDirectResultWrapper)
+
+	go func() {
+		defer close(r)
+
+		if a.shouldChunk(ctx, source, options) {
+			var result S2TDirectResult
+			a, result = a.s2tDirectChunked(ctx, source, "", options)
+			r <- S2TDirectResultWrapper{
+				Result: result,
+				Client: a,
+			}
+			return
+		}
+
+		var prepared preparedSource
+		var err error
+		a, prepared, err = a.prepareSource(ctx, source, "", options, false)
+		if err != nil {
+			r <- S2TDirectResultWrapper{
+				Result: S2TDirectResult{
+					Text: "",
+					Err:  err,
+				},
+				Client: a,
+			}
+			return
+		}
+
+		result := prepared.processResult(<-prepared.provider.ExecuteS2TDirect(ctx, prepared.source, prepared.options))
+
+		// Delete temporary files (if they should be deleted and if they exist)
+		a.deleteTempFiles(prepared)
+
+		r <- S2TDirectResultWrapper{
+			Result: result,
+			Client: a,
+		}
+		return
+	}()
+
+	return r
+}
+
+// preparedSource is the result of prepareSource.
+type preparedSource struct {
+	// provider is the provider instance with a service client for the selected region.
+	provider S2TProvider
+	// options are the options with a specified provider.
+	options SpeechToTextOptions
+	// source is the URL or path of the source file that is passed to the provider.
+	source string
+	// tmpUploadedFile is the source file that has been temporarily uploaded to a storage service (nil if no file was uploaded).
+	tmpUploadedFile *gostorage.GoStorageObject
+	// tmpLocalFile is the path of the converted source file (empty if the source file hasn't been converted).
+	tmpLocalFile string
+	// redaction is the content redaction config that is applied locally to the result, because the provider can't
+	// redact content natively (nil if no local redaction is needed, see getLocalRedaction).
+	redaction *ContentRedactionConfig
+	// profanityFilter is the profanity filter that is applied locally to the result, because the provider can't
+	// filter profanities natively (nil if no local filtering is needed, see getLocalProfanityFilter).
+	profanityFilter *ProfanityFilterConfig
+}
+
+// processResult applies the local content redaction and profanity filter (if any) to the given result of the provider.
+func (p preparedSource) processResult(result S2TDirectResult) S2TDirectResult {
+	return filterResultProfanity(redactResult(result, p.redaction), p.profanityFilter)
+}
+
+// prepareSource prepares the given source file for the transcription:
+// If the given options don't specify a provider, a provider is chosen based on heuristics.
+// If the client has no region preference, the region is inferred from the source or destination file.
+// If transcoding is enabled and the provider doesn't support the source file, it is converted into a local temporary
+// file (see SpeechToTextOptions.TranscodingConfig).
+// If the source file is a local file or a file from some other URL, and if the provider doesn't support direct file
+// input (or if requireStorage is true), the file is uploaded to the storage service of the provider.
+// Finally, the service client of the provider is created for the region.
+func (a GoS2TClient) prepareSource(ctx context.Context, source string, destination string, options SpeechToTextOptions, requireStorage bool) (GoS2TClient, preparedSource, error) {
 	if options.Provider == providers.ProviderUnspecified {
 		var err error
-		options, err = a.determineProvider(options, source)
+		options, err = a.determineProvider(ctx, options, source)
 		if err != nil {
-			return a, err
+			return a, preparedSource{}, err
 		}
 	}
 
-	newSource := source
-	// in some cases, a temporary file needs to be uploaded to a storage service
-	var tmpUploadedFile *gostorage.GoStorageObject = nil
+	// providers that can't redact content or filter profanities natively get options without these features,
+	// and the result is processed locally
+	redaction := getLocalRedaction(options)
+	if redaction != nil {
+		options.ContentRedactionConfig = ContentRedactionConfig{}
+	}
+	profanityFilter := getLocalProfanityFilter(options)
+	if profanityFilter != nil {
+		options.ProfanityFilter = false
+	}
+	prepared := preparedSource{
+		options:         options,
+		source:          source,
+		redaction:       redaction,
+		profanityFilter: profanityFilter,
+	}
 
 	provider := a.getProviderInstance(options.Provider)
+	if provider == nil {
+		return a, prepared, errors.New(fmt.Sprintf("The provider '%s' is not supported. Register it with providers.Register.", options.Provider))
+	}
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			removeTempLocalFile(prepared.tmpLocalFile)
+		}
+	}()
+	if !a.IsProviderStorageUrl(source) {
+		if fileType := GetAudioFileType(ctx, source); needsTranscoding(provider, fileType, options) {
+			transcodedFile, errTranscode := transcodeSource(ctx, provider, source, fileType, options)
+			if errTranscode != nil {
+				return a, prepared, errTranscode
+			}
+			source = transcodedFile
+			prepared.source = transcodedFile
+			prepared.tmpLocalFile = transcodedFile
+			// the converted file has a header -> audio format is detected automatically
+			prepared.options.AudioFormat = AudioFormat{}
+			options = prepared.options
+		}
+	}
 	if a.IsProviderStorageUrl(source) {
 		storageObj := ParseUrlToGoStorageObject(source)
 		if a.region == nil {
@@ -113,14 +445,6 @@ func (a GoS2TClient) S2T(source string, destination string, options SpeechToText
 				region = provider.GetDefaultRegion()
 			}
 			a.region = &region
-			cred := a.credentials
-
-			var errServiceClient error = nil
-			provider, errServiceClient = provider.CreateServiceClient(*cred, region)
-			if errServiceClient != nil {
-				return a, errors.Join(errors.New("error while creating S2T service client"), errServiceClient)
-			}
-
 		} else if !strings.EqualFold(*a.region, storageObj.Region) {
 			// File is in different region -> move file
 			destStorageObj := gostorage.GoStorageObject{
@@ -131,318 +455,348 @@ func (a GoS2TClient) S2T(source string, destination string, options SpeechToText
 				LocalFilePath: "",
 				ProviderType:  storageObj.ProviderType,
 			}
-			a.gostorageClient.Copy(storageObj, destStorageObj)
+			errCopy := runWithContext(ctx, func() error {
+				return a.storage.Copy(storageObj, destStorageObj)
+			}, nil)
+			if errCopy != nil {
+				return a, prepared, errCopy
+			}
 		}
-	} else if strings.HasPrefix(source, "http") { // file somewhere else online
+	} else {
 		if a.region == nil {
-			// Region preference is not set and source doesn't have region -> use region of destination file (if exists)
-			storageObjDest := ParseUrlToGoStorageObject(destination)
-			region := storageObjDest.Region
+			// Region preference is not set and source doesn't have region -> use region of destination file (if it is
+			// stored on the storage service of the provider)
+			region := ""
+			if provider.IsURLonOwnStorage(destination) {
+				region = getDestinationRegion(destination)
+			}
 			if strings.EqualFold(region, "") {
 				region = provider.GetDefaultRegion()
 			}
 			a.region = &region
 		}
 
-		if !provider.SupportsDirectFileInput() {
-			// direct file input not supported -> download file and upload to storage service
-
-			storageObj, errExtUrlToStorageObj := a.externalUrlToStorageObj(source, options)
-			if errExtUrlToStorageObj != nil {
-				return a, errExtUrlToStorageObj
+		if requireStorage || !provider.SupportsDirectFileInput() {
+			// direct file input not supported -> upload to storage
+			var storageObj *gostorage.GoStorageObject = nil
+			if strings.HasPrefix(source, "http") { // file somewhere else online -> download file first
+				var errExtUrlToStorageObj error = nil
+				storageObj, errExtUrlToStorageObj = a.externalUrlToStorageObj(ctx, source, options)
+				if errExtUrlToStorageObj != nil {
+					return a, prepared, errExtUrlToStorageObj
+				}
+			} else { // local file
+				storageObj = &gostorage.GoStorageObject{
+					Bucket:        options.TempBucket,
+					Key:           getTempKey(GetAudioFileType(ctx, source)),
+					Region:        *a.region,
+					IsLocal:       true,
+					LocalFilePath: source,
+					ProviderType:  ProviderToGoStorageProvider(options.Provider),
+				}
 			}
 
-			a.gostorageClient.UploadFile(*storageObj)
-			newSource = provider.GetStorageUrl(storageObj.Region, storageObj.Bucket, storageObj.Key)
-			tmpUploadedFile = storageObj
+			errUpload := runWithContext(ctx, func() error {
+				return a.storage.UploadFile(*storageObj)
+			}, func(err error) {
+				// the context was done before the upload finished -> delete the file once it has been uploaded
+				if err == nil {
+					a.deleteTempUploadedFile(storageObj)
+				}
+				if a.DeleteTempFile && !strings.EqualFold(storageObj.LocalFilePath, source) {
+					removeTempLocalFile(storageObj.LocalFilePath)
+				}
+			})
+			if errUpload != nil {
+				return a, prepared, errUpload
+			}
+			prepared.source = provider.GetStorageUrl(storageObj.Region, storageObj.Bucket, storageObj.Key)
+			prepared.tmpUploadedFile = storageObj
 
 			// delete temporarily stored audio file (if needed).
 			// The temporarily uploaded file is deleted after S2T has been executed.
-			if a.DeleteTempFile {
+			if a.DeleteTempFile && !strings.EqualFold(storageObj.LocalFilePath, source) {
 				removeErr := os.Remove(storageObj.LocalFilePath)
 				if removeErr != nil {
-					return a, removeErr
+					return a, prepared, errors.Join(errors.New("error while removing temporarily stored audio file"), removeErr)
 				}
 			}
 		}
-
-	} else { // local file
-		if a.region == nil {
-			// Region preference is not set and source doesn't have region -> use region of destination file (if exists)
-			storageObjDest := ParseUrlToGoStorageObject(destination)
-			region := storageObjDest.Region
-			if strings.EqualFold(region, "") {
-				region = provider.GetDefaultRegion()
-			}
-			a.region = &region
-		}
-
-		if !provider.SupportsDirectFileInput() {
-			// direct file input not supported -> upload to storage
-
-			storageObj := gostorage.GoStorageObject{
-				Bucket:        options.TempBucket,
-				Key:           strconv.FormatInt(time.Now().UnixNano(), 10), // essentially random key
-				Region:        *a.region,
-				IsLocal:       true,
-				LocalFilePath: source,
-				ProviderType:  ProviderToGoStorageProvider(options.Provider),
-			}
-			a.gostorageClient.UploadFile(storageObj)
-			newSource = provider.GetStorageUrl(storageObj.Region, storageObj.Bucket, storageObj.Key)
-			tmpUploadedFile = &storageObj
-		}
 	}
 
-	err := provider.ExecuteS2T(newSource, destination, options)
-	if err != nil {
-		return a, err
+	provider, errServiceClient := a.getServiceClient(ctx, options.Provider, *a.region)
+	if errServiceClient != nil {
+		return a, prepared, ContextError(ctx, errors.Join(errors.New("error while creating S2T service client"), errServiceClient))
 	}
+	prepared.provider = provider
 
-	// Delete temporarily uploaded file (if it should be deleted and if it exists)
-	if a.DeleteTempFile && (tmpUploadedFile != nil) {
-		a.gostorageClient.DeleteFile(*tmpUploadedFile)
+	var transformOptionsErr error = nil
+	prepared.source, prepared.options, transformOptionsErr = provider.TransformOptions(ctx, prepared.source, prepared.options)
+	if transformOptionsErr != nil {
+		return a, prepared, transformOptionsErr
 	}
 
-	return a, nil
+	succeeded = true
+	return a, prepared, nil
 }
 
-type S2TDirectResultWrapper struct {
-	Result S2TDirectResult
-	Client GoS2TClient
+// needsTranscoding returns true if the source file with the given file type should be converted before it is passed to
+// the given provider, according to options.TranscodingConfig.
+func needsTranscoding(provider S2TProvider, fileType string, options SpeechToTextOptions) bool {
+	config := options.TranscodingConfig
+	if !config.Enabled {
+		return false
+	}
+	if config.IsConversionRequested() {
+		return true
+	}
+	if options.AudioFormat.IsRaw() {
+		registration, _ := providers.GetRegistration(options.Provider)
+		return !registration.SupportsCapability(providers.CapabilityRawAudio)
+	}
+	return !provider.SupportsFileType(fileType)
 }
 
-func (a GoS2TClient) S2TDirect(source string, options SpeechToTextOptions) <-chan S2TDirectResultWrapper {
-	r := make(chan S2TDirectResultWrapper)
-
-	go func() {
-		defer close(r)
-
-		newSource := source
-		// in some cases, a temporary file needs to be uploaded to a storage service
-		var tmpUploadedFile *gostorage.GoStorageObject = nil
-
-		if options.Provider == providers.ProviderUnspecified {
-			var err error
-			options, err = a.determineProvider(options, source)
-			if err != nil {
-				r <- S2TDirectResultWrapper{
-					Result: S2TDirectResult{
-						Text: "",
-						Err:  err,
-					},
-					Client: a,
-				}
-				return
-			}
-		}
-
-		provider := a.getProviderInstance(options.Provider)
-		if a.IsProviderStorageUrl(source) {
-			storageObj := ParseUrlToGoStorageObject(source)
-			if a.region == nil {
-				// Region preference is not set -> use region of source file
-				region := storageObj.Region
-				a.region = &region
-				cred := a.credentials
-
-				var errServiceClient error = nil
-				provider, errServiceClient = provider.CreateServiceClient(*cred, region)
-				if errServiceClient != nil {
-					r <- S2TDirectResultWrapper{
-						Result: S2TDirectResult{
-							Text: "",
-							Err:  errors.Join(errors.New("error while creating S2T service client"), errServiceClient),
-						},
-						Client: a,
-					}
-					return
-				}
-
-			} else if !strings.EqualFold(*a.region, storageObj.Region) {
-				// File is in different region -> move file
-				destStorageObj := gostorage.GoStorageObject{
-					Bucket:        storageObj.Bucket,
-					Key:           storageObj.Key,
-					Region:        *a.region,
-					IsLocal:       false,
-					LocalFilePath: "",
-					ProviderType:  storageObj.ProviderType,
-				}
-				a.gostorageClient.Copy(storageObj, destStorageObj)
-			}
-		} else if strings.HasPrefix(source, "http") { // file somewhere else online
-			if a.region == nil {
-				region := provider.GetDefaultRegion()
-				a.region = &region
-			}
-
-			if !provider.SupportsDirectFileInput() {
-				// direct file input not supported -> download file and upload to storage service
-
-				storageObj, errExtUrlToStorageObj := a.externalUrlToStorageObj(source, options)
-				if errExtUrlToStorageObj != nil {
-					r <- S2TDirectResultWrapper{
-						Result: S2TDirectResult{
-							Text: "",
-							Err:  errExtUrlToStorageObj,
-						},
-						Client: a,
-					}
-					return
-				}
+// canTranscode returns true if the source file with the given file type can be converted into a file type that the
+// given provider supports, according to options.TranscodingConfig.
+func canTranscode(provider S2TProvider, fileType string, options SpeechToTextOptions) bool {
+	return options.TranscodingConfig.Enabled && CanDecodeAudio(fileType, options.AudioFormat) &&
+		!strings.EqualFold(GetTranscodingTarget(provider.SupportsFileType), "")
+}
 
-				a.gostorageClient.UploadFile(*storageObj)
-				newSource = provider.GetStorageUrl(storageObj.Region, storageObj.Bucket, storageObj.Key)
-				tmpUploadedFile = storageObj
-
-				// delete temporarily stored audio file (if needed).
-				// The temporarily uploaded file is deleted after S2T has been executed.
-				if a.DeleteTempFile {
-					removeErr := os.Remove(storageObj.LocalFilePath)
-					if removeErr != nil {
-						r <- S2TDirectResultWrapper{
-							Result: S2TDirectResult{
-								Text: "",
-								Err:  errors.Join(errors.New("error while removing temporarily stored audio file"), removeErr),
-							},
-							Client: a,
-						}
-						return
-					}
-				}
-			}
+// transcodeSource converts the given local file or file from some other URL into the first file type that the given
+// provider supports (see GetTranscodingTarget) and stores it in a local temporary file. The path of the temporary file
+// is returned.
+func transcodeSource(ctx context.Context, provider S2TProvider, source string, fileType string, options SpeechToTextOptions) (string, error) {
+	target := GetTranscodingTarget(provider.SupportsFileType)
+	if strings.EqualFold(target, "") {
+		return "", errors.New(fmt.Sprintf("Couldn't convert the source file '%s', because the provider '%s' doesn't support any file type that can be encoded.", source, options.Provider))
+	}
 
-		} else { // local file
-			if a.region == nil {
-				region := provider.GetDefaultRegion()
-				a.region = &region
-			}
+	content, errContent := ReadSourceContent(ctx, source)
+	if errContent != nil {
+		return "", errContent
+	}
+	converted, errTranscode := TranscodeAudio(content, fileType, options.AudioFormat, target, options.TranscodingConfig)
+	if errTranscode != nil {
+		return "", errors.Join(errors.New(fmt.Sprintf("Couldn't convert the source file '%s'.", source)), errTranscode)
+	}
 
-			if !provider.SupportsDirectFileInput() {
-				// direct file input not supported -> upload to storage
+	tmpFile, errTmpFile := os.CreateTemp("", "s2t-transcoded-*."+target)
+	if errTmpFile != nil {
+		return "", errTmpFile
+	}
+	_, errWrite := tmpFile.Write(converted)
+	errClose := tmpFile.Close()
+	if errWrite != nil || errClose != nil {
+		_ = os.Remove(tmpFile.Name())
+		return "", errors.Join(errors.New("error while writing converted audio to temporary file"), errWrite, errClose)
+	}
+	return tmpFile.Name(), nil
+}
 
-				storageObj := gostorage.GoStorageObject{
-					Bucket:        options.TempBucket,
-					Key:           strconv.FormatInt(time.Now().UnixNano(), 10), // essentially random key
-					Region:        *a.region,
-					IsLocal:       true,
-					LocalFilePath: source,
-					ProviderType:  ProviderToGoStorageProvider(options.Provider),
-				}
-				a.gostorageClient.UploadFile(storageObj)
-				newSource = provider.GetStorageUrl(storageObj.Region, storageObj.Bucket, storageObj.Key)
-				tmpUploadedFile = &storageObj
-			}
-		}
+// removeTempLocalFile removes the given local temporary file (if it is not empty).
+func removeTempLocalFile(path string) {
+	if strings.EqualFold(path, "") {
+		return
+	}
+	if errRemove := os.Remove(path); errRemove != nil {
+		fmt.Printf(errors.Join(errors.New(fmt.Sprintf("A non-fatal error occurred while removing the temporary file '%s'.", path)), errRemove).Error())
+	}
+}
 
-		var transformOptionsErr error = nil
-		newSource, options, transformOptionsErr = provider.TransformOptions(newSource, options)
-		if transformOptionsErr != nil {
-			r <- S2TDirectResultWrapper{
-				Result: S2TDirectResult{
-					Text: "",
-					Err:  transformOptionsErr,
-				},
-				Client: a,
-			}
-			return
-		}
+// getTempKey returns an essentially random key for a temporarily uploaded file with the given file type.
+// The file type is kept, because some providers (like AWS) use it to determine the media format.
+func getTempKey(fileType string) string {
+	return GetTempKey(fileType)
+}
 
-		result := <-provider.ExecuteS2TDirect(newSource, options)
+// getDestinationRegion returns the region of the given destination, if it is a storage URL that contains a region.
+// Otherwise, an empty string is returned.
+func getDestinationRegion(destination string) string {
+	if !IsAWSUrl(destination) && !IsGoogleUrl(destination) {
+		return ""
+	}
+	return ParseUrlToGoStorageObject(destination).Region
+}
 
-		// Delete temporarily uploaded file (if it should be deleted and if it exists)
-		if a.DeleteTempFile && (tmpUploadedFile != nil) {
-			a.gostorageClient.DeleteFile(*tmpUploadedFile)
-		}
+// deleteTempFiles deletes the temporary files of the given prepared source, i.e. the temporarily uploaded file
+// (if it should be deleted and if it exists) and the converted local file (if it exists).
+func (a GoS2TClient) deleteTempFiles(prepared preparedSource) {
+	a.deleteTempUploadedFile(prepared.tmpUploadedFile)
+	removeTempLocalFile(prepared.tmpLocalFile)
+}
 
-		r <- S2TDirectResultWrapper{
-			Result: S2TDirectResult{
-				Text: result.Text,
-				Err:  result.Err,
-			},
-			Client: a,
+// deleteTempUploadedFile deletes the given temporarily uploaded file (if it should be deleted and if it exists).
+func (a GoS2TClient) deleteTempUploadedFile(tmpUploadedFile *gostorage.GoStorageObject) {
+	if a.DeleteTempFile && (tmpUploadedFile != nil) {
+		errDelete := a.storage.DeleteFile(*tmpUploadedFile)
+		if errDelete != nil {
+			fmt.Printf(errors.Join(errors.New(fmt.Sprintf("A non-fatal error occurred while deleting the temporarily uploaded file '%s'.", tmpUploadedFile.Key)), errDelete).Error())
 		}
-		return
-	}()
-
-	return r
+	}
 }
 
 // determineProvider executes heuristics in order to determine the most optimal cloud provider for speech transcription
 // based on the input parameters.
+// The registered providers (see providers.Register) are narrowed down to those that support the capabilities required
+// by the operation (the given capabilities, e.g. providers.CapabilityStreaming), the capabilities required by the
+// options (see SpeechToTextOptions.GetRequiredCapabilities) and the file type of the source, which is detected
+// from the content of the source file if possible (see getAudioFileType).
+// Capabilities that no remaining provider supports are skipped, i.e. earlier capabilities have higher priority.
 // If returns the given SpeechToTextOptions with the 'Provider' property set to a specific provider.
-func (a GoS2TClient) determineProvider(options SpeechToTextOptions, source string) (SpeechToTextOptions, error) {
-
-	if strings.EqualFold(options.LanguageConfig.LanguageCode, "") {
-		// no language specified -> language needs to be determined -> only available on AWS
-		options.Provider = providers.ProviderAWS
-		return options, nil
+func (a GoS2TClient) determineProvider(ctx context.Context, options SpeechToTextOptions, source string, capabilities ...providers.Capability) (SpeechToTextOptions, error) {
+	// providers whose factory doesn't create an S2TProvider can't be used
+	var candidates []providers.Provider
+	for _, prov := range a.getAllProviders() {
+		if a.getProviderInstance(prov) != nil {
+			candidates = append(candidates, prov)
+		}
 	}
-
-	if !options.ContentRedactionConfig.IsEmpty() {
-		// only available on AWS
-		options.Provider = providers.ProviderAWS
-		return options, nil
+	if len(candidates) < 1 {
+		return options, errors.New("No S2T provider has been registered.")
 	}
 
-	if options.ProfanityFilter || options.EnableAutomaticPunctuation || options.EnableSpokenPunctuation || options.EnableSpokenEmojis {
-		// only available on GCP
-		options.Provider = providers.ProviderGCP
-		return options, nil
+	for _, capability := range append(capabilities, options.GetRequiredCapabilities()...) {
+		candidates = narrowProviders(candidates, func(prov providers.Provider) bool {
+			registration, _ := providers.GetRegistration(prov)
+			return registration.SupportsCapability(capability)
+		})
 	}
 
 	// use provider that supports the source file type
-	fileType := GetFileTypeFromFileName(source)
-	availProv := make(map[providers.Provider]bool)
-	numAvailProv := 0
-	for _, prov := range providers.GetAllProviders() {
-		if a.getProviderInstance(prov).SupportsFileType(fileType) {
-			availProv[prov] = true
-			numAvailProv++
-		} else {
-			availProv[prov] = false
-		}
-	}
-
-	// Only one provider supports file type -> return that provider
-	if numAvailProv == 1 {
-		for prov, avail := range availProv {
-			if avail {
-				options.Provider = prov
-				return options, nil
+	fileType := a.getAudioFileType(ctx, source)
+	candidates = narrowProviders(candidates, func(prov providers.Provider) bool {
+		instance := a.getProviderInstance(prov)
+		return instance.SupportsFileType(fileType) || canTranscode(instance, fileType, options)
+	})
+
+	// More than one provider satisfies the requirements -> use default provider, if possible
+	for _, prov := range candidates {
+		if prov == DefaultProvider {
+			options.Provider = prov
+			return options, nil
+		}
+	}
+	options.Provider = candidates[0]
+	return options, nil
+}
+
+// getAudioFileType returns the file type of the given source (see GetAudioFileType). Files on the storage service of a
+// provider are detected from their content as well, by reading their header through the storage client of the
+// provider (see StorageAudioHeaderReader). If the header can't be read, the file extension is used.
+func (a GoS2TClient) getAudioFileType(ctx context.Context, source string) string {
+	for _, provider := range a.getAllProviders() {
+		instance := a.getProviderInstance(provider)
+		if instance == nil || !instance.IsURLonOwnStorage(source) {
+			continue
+		}
+		if _, ok := instance.(StorageAudioHeaderReader); !ok {
+			// the header can't be read -> no need to create a service client
+			break
+		}
+		region := getDestinationRegion(source)
+		if strings.EqualFold(region, "") {
+			region = instance.GetDefaultRegion()
+			if a.region != nil {
+				region = *a.region
 			}
 		}
+		instance, err := a.getServiceClient(ctx, provider, region)
+		if err != nil {
+			break
+		}
+		reader, ok := instance.(StorageAudioHeaderReader)
+		if !ok {
+			break
+		}
+		if header, err := reader.ReadStorageAudioHeader(ctx, source); err == nil {
+			if fileType := DetectAudioFileType(header); !strings.EqualFold(fileType, "") {
+				return fileType
+			}
+		}
+		break
 	}
+	return GetAudioFileType(ctx, source)
+}
 
-	// Either: requirement cannot be satisfied -> use any provider
-	// Or: More than one provider supports file type -> use any provider
-	options.Provider = DefaultProvider
-	return options, nil
+// narrowProviders returns the given providers that satisfy the given requirement.
+// If no provider satisfies the requirement, all given providers are returned.
+func narrowProviders(candidates []providers.Provider, requirement func(prov providers.Provider) bool) []providers.Provider {
+	var narrowed []providers.Provider
+	for _, prov := range candidates {
+		if requirement(prov) {
+			narrowed = append(narrowed, prov)
+		}
+	}
+	if len(narrowed) < 1 {
+		return candidates
+	}
+	return narrowed
 }
 
 func (a GoS2TClient) initializeGoStorage() GoS2TClient {
-	if a.gostorageClient == nil {
-		a.gostorageClient = &gostorage.GoStorage{
-			Credentials: *a.credentials,
+	if a.storage == nil {
+		a.storage = NewGoStorage(*a.credentials)
+	}
+	return a
+}
+
+// WithStorage returns a copy of the client that uses the given storage instead of GoStorage
+// (e.g. a fake storage for testing, see the s2ttest package).
+func (a GoS2TClient) WithStorage(storage Storage) GoS2TClient {
+	a.storage = storage
+	return a
+}
+
+// WithProviderInstance returns a client that uses the given instance for the given provider, instead of an instance
+// created by the factory the provider has been registered with (e.g. a fake provider for testing, see the s2ttest
+// package). The provider doesn't need to be registered. However, providers that aren't registered have no capabilities
+// (see providers.Register).
+// Note that the returned client shares its provider instances with the original client.
+func (a GoS2TClient) WithProviderInstance(provider providers.Provider, instance S2TProvider) GoS2TClient {
+	a.providerMutex.Lock()
+	defer a.providerMutex.Unlock()
+	a.providerInstances[provider] = &instance
+	for key := range a.serviceClients {
+		if key.provider == provider {
+			delete(a.serviceClients, key)
 		}
 	}
 	return a
 }
 
+// getAllProviders returns all registered providers and all providers with an instance given to WithProviderInstance.
+func (a GoS2TClient) getAllProviders() []providers.Provider {
+	a.providerMutex.Lock()
+	defer a.providerMutex.Unlock()
+	var unregistered []providers.Provider
+	for provider := range a.providerInstances {
+		if _, registered := providers.GetRegistration(provider); !registered {
+			unregistered = append(unregistered, provider)
+		}
+	}
+	sort.Slice(unregistered, func(i, j int) bool { return unregistered[i] < unregistered[j] })
+	return append(providers.GetAllProviders(), unregistered...)
+}
+
+// CreateProviderInstance creates a new instance of the given provider, using the factory it has been registered with
+// (see providers.Register). If the provider hasn't been registered, or its factory doesn't create an S2TProvider,
+// nil is returned.
 func CreateProviderInstance(provider providers.Provider) S2TProvider {
-	switch provider {
-	case providers.ProviderAWS:
-		return s2t_aws.S2TAmazonWebServices{}
-	case providers.ProviderGCP:
-		return s2t_gcp.S2TGoogleCloudPlatform{}
-	default:
+	registration, exists := providers.GetRegistration(provider)
+	if !exists {
+		return nil
+	}
+	instance, ok := registration.Factory().(S2TProvider)
+	if !ok {
 		return nil
 	}
+	return instance
 }
 
-func (a GoS2TClient) externalUrlToStorageObj(url string, options SpeechToTextOptions) (*gostorage.GoStorageObject, error) {
-	reader, errDownload := ReadFromUrl(url)
+func (a GoS2TClient) externalUrlToStorageObj(ctx context.Context, url string, options SpeechToTextOptions) (*gostorage.GoStorageObject, error) {
+	reader, errDownload := ReadFromUrlWithContext(ctx, url)
 	if errDownload != nil {
 		return nil, errDownload
 	}
@@ -462,7 +816,12 @@ func (a GoS2TClient) externalUrlToStorageObj(url string, options SpeechToTextOpt
 
 	errStoreFile := StoreAudioToLocalFile(reader, tmpFile)
 	if errStoreFile != nil {
-		return nil, errStoreFile
+		return nil, ContextError(ctx, errStoreFile)
+	}
+	if ctx.Err() != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpFile.Name())
+		return nil, ctx.Err()
 	}
 
 	errClose := tmpFile.Close()
@@ -470,9 +829,13 @@ func (a GoS2TClient) externalUrlToStorageObj(url string, options SpeechToTextOpt
 		return nil, errClose
 	}
 
+	fileType := GetAudioFileType(ctx, tmpFile.Name())
+	if strings.EqualFold(fileType, "") {
+		fileType = GetFileTypeFromFileName(url)
+	}
 	storageObj := gostorage.GoStorageObject{
 		Bucket:        options.TempBucket,
-		Key:           strconv.FormatInt(time.Now().UnixNano(), 10), // essentially random key
+		Key:           getTempKey(fileType),
 		Region:        *a.region,
 		IsLocal:       true,
 		LocalFilePath: tmpFile.Name(),
@@ -483,12 +846,37 @@ func (a GoS2TClient) externalUrlToStorageObj(url string, options SpeechToTextOpt
 }
 
 // IsProviderStorageUrl checks if the given string is a valid file URL for a storage service of one of the
-// supported storage providers.
+// registered providers.
 func (a GoS2TClient) IsProviderStorageUrl(url string) bool {
-	for _, provider := range providers.GetAllProviders() {
-		if a.getProviderInstance(provider).IsURLonOwnStorage(url) {
+	for _, provider := range a.getAllProviders() {
+		if instance := a.getProviderInstance(provider); instance != nil && instance.IsURLonOwnStorage(url) {
 			return true
 		}
 	}
 	return false
 }
+
+// runWithContext executes the given function and returns its error as soon as it has finished, or ctx.Err() as soon as
+// the given context is done. This is used for GoStorage operations, which don't support contexts.
+// Note that a function that is still running when the context is done keeps running in the background. Once it has
+// finished, its error is passed to cleanup (if not nil), e.g. to delete a file that has been uploaded after all.
+func runWithContext(ctx context.Context, f func() error, cleanup func(err error)) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- f()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if cleanup != nil {
+			go func() {
+				cleanup(<-done)
+			}()
+		}
+		return ctx.Err()
+	}
+}
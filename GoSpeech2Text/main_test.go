@@ -0,0 +1,821 @@
+package GoText2Speech
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/FaaSTools/GoStorage/gostorage"
+	s2tAws "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/aws"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/s2ttest"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	awsSdk "github.com/aws/aws-sdk-go-v2/aws"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const fakeProviderName providers.Provider = "FAKE"
+
+func createTestClient(fake *s2ttest.FakeProvider) (GoS2TClient, *s2ttest.FakeStorage) {
+	storage := s2ttest.NewFakeStorage()
+	client := CreateGoS2TClient(&CredentialsHolder{}, "").
+		WithStorage(storage).
+		WithProviderInstance(fakeProviderName, fake)
+	return client, storage
+}
+
+func createTestAudioFile(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "audio.wav")
+	if err := os.WriteFile(path, []byte("RIFF"), 0644); err != nil {
+		t.Fatal("couldn't create audio file: ", err)
+	}
+	return path
+}
+
+func getTestOptions() SpeechToTextOptions {
+	options := GetDefaultSpeechToTextOptions()
+	options.Provider = fakeProviderName
+	options.TempBucket = "temp-bucket"
+	options.LanguageConfig.LanguageCode = "en-US"
+	return *options
+}
+
+func TestDetermineProvider(t *testing.T) {
+	client := CreateGoS2TClient(&CredentialsHolder{}, "")
+
+	options, _ := client.determineProvider(context.Background(), SpeechToTextOptions{}, "audio.mp3")
+	if options.Provider != providers.ProviderAWS {
+		t.Error("expected AWS for language identification: Got ", options.Provider)
+	}
+
+	options, _ = client.determineProvider(context.Background(), SpeechToTextOptions{LanguageConfig: LanguageConfig{LanguageCode: "en-US"}, ProfanityFilter: true}, "audio.mp3")
+	if options.Provider != providers.ProviderAWS {
+		t.Error("expected profanity filter not to dictate the provider for mp3: Got ", options.Provider)
+	}
+
+	options, _ = client.determineProvider(context.Background(), SpeechToTextOptions{ProfanityFilter: true}, "audio.mp3")
+	if options.Provider != providers.ProviderAWS {
+		t.Error("expected language identification to have priority: Got ", options.Provider)
+	}
+
+	options, _ = client.determineProvider(context.Background(), SpeechToTextOptions{LanguageConfig: LanguageConfig{LanguageCode: "en-US"}}, "audio.mp3")
+	if options.Provider != DefaultProvider {
+		t.Error("expected default provider: Got ", options.Provider)
+	}
+
+	entityType := RedactionEntityAll
+	redaction := ContentRedactionConfig{RedactionEntityTypes: []*RedactionEntityType{&entityType}}
+	options, _ = client.determineProvider(context.Background(), SpeechToTextOptions{LanguageConfig: LanguageConfig{LanguageCode: "es-ES"}, ContentRedactionConfig: redaction}, "audio.flac")
+	if options.Provider != DefaultProvider {
+		t.Error("expected content redaction not to dictate the provider: Got ", options.Provider)
+	}
+
+	options, _ = client.determineProvider(context.Background(), SpeechToTextOptions{LanguageConfig: LanguageConfig{LanguageCode: "en-US"}, AudioFormat: AudioFormat{Encoding: AudioEncodingMulaw}}, "audio.raw")
+	if options.Provider != providers.ProviderGCP {
+		t.Error("expected GCP for raw audio: Got ", options.Provider)
+	}
+}
+
+func TestDetermineProviderForStreaming(t *testing.T) {
+	providers.Register("NO_STREAMING", func() interface{} { return s2ttest.NewFakeProvider("NO_STREAMING", "") }, nil, gostorage.ProviderAWS)
+	t.Cleanup(func() { providers.Unregister("NO_STREAMING") })
+	client := CreateGoS2TClient(&CredentialsHolder{}, "")
+
+	// only the provider without streaming supports the file type
+	options := SpeechToTextOptions{LanguageConfig: LanguageConfig{LanguageCode: "en-US"}}
+	if determined, _ := client.determineProvider(context.Background(), options, "audio.xyz"); determined.Provider != "NO_STREAMING" {
+		t.Error("expected provider that supports the file type: Got ", determined.Provider)
+	}
+	determined, err := client.determineProvider(context.Background(), options, "audio.xyz", providers.CapabilityStreaming)
+	if registration, _ := providers.GetRegistration(determined.Provider); err != nil || !registration.SupportsCapability(providers.CapabilityStreaming) {
+		t.Error("expected provider that supports streaming: Got ", determined.Provider, err)
+	}
+}
+
+func TestDetermineProviderSkipsInvalidProvider(t *testing.T) {
+	providers.Register("INVALID", func() interface{} { return struct{}{} }, nil, gostorage.ProviderAWS)
+	t.Cleanup(func() { providers.Unregister("INVALID") })
+	client := CreateGoS2TClient(&CredentialsHolder{}, "")
+
+	options, err := client.determineProvider(context.Background(), SpeechToTextOptions{LanguageConfig: LanguageConfig{LanguageCode: "en-US"}}, "audio.mp3")
+	if err != nil || options.Provider != DefaultProvider {
+		t.Error("wrong provider: Got ", options.Provider, err)
+	}
+}
+
+func TestGetAudioFileTypeOnProviderStorage(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	fake.StorageUrlPrefix = "fake://"
+	fake.StorageFiles = map[string][]byte{"fake://bucket/recording.mp3": []byte("fLaC\x00\x00\x00\x22")}
+	client, _ := createTestClient(fake)
+
+	if fileType := client.getAudioFileType(context.Background(), "fake://bucket/recording.mp3"); fileType != "flac" {
+		t.Error("wrong file type: Got ", fileType)
+	}
+	if fileType := client.getAudioFileType(context.Background(), "fake://bucket/missing.ogg"); fileType != "ogg" {
+		t.Error("expected file extension for unreadable file: Got ", fileType)
+	}
+	if calls := fake.CallsTo("ReadStorageAudioHeader"); len(calls) != 2 || calls[0].Source != "fake://bucket/recording.mp3" {
+		t.Error("wrong header reads: Got ", calls)
+	}
+}
+
+func TestGetAudioFileTypeWithoutStorageAudioHeaderReader(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	fake.StorageUrlPrefix = "fake://"
+	fake.StorageFiles = map[string][]byte{"fake://bucket/recording.mp3": []byte("fLaC\x00\x00\x00\x22")}
+	client := CreateGoS2TClient(&CredentialsHolder{}, "").
+		WithProviderInstance(fakeProviderName, s2ttest.BasicProvider{S2TProvider: fake})
+
+	if fileType := client.getAudioFileType(context.Background(), "fake://bucket/recording.mp3"); fileType != "mp3" {
+		t.Error("expected file extension: Got ", fileType)
+	}
+	if calls := fake.CallsTo("CreateServiceClient"); len(calls) != 0 {
+		t.Error("expected no service client to be created: Got ", calls)
+	}
+}
+
+func TestS2TDirectUploadsAndDeletesTempFile(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	client, storage := createTestClient(fake)
+	source := createTestAudioFile(t)
+
+	result := <-client.S2TDirect(source, getTestOptions())
+	if result.Result.Err != nil || result.Result.Text != "hello world" {
+		t.Fatal("wrong result: Got ", result.Result)
+	}
+
+	uploads := storage.Uploads()
+	if len(uploads) != 1 || uploads[0].Bucket != "temp-bucket" || uploads[0].LocalFilePath != source {
+		t.Fatal("wrong uploads: Got ", uploads)
+	}
+	if !strings.HasSuffix(uploads[0].Key, ".wav") {
+		t.Error("expected file type in temporary key: Got ", uploads[0].Key)
+	}
+	deletes := storage.Deletes()
+	if len(deletes) != 1 || deletes[0].Key != uploads[0].Key {
+		t.Error("expected temp file to be deleted: Got ", deletes)
+	}
+
+	calls := fake.CallsTo("ExecuteS2TDirect")
+	if len(calls) != 1 || calls[0].Source != "fake://temp-bucket/"+uploads[0].Key || calls[0].Region != s2ttest.DefaultFakeRegion {
+		t.Error("wrong provider calls: Got ", calls)
+	}
+}
+
+func TestS2TDirectDirectFileInput(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	fake.DirectFileInput = true
+	client, storage := createTestClient(fake)
+	source := createTestAudioFile(t)
+
+	result := <-client.S2TDirect(source, getTestOptions())
+	if result.Result.Err != nil {
+		t.Fatal("unexpected error: ", result.Result.Err)
+	}
+	if len(storage.Uploads()) != 0 || fake.CallsTo("ExecuteS2TDirect")[0].Source != source {
+		t.Error("expected local file to be passed directly: Got ", fake.Calls())
+	}
+}
+
+func TestS2TProviderError(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "")
+	errTranscribe := errors.New("transcription failed")
+	fake.TranscribeFunc = func(ctx context.Context, source string, options SpeechToTextOptions) (Transcript, error) {
+		return Transcript{}, errTranscribe
+	}
+	client, storage := createTestClient(fake)
+
+	_, err := client.S2T(createTestAudioFile(t), "s3://bucket/result.txt", getTestOptions())
+	if !errors.Is(err, errTranscribe) {
+		t.Error("wrong error: Got ", err)
+	}
+	if len(storage.Deletes()) != 1 {
+		t.Error("expected temp file to be deleted after error: Got ", storage.Deletes())
+	}
+}
+
+func TestS2TCanceledDuringUpload(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	client, storage := createTestClient(fake)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	storage.BeforeUpload = func(obj gostorage.GoStorageObject) {
+		cancel()
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	_, err := client.S2TWithContext(ctx, createTestAudioFile(t), "s3://bucket/result.txt", getTestOptions())
+	if !errors.Is(err, context.Canceled) {
+		t.Error("wrong error: Got ", err)
+	}
+	for i := 0; i < 100 && len(storage.Deletes()) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	uploads, deletes := storage.Uploads(), storage.Deletes()
+	if len(uploads) != 1 || len(deletes) != 1 || deletes[0].Key != uploads[0].Key {
+		t.Error("expected uploaded file to be deleted after cancellation: Got ", uploads, deletes)
+	}
+	if len(fake.CallsTo("ExecuteS2T")) != 0 {
+		t.Error("expected no transcription: Got ", fake.Calls())
+	}
+}
+
+func TestS2TUploadError(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	client, storage := createTestClient(fake)
+	storage.UploadErr = errors.New("upload failed")
+
+	_, err := client.S2T(createTestAudioFile(t), "s3://bucket/result", getTestOptions())
+	if !errors.Is(err, storage.UploadErr) {
+		t.Error("wrong error: Got ", err)
+	}
+	if len(fake.CallsTo("ExecuteS2T")) != 0 {
+		t.Error("expected no transcription after upload error")
+	}
+}
+
+func TestS2TOutputFormat(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	client, storage := createTestClient(fake)
+
+	_, err := client.S2T(createTestAudioFile(t), "s3://result-bucket/result.srt", getTestOptions())
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	content, exists := storage.File("result-bucket", "result.srt")
+	if !exists || !strings.HasPrefix(string(content), "1\n00:00:00,000 --> 00:00:01,000\nhello world") {
+		t.Error("wrong result file: Got ", string(content))
+	}
+
+	// no output format -> provider writes its result file
+	_, err = client.S2T(createTestAudioFile(t), "s3://result-bucket/result", getTestOptions())
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if text, _ := fake.Output("s3://result-bucket/result"); text != "hello world" {
+		t.Error("wrong provider output: Got ", text)
+	}
+}
+
+func TestStartS2TWaitS2T(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	fake.JobStatuses = []JobStatus{JobStatusInProgress, JobStatusCompleted}
+	client, storage := createTestClient(fake)
+	options := getTestOptions()
+	options.TranscriptionJobCheckIntervalMs = 1
+
+	job, err := client.StartS2T(context.Background(), createTestAudioFile(t), "", options)
+	if err != nil || job.TempSourceFile == nil {
+		t.Fatal("wrong job: Got ", job, err)
+	}
+
+	job, err = client.WaitS2T(context.Background(), job, options)
+	if err != nil || job.Status != JobStatusCompleted {
+		t.Fatal("wrong job: Got ", job, err)
+	}
+	if job.TempSourceFile != nil || len(storage.Deletes()) != 1 {
+		t.Error("expected temp file to be deleted after job completed: Got ", storage.Deletes())
+	}
+	if result := client.GetS2TResult(context.Background(), job); result.Text != "hello world" {
+		t.Error("wrong result: Got ", result)
+	}
+}
+
+func TestStartS2TWithoutTranscriptionJobProvider(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	storage := s2ttest.NewFakeStorage()
+	client := CreateGoS2TClient(&CredentialsHolder{}, "").
+		WithStorage(storage).
+		WithProviderInstance(fakeProviderName, s2ttest.BasicProvider{S2TProvider: fake})
+
+	_, err := client.StartS2T(context.Background(), createTestAudioFile(t), "", getTestOptions())
+	if err == nil || !strings.Contains(err.Error(), "transcription jobs") {
+		t.Error("expected error for provider without transcription jobs: Got ", err)
+	}
+	if len(storage.Uploads()) != len(storage.Deletes()) {
+		t.Error("expected uploaded file to be deleted: Got ", storage.Deletes())
+	}
+	if _, err = client.GetS2TStatus(context.Background(), TranscriptionJob{Name: "job", Provider: fakeProviderName}); err == nil {
+		t.Error("expected error for provider without transcription jobs")
+	}
+}
+
+func TestS2TDirectTranscodesUnsupportedAudio(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	fake.SupportedFileTypes = []string{"wav"}
+	client, storage := createTestClient(fake)
+	client.DeleteTempFile = false
+	source := filepath.Join(t.TempDir(), "audio.ulaw")
+	if err := os.WriteFile(source, []byte{0xFF, 0xFF, 0x00, 0x00}, 0644); err != nil {
+		t.Fatal("couldn't create audio file: ", err)
+	}
+	options := getTestOptions()
+	options.AudioFormat = AudioFormat{Encoding: AudioEncodingMulaw, SampleRateHertz: 8000}
+	options.TranscodingConfig = TranscodingConfig{Enabled: true}
+
+	result := <-client.S2TDirect(source, options)
+	if result.Result.Err != nil {
+		t.Fatal("unexpected error: ", result.Result.Err)
+	}
+
+	uploads := storage.Uploads()
+	if len(uploads) != 1 || !strings.HasSuffix(uploads[0].Key, ".wav") || uploads[0].LocalFilePath == source {
+		t.Fatal("wrong uploads: Got ", uploads)
+	}
+	if content, _ := storage.File("temp-bucket", uploads[0].Key); DetectAudioFileType(content) != "wav" || len(content) != 44+8 {
+		t.Error("wrong uploaded file: Got ", content)
+	}
+	if _, err := os.Stat(uploads[0].LocalFilePath); !os.IsNotExist(err) {
+		t.Error("expected converted file to be removed: Got ", err)
+	}
+	if calls := fake.CallsTo("ExecuteS2TDirect"); len(calls) != 1 || calls[0].Options.AudioFormat.IsRaw() {
+		t.Error("expected audio format to be reset after conversion: Got ", calls)
+	}
+}
+
+func TestS2TDirectChunked(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	fake.DirectFileInput = true
+	client, _ := createTestClient(fake)
+	wav, _ := EncodeAudio(PCMAudio{Samples: make([]int16, 3000), SampleRateHertz: 1000, ChannelCount: 1}, "wav")
+	source := filepath.Join(t.TempDir(), "audio.wav")
+	if err := os.WriteFile(source, wav, 0644); err != nil {
+		t.Fatal("couldn't create audio file: ", err)
+	}
+	options := getTestOptions()
+	options.ChunkingConfig = ChunkingConfig{Enabled: true, ChunkDurationMs: 1000, OverlapMs: 100, MaxConcurrency: 2}
+	options.TranscriptionJobName = TranscriptionJobNameConfig{TranscriptionJobName: "job"}
+
+	result := <-client.S2TDirect(source, options)
+	if result.Result.Err != nil {
+		t.Fatal("unexpected error: ", result.Result.Err)
+	}
+
+	calls := fake.CallsTo("ExecuteS2TDirect")
+	if len(calls) != 5 {
+		t.Fatal("wrong number of transcribed chunks: Got ", len(calls))
+	}
+	jobNames := make(map[string]bool)
+	for _, call := range calls {
+		if call.Source == source || call.Options.ChunkingConfig.Enabled {
+			t.Error("expected chunk to be transcribed: Got ", call)
+		}
+		jobNames[call.Options.TranscriptionJobName.GetTranscriptionJobName()] = true
+		if _, err := os.Stat(call.Source); !os.IsNotExist(err) {
+			t.Error("expected chunk file to be removed: Got ", err)
+		}
+	}
+	if len(jobNames) != 5 || !jobNames["job-1"] || !jobNames["job-5"] {
+		t.Error("expected unique job names for chunks: Got ", jobNames)
+	}
+	if result.Result.Text != strings.TrimSpace(strings.Repeat("hello world ", 5)) || len(result.Result.Transcript.Segments) != 5 {
+		t.Error("wrong merged result: Got ", result.Result.Text)
+	}
+	if segment := result.Result.Transcript.Segments[4]; segment.StartTime != 2640*time.Millisecond {
+		t.Error("wrong offset of last chunk: Got ", segment.StartTime)
+	}
+}
+
+func TestS2TDirectChunkedCanceled(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	fake.DirectFileInput = true
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var calls atomic.Int32
+	fake.TranscribeFunc = func(ctx context.Context, source string, options SpeechToTextOptions) (Transcript, error) {
+		if calls.Add(1) == 2 {
+			// the remaining chunks are still waiting for the semaphore and are skipped
+			cancel()
+			time.Sleep(50 * time.Millisecond)
+		}
+		return Transcript{Text: "hello world"}, nil
+	}
+	client, _ := createTestClient(fake)
+	wav, _ := EncodeAudio(PCMAudio{Samples: make([]int16, 3000), SampleRateHertz: 1000, ChannelCount: 1}, "wav")
+	source := filepath.Join(t.TempDir(), "audio.wav")
+	if err := os.WriteFile(source, wav, 0644); err != nil {
+		t.Fatal("couldn't create audio file: ", err)
+	}
+	options := getTestOptions()
+	options.ChunkingConfig = ChunkingConfig{Enabled: true, ChunkDurationMs: 1000, OverlapMs: 100, MaxConcurrency: 1}
+
+	result := <-client.S2TDirectWithContext(ctx, source, options)
+	if !errors.Is(result.Result.Err, context.Canceled) {
+		t.Error("wrong error: Got ", result.Result.Err, result.Result.Text)
+	}
+}
+
+func TestStreamingS2T(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	client, _ := createTestClient(fake)
+	options := getTestOptions()
+	options.AudioFormat = AudioFormat{Encoding: AudioEncodingLinear16, SampleRateHertz: 16000}
+
+	results, err := client.StreamingS2T(context.Background(), strings.NewReader("audio"), options)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	var received []PartialResult
+	for result := range results {
+		received = append(received, result)
+	}
+	if len(received) != 2 || received[0].IsFinal || !received[1].IsFinal || received[1].Text != "hello world" {
+		t.Error("wrong results: Got ", received)
+	}
+	if string(fake.StreamedAudio()) != "audio" {
+		t.Error("wrong streamed audio: Got ", fake.StreamedAudio())
+	}
+	if calls := fake.CallsTo("CreateServiceClient"); len(calls) != 1 || calls[0].Region != s2ttest.DefaultFakeRegion {
+		t.Error("expected service client for default region: Got ", calls)
+	}
+}
+
+func TestStreamingS2TWithoutStreamingProvider(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	client := CreateGoS2TClient(&CredentialsHolder{}, "").
+		WithProviderInstance(fakeProviderName, s2ttest.BasicProvider{S2TProvider: fake})
+	options := getTestOptions()
+	options.AudioFormat = AudioFormat{Encoding: AudioEncodingLinear16, SampleRateHertz: 16000}
+
+	if _, err := client.StreamingS2T(context.Background(), strings.NewReader("audio"), options); err == nil || !strings.Contains(err.Error(), "streaming") {
+		t.Error("expected error for provider without streaming: Got ", err)
+	}
+}
+
+func TestS2TBatch(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "")
+	fake.DirectFileInput = true
+	errTranscribe := errors.New("transcription failed")
+	var running, maxRunning atomic.Int32
+	fake.TranscribeFunc = func(ctx context.Context, source string, options SpeechToTextOptions) (Transcript, error) {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			previous := maxRunning.Load()
+			if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if strings.HasSuffix(source, "fail.wav") {
+			return Transcript{}, errTranscribe
+		}
+		return Transcript{Text: filepath.Base(source)}, nil
+	}
+	client, _ := createTestClient(fake)
+
+	var items []BatchItem
+	for i := 0; i < 6; i++ {
+		name := "audio" + strconv.Itoa(i) + ".wav"
+		if i == 2 {
+			name = "fail.wav"
+		}
+		items = append(items, BatchItem{Source: filepath.Join(t.TempDir(), name), Options: getTestOptions()})
+	}
+
+	indexes := make(map[int]bool)
+	for result := range client.S2TBatch(items, BatchOptions{MaxConcurrency: 2}) {
+		indexes[result.Index] = true
+		if result.Index == 2 {
+			if !errors.Is(result.Result.Err, errTranscribe) {
+				t.Error("wrong error of failed item: Got ", result.Result.Err)
+			}
+		} else if result.Result.Err != nil || result.Result.Text != filepath.Base(items[result.Index].Source) {
+			t.Error("wrong result of item ", result.Index, ": Got ", result.Result)
+		}
+	}
+	if len(indexes) != len(items) {
+		t.Error("wrong number of results: Got ", len(indexes))
+	}
+	if maxRunning.Load() != 2 {
+		t.Error("wrong maximum number of concurrent transcriptions: Got ", maxRunning.Load())
+	}
+}
+
+func TestS2TIgnoresRegionOfForeignDestination(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	fake.DirectFileInput = true
+	client, storage := createTestClient(fake)
+
+	// the destination isn't stored on the storage service of the provider -> its region must not be used
+	_, err := client.S2T(createTestAudioFile(t), "https://result-bucket.s3.eu-central-1.amazonaws.com/transcript.txt", getTestOptions())
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if calls := fake.CallsTo("CreateServiceClient"); len(calls) != 1 || calls[0].Region != s2ttest.DefaultFakeRegion {
+		t.Error("expected service client for default region: Got ", calls)
+	}
+	if _, exists := storage.File("result-bucket", "transcript.txt"); !exists {
+		t.Error("expected result file to be stored")
+	}
+}
+
+func TestServiceClientsPerRegion(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	client, _ := createTestClient(fake)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(region string) {
+			defer wg.Done()
+			if _, err := client.getServiceClient(context.Background(), fakeProviderName, region); err != nil {
+				t.Error("unexpected error: ", err)
+			}
+		}([]string{"region-1", "region-2"}[i%2])
+	}
+	wg.Wait()
+	if calls := fake.CallsTo("CreateServiceClient"); len(calls) != 2 {
+		t.Error("expected one service client per region: Got ", calls)
+	}
+
+	if err := client.CloseProviderClient(fakeProviderName); err != nil {
+		t.Error("unexpected error: ", err)
+	}
+	if _, err := client.getServiceClient(context.Background(), fakeProviderName, "region-1"); err != nil {
+		t.Error("unexpected error: ", err)
+	}
+	if calls := fake.CallsTo("CreateServiceClient"); len(calls) != 3 {
+		t.Error("expected service client to be created again after closing: Got ", calls)
+	}
+}
+
+func TestS2TBatchRateLimit(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	fake.DirectFileInput = true
+	client, _ := createTestClient(fake)
+	options := getTestOptions()
+	options.TranscriptionJobName = TranscriptionJobNameConfig{TranscriptionJobName: "job"}
+	items := make([]BatchItem, 3)
+	for i := range items {
+		items[i] = BatchItem{Source: createTestAudioFile(t), Options: options}
+	}
+
+	start := time.Now()
+	count := 0
+	for result := range client.S2TBatch(items, BatchOptions{RateLimits: map[providers.Provider]float64{fakeProviderName: 20}}) {
+		if result.Result.Err != nil {
+			t.Error("unexpected error: ", result.Result.Err)
+		}
+		count++
+	}
+	// 3 transcriptions with 20 per second -> last one starts after 100 ms
+	if elapsed := time.Since(start); count != 3 || elapsed < 100*time.Millisecond {
+		t.Error("expected transcriptions to be rate limited: Got ", count, " results after ", elapsed)
+	}
+	jobNames := make(map[string]bool)
+	for _, call := range fake.CallsTo("ExecuteS2TDirect") {
+		jobNames[call.Options.TranscriptionJobName.GetTranscriptionJobName()] = true
+	}
+	if len(jobNames) != len(items) {
+		t.Error("expected unique job names for items: Got ", jobNames)
+	}
+}
+
+func TestVocabularyWithoutVocabularyManager(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	client := CreateGoS2TClient(&CredentialsHolder{}, "").
+		WithProviderInstance(fakeProviderName, s2ttest.BasicProvider{S2TProvider: fake})
+
+	if _, err := client.ListVocabularies(context.Background(), fakeProviderName); err == nil || !strings.Contains(err.Error(), "vocabularies") {
+		t.Error("expected error for provider without vocabularies: Got ", err)
+	}
+}
+
+func TestSyncVocabulary(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "hello world")
+	client, _ := createTestClient(fake)
+	options := getTestOptions()
+	options.TranscriptionJobCheckIntervalMs = 1
+	vocabulary := Vocabulary{Id: "shop", Phrases: []VocabularyPhrase{{Phrase: "Widget Pro"}}}
+
+	synced, err := client.SyncVocabulary(context.Background(), fakeProviderName, vocabulary, options)
+	if err != nil || synced.State != VocabularyStateReady {
+		t.Fatal("wrong vocabulary: Got ", synced, err)
+	}
+	if calls := fake.CallsTo("CreateVocabulary"); len(calls) != 1 {
+		t.Error("expected vocabulary to be created: Got ", fake.Calls())
+	}
+
+	vocabulary.Phrases = []VocabularyPhrase{{Phrase: "Gadget"}}
+	synced, err = client.SyncVocabulary(context.Background(), fakeProviderName, vocabulary, options)
+	if err != nil || synced.State != VocabularyStateReady || synced.Phrases[0].Phrase != "Gadget" {
+		t.Fatal("wrong vocabulary: Got ", synced, err)
+	}
+	if calls := fake.CallsTo("UpdateVocabulary"); len(calls) != 1 {
+		t.Error("expected vocabulary to be updated: Got ", fake.Calls())
+	}
+
+	if err = client.DeleteVocabulary(context.Background(), fakeProviderName, "shop"); err != nil {
+		t.Error("unexpected error: ", err)
+	}
+	if _, err = client.GetVocabulary(context.Background(), fakeProviderName, "shop"); !errors.Is(err, ErrVocabularyNotFound) {
+		t.Error("expected ErrVocabularyNotFound: Got ", err)
+	}
+}
+
+func TestS2TDirectLocalProfanityFilter(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "well damn that works")
+	client, _ := createTestClient(fake)
+	options := getTestOptions()
+	options.ProfanityFilter = true
+
+	result := <-client.S2TDirect(createTestAudioFile(t), options)
+	if result.Result.Err != nil || result.Result.Text != "well d*** that works" {
+		t.Fatal("wrong result: Got ", result.Result)
+	}
+	if result.Result.Transcript.Segments[0].Text != "well d*** that works" {
+		t.Error("wrong transcript: Got ", result.Result.Transcript)
+	}
+	if calls := fake.CallsTo("ExecuteS2TDirect"); len(calls) != 1 || calls[0].Options.ProfanityFilter {
+		t.Error("expected provider without profanity filter capability to get unfiltered options: Got ", calls)
+	}
+
+	options.ProfanityFilterConfig = ProfanityFilterConfig{Method: ProfanityFilterMethodRemove, Words: []string{"works"}}
+	result = <-client.S2TDirect(createTestAudioFile(t), options)
+	if result.Result.Err != nil || result.Result.Text != "well damn that" {
+		t.Error("wrong result: Got ", result.Result)
+	}
+}
+
+func TestFilterStreamProfanityStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := make(chan PartialResult, 1)
+	results <- PartialResult{Text: "well damn"}
+
+	filtered := filterStreamProfanity(ctx, results, &ProfanityFilterConfig{})
+	time.Sleep(50 * time.Millisecond)
+	if result, ok := <-filtered; ok {
+		t.Error("expected filtering to stop after cancellation: Got ", result)
+	}
+}
+
+func TestGetS2TResultLocalProfanityFilter(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "well damn that works")
+	client, _ := createTestClient(fake)
+	options := getTestOptions()
+	options.ProfanityFilter = true
+
+	job, err := client.StartS2T(context.Background(), createTestAudioFile(t), "", options)
+	if err != nil || job.ProfanityFilter == nil {
+		t.Fatal("expected local profanity filter in job: Got ", job, err)
+	}
+	serialized, _ := job.ToJSON()
+	job, _ = ParseTranscriptionJob(serialized)
+	job, err = client.WaitS2T(context.Background(), job, options)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if result := client.GetS2TResult(context.Background(), job); result.Text != "well d*** that works" {
+		t.Error("wrong result: Got ", result)
+	}
+}
+
+func TestS2TDirectLocalRedaction(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "my pin is 4821")
+	client, _ := createTestClient(fake)
+	options := getTestOptions()
+	entityType := RedactionEntityPin
+	options.ContentRedactionConfig = ContentRedactionConfig{
+		RedactionEntityTypes: []*RedactionEntityType{&entityType},
+		RedactionOutput:      RedactionOutputRedactedAndUnredacted,
+	}
+
+	result := <-client.S2TDirect(createTestAudioFile(t), options)
+	if result.Result.Err != nil || result.Result.Text != "my pin is [PII]" {
+		t.Fatal("wrong result: Got ", result.Result)
+	}
+	if len(result.Result.Transcript.Entities) != 1 || result.Result.Transcript.Entities[0].Type != RedactionEntityPin {
+		t.Error("wrong entities: Got ", result.Result.Transcript.Entities)
+	}
+	if result.Result.UnredactedTranscript == nil || result.Result.UnredactedTranscript.Text != "my pin is 4821" {
+		t.Error("wrong unredacted transcript: Got ", result.Result.UnredactedTranscript)
+	}
+	if calls := fake.CallsTo("ExecuteS2TDirect"); len(calls) != 1 || !calls[0].Options.ContentRedactionConfig.IsEmpty() {
+		t.Error("expected provider without content redaction capability to get options without redaction: Got ", calls)
+	}
+}
+
+func TestRedactStreamStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := make(chan PartialResult, 1)
+	results <- PartialResult{Text: "my pin is 4821"}
+
+	redacted := redactStream(ctx, results, &ContentRedactionConfig{})
+	time.Sleep(50 * time.Millisecond)
+	if result, ok := <-redacted; ok {
+		t.Error("expected redaction to stop after cancellation: Got ", result)
+	}
+}
+
+func TestS2TLocalRedactionUnredactedDestination(t *testing.T) {
+	fake := s2ttest.NewFakeProvider(fakeProviderName, "my pin is 4821")
+	client, storage := createTestClient(fake)
+	options := getTestOptions()
+	entityType := RedactionEntityPin
+	options.ContentRedactionConfig = ContentRedactionConfig{
+		RedactionEntityTypes: []*RedactionEntityType{&entityType},
+		RedactionOutput:      RedactionOutputRedactedAndUnredacted,
+	}
+
+	_, err := client.S2T(createTestAudioFile(t), "s3://result-bucket/transcript.json", options)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	content, exists := storage.File("result-bucket", "transcript.json")
+	if !exists || !strings.Contains(string(content), "my pin is [PII]") || !strings.Contains(string(content), `"type": "PIN"`) {
+		t.Error("wrong redacted result file: Got ", string(content))
+	}
+	content, exists = storage.File("result-bucket", "transcript.unredacted.json")
+	if !exists || !strings.Contains(string(content), "my pin is 4821") || !strings.Contains(string(content), `"type": "PIN"`) {
+		t.Error("wrong unredacted result file: Got ", string(content))
+	}
+}
+
+func TestS2TTextDestinationOnAws(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("hello world")
+	defer emulator.Close()
+	storage := s2ttest.NewFakeStorage()
+	client := CreateGoS2TClient(&CredentialsHolder{
+		AwsCredentials: &awsSdk.Credentials{AccessKeyID: "test", SecretAccessKey: "test"},
+	}, "us-east-1").
+		WithStorage(storage).
+		WithProviderInstance(providers.ProviderAWS, s2tAws.S2TAmazonWebServices{Endpoint: emulator.URL})
+	options := GetDefaultSpeechToTextOptions()
+	options.Provider = providers.ProviderAWS
+	options.LanguageConfig.LanguageCode = "en-US"
+	options.TranscriptionJobCheckIntervalMs = 1
+
+	// no TempBucket -> the plain text can't be formatted
+	if _, err := client.S2T("s3://audio-bucket/testfile.mp3", "s3://result-bucket/testfile.txt", *options); err == nil || !strings.Contains(err.Error(), "TempBucket") {
+		t.Error("expected error about missing TempBucket: Got ", err)
+	}
+
+	options.TempBucket = "temp-bucket"
+	if _, err := client.S2T("s3://audio-bucket/testfile.mp3", "s3://result-bucket/testfile.txt", *options); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	starts := emulator.StartRequests()
+	if len(starts) != 1 || starts[0].OutputBucketName != "temp-bucket" {
+		t.Fatal("wrong start requests: Got ", starts)
+	}
+	if content, exists := storage.File("result-bucket", "testfile.txt"); !exists || string(content) != "hello world" {
+		t.Error("wrong result file: Got ", string(content))
+	}
+
+	// no file extension -> the native result file is written without waiting for the job
+	if _, err := client.S2T("s3://audio-bucket/testfile.mp3", "s3://result-bucket/testfile", *options); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	starts = emulator.StartRequests()
+	if len(starts) != 2 || starts[1].OutputBucketName != "result-bucket" || starts[1].OutputKey != "testfile" {
+		t.Fatal("wrong start requests: Got ", starts)
+	}
+	if checks := emulator.StatusChecks(starts[1].TranscriptionJobName); checks != 0 {
+		t.Error("expected S2T not to wait for the job: Got ", checks)
+	}
+}
+
+func TestS2TAwsRedactedAndUnredactedWithoutOutputFormat(t *testing.T) {
+	emulator := s2ttest.NewTranscribeEmulator("my pin is 4821")
+	defer emulator.Close()
+	emulator.RedactedWords = map[string]string{"4821": "PIN"}
+	storage := s2ttest.NewFakeStorage()
+	client := CreateGoS2TClient(&CredentialsHolder{
+		AwsCredentials: &awsSdk.Credentials{AccessKeyID: "test", SecretAccessKey: "test"},
+	}, "us-east-1").
+		WithStorage(storage).
+		WithProviderInstance(providers.ProviderAWS, s2tAws.S2TAmazonWebServices{Endpoint: emulator.URL})
+	options := GetDefaultSpeechToTextOptions()
+	options.Provider = providers.ProviderAWS
+	options.LanguageConfig.LanguageCode = "en-US"
+	options.TranscriptionJobCheckIntervalMs = 1
+	options.TempBucket = "temp-bucket"
+	entityType := RedactionEntityPin
+	options.ContentRedactionConfig = ContentRedactionConfig{
+		ContentRedactionType: RedactionTypePersonallyIdentifiableInformation,
+		RedactionEntityTypes: []*RedactionEntityType{&entityType},
+		RedactionOutput:      RedactionOutputRedactedAndUnredacted,
+	}
+
+	if _, err := client.S2T("s3://audio-bucket/testfile.mp3", "s3://result-bucket/testfile", *options); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	// both transcripts are stored as JSON, so that both list the redacted entities
+	for _, key := range []string{"testfile", "testfile" + UnredactedFileSuffix} {
+		content, exists := storage.File("result-bucket", key)
+		var transcript Transcript
+		if !exists || json.Unmarshal(content, &transcript) != nil || len(transcript.Entities) != 1 {
+			t.Error("wrong result file '", key, "': Got ", string(content))
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package GoText2Speech
+
+import (
+	"context"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+)
+
+// getLocalProfanityFilter returns the profanity filter config that must be applied locally to the results, because
+// the provider of the given options can't filter profanities natively with the configured method (or because local
+// filtering is enforced, see ProfanityFilterConfig.LocalOnly).
+// If profanities aren't filtered or are filtered natively by the provider, nil is returned.
+func getLocalProfanityFilter(options SpeechToTextOptions) *ProfanityFilterConfig {
+	if !options.ProfanityFilter {
+		return nil
+	}
+	config := options.ProfanityFilterConfig
+	registration, _ := providers.GetRegistration(options.Provider)
+	if config.LocalOnly || !registration.SupportsCapability(config.GetMethod().GetCapability()) {
+		return &config
+	}
+	return nil
+}
+
+// filterResultProfanity applies the given local profanity filter to the text and transcripts of the given result.
+// If the filter is nil, the result is returned unchanged.
+func filterResultProfanity(result S2TDirectResult, config *ProfanityFilterConfig) S2TDirectResult {
+	if config == nil || result.Err != nil {
+		return result
+	}
+	result.Text = FilterProfanity(result.Text, *config)
+	if result.Transcript != nil {
+		transcript := FilterTranscriptProfanity(*result.Transcript, *config)
+		result.Transcript = &transcript
+	}
+	if result.UnredactedTranscript != nil {
+		unredacted := FilterTranscriptProfanity(*result.UnredactedTranscript, *config)
+		result.UnredactedTranscript = &unredacted
+	}
+	return result
+}
+
+// filterStreamProfanity applies the given local profanity filter to the text and words of every result of the given
+// channel. If the filter is nil, the channel is returned unchanged. Filtering stops as soon as the context is done.
+func filterStreamProfanity(ctx context.Context, results <-chan PartialResult, config *ProfanityFilterConfig) <-chan PartialResult {
+	if config == nil {
+		return results
+	}
+	r := make(chan PartialResult)
+	go func() {
+		defer close(r)
+		for result := range results {
+			result.Text = FilterProfanity(result.Text, *config)
+			if result.Words != nil {
+				result.Words = FilterTranscriptProfanity(Transcript{Segments: []TranscriptSegment{{Words: result.Words}}}, *config).Segments[0].Words
+			}
+			if !SendPartialResult(ctx, r, result) {
+				return
+			}
+		}
+	}()
+	return r
+}
@@ -8,8 +8,14 @@ const (
 	ProviderUnspecified Provider = ""
 )
 
-var allProviders = []Provider{ProviderAWS, ProviderGCP}
-
+// GetAllProviders returns all registered providers in the order of their registration.
+// See Register.
 func GetAllProviders() []Provider {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	allProviders := make([]Provider, len(registrations))
+	for i, registration := range registrations {
+		allProviders[i] = registration.Name
+	}
 	return allProviders
 }
@@ -0,0 +1,116 @@
+package providers
+
+import (
+	"github.com/FaaSTools/GoStorage/gostorage"
+	"sync"
+)
+
+// Capability is a feature that is only supported by some providers.
+// It is used to choose a provider if none is specified in the options.
+type Capability string
+
+const (
+	// CapabilityLanguageIdentification means that the language of the speech can be identified automatically.
+	CapabilityLanguageIdentification Capability = "language_identification"
+	// CapabilityContentRedaction means that personally identifiable information can be redacted.
+	CapabilityContentRedaction Capability = "content_redaction"
+	// CapabilityAutomaticPunctuation means that punctuation can be added automatically.
+	CapabilityAutomaticPunctuation Capability = "automatic_punctuation"
+	// CapabilitySpokenPunctuation means that spoken punctuation can be replaced by punctuation symbols.
+	CapabilitySpokenPunctuation Capability = "spoken_punctuation"
+	// CapabilitySpokenEmojis means that spoken emojis can be replaced by emoji symbols.
+	CapabilitySpokenEmojis Capability = "spoken_emojis"
+	// CapabilityProfanityFilter means that profanities can be masked.
+	CapabilityProfanityFilter Capability = "profanity_filter"
+	// CapabilityProfanityFilterRemove means that profanities can be removed.
+	CapabilityProfanityFilterRemove Capability = "profanity_filter_remove"
+	// CapabilityProfanityFilterTag means that profanities can be tagged.
+	CapabilityProfanityFilterTag Capability = "profanity_filter_tag"
+	// CapabilitySpeakerDiarization means that speakers can be labeled.
+	CapabilitySpeakerDiarization Capability = "speaker_diarization"
+	// CapabilityRawAudio means that headerless audio (e.g. LINEAR16 or MULAW) can be transcribed.
+	CapabilityRawAudio Capability = "raw_audio"
+	// CapabilityStreaming means that audio streams can be transcribed in real time (see StreamingS2TProvider of the
+	// shared package).
+	CapabilityStreaming Capability = "streaming"
+)
+
+// Factory creates a new provider instance. The returned value must implement the S2TProvider interface of the
+// shared package (which can't be referenced here, because the shared package depends on this package).
+type Factory func() interface{}
+
+// Registration describes a registered provider.
+type Registration struct {
+	// Name is the name that is used to select the provider (see SpeechToTextOptions.Provider).
+	Name Provider
+	// Factory creates new instances of the provider.
+	Factory Factory
+	// Capabilities lists the optional features that the provider supports.
+	Capabilities []Capability
+	// StorageProvider is the GoStorage provider of the storage service that the provider uses
+	// (e.g. to upload local source files).
+	StorageProvider gostorage.ProviderType
+}
+
+// SupportsCapability checks if the given capability is listed in the capabilities of the registration.
+func (r Registration) SupportsCapability(capability Capability) bool {
+	for _, c := range r.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	registryMutex sync.RWMutex
+	registrations []Registration
+)
+
+// Register adds a provider to the registry, so that it can be used by GoS2TClient.
+// The AWS and GCP providers register themselves when their packages are imported.
+// Custom providers should be registered in an init function.
+// If a provider with the same name has already been registered, it is replaced.
+func Register(name Provider, factory Factory, capabilities []Capability, storageProvider gostorage.ProviderType) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registration := Registration{
+		Name:            name,
+		Factory:         factory,
+		Capabilities:    capabilities,
+		StorageProvider: storageProvider,
+	}
+	for i, existing := range registrations {
+		if existing.Name == name {
+			registrations[i] = registration
+			return
+		}
+	}
+	registrations = append(registrations, registration)
+}
+
+// Unregister removes the given provider from the registry (e.g. to clean up after tests).
+// Nothing happens if the provider hasn't been registered.
+func Unregister(name Provider) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	for i, existing := range registrations {
+		if existing.Name == name {
+			registrations = append(registrations[:i], registrations[i+1:]...)
+			return
+		}
+	}
+}
+
+// GetRegistration returns the registration of the given provider.
+// The second return value is false if the provider hasn't been registered.
+func GetRegistration(name Provider) (Registration, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	for _, registration := range registrations {
+		if registration.Name == name {
+			return registration, true
+		}
+	}
+	return Registration{}, false
+}
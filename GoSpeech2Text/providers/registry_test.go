@@ -0,0 +1,39 @@
+package providers
+
+import "testing"
+
+func TestRegister(t *testing.T) {
+	Register("TEST_REGISTER", func() interface{} { return "first" }, []Capability{CapabilityProfanityFilter}, "")
+	Register("TEST_REGISTER", func() interface{} { return "second" }, nil, "")
+
+	count := 0
+	for _, provider := range GetAllProviders() {
+		if provider == "TEST_REGISTER" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Error("expected provider to be registered once: Got ", count)
+	}
+
+	registration, exists := GetRegistration("TEST_REGISTER")
+	if !exists || registration.Factory() != "second" {
+		t.Error("expected registration to be replaced: Got ", registration)
+	}
+	if registration.SupportsCapability(CapabilityProfanityFilter) {
+		t.Error("expected capabilities to be replaced")
+	}
+	if _, exists = GetRegistration("UNKNOWN"); exists {
+		t.Error("expected unknown provider not to be registered")
+	}
+
+	Unregister("TEST_REGISTER")
+	if _, exists = GetRegistration("TEST_REGISTER"); exists {
+		t.Error("expected provider to be unregistered")
+	}
+	for _, provider := range GetAllProviders() {
+		if provider == "TEST_REGISTER" {
+			t.Error("expected unregistered provider not to be listed")
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package GoText2Speech
+
+import (
+	"context"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+)
+
+// getLocalRedaction returns the content redaction config that must be applied locally to the results, because the
+// provider of the given options can't redact content natively.
+// If content isn't redacted or is redacted natively by the provider, nil is returned.
+func getLocalRedaction(options SpeechToTextOptions) *ContentRedactionConfig {
+	if options.ContentRedactionConfig.IsEmpty() {
+		return nil
+	}
+	registration, _ := providers.GetRegistration(options.Provider)
+	if registration.SupportsCapability(providers.CapabilityContentRedaction) {
+		return nil
+	}
+	config := options.ContentRedactionConfig
+	return &config
+}
+
+// redactResult redacts the text and transcript of the given result with the given local content redaction config
+// (see RedactTranscript). If the config is nil, the result is returned unchanged.
+func redactResult(result S2TDirectResult, config *ContentRedactionConfig) S2TDirectResult {
+	if config == nil || result.Err != nil {
+		return result
+	}
+	transcript := Transcript{Text: result.Text}
+	if result.Transcript != nil {
+		transcript = *result.Transcript
+	}
+	redacted, unredacted := RedactTranscript(transcript, *config)
+	result.Text = redacted.Text
+	if result.Transcript != nil {
+		result.Transcript = &redacted
+	}
+	result.UnredactedTranscript = unredacted
+	return result
+}
+
+// redactStream redacts the text and words of every result of the given channel with the given local content
+// redaction config. Since every result is redacted on its own, entities that span multiple results aren't detected.
+// If the config is nil, the channel is returned unchanged. Redaction stops as soon as the context is done.
+func redactStream(ctx context.Context, results <-chan PartialResult, config *ContentRedactionConfig) <-chan PartialResult {
+	if config == nil {
+		return results
+	}
+	r := make(chan PartialResult)
+	go func() {
+		defer close(r)
+		for result := range results {
+			redacted, _ := RedactTranscript(Transcript{
+				Text:     result.Text,
+				Segments: []TranscriptSegment{{Text: result.Text, Words: result.Words}},
+			}, *config)
+			result.Text = redacted.Text
+			result.Words = redacted.Segments[0].Words
+			if !SendPartialResult(ctx, r, result) {
+				return
+			}
+		}
+	}()
+	return r
+}
+
+// writeUnredactedTranscript stores the given unredacted transcript in the given format at the unredacted destination
+// for the given destination of the redacted transcript (see GetUnredactedDestination).
+func (a GoS2TClient) writeUnredactedTranscript(ctx context.Context, transcript Transcript, format OutputFormat, destination string) error {
+	content, err := FormatTranscript(transcript, format)
+	if err != nil {
+		return err
+	}
+	return a.writeToDestination(ctx, content, GetUnredactedDestination(destination))
+}
@@ -0,0 +1,454 @@
+// Package s2ttest provides an in-memory fake S2TProvider and a fake Storage, which can be injected into a GoS2TClient
+// (see GoS2TClient.WithProviderInstance and GoS2TClient.WithStorage) to test code that uses GoSpeech2Text without
+// network access.
+package s2ttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FaaSTools/GoStorage/gostorage"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	_ S2TProvider              = (*FakeProvider)(nil)
+	_ TranscriptionJobProvider = (*FakeProvider)(nil)
+	_ StreamingS2TProvider     = (*FakeProvider)(nil)
+	_ VocabularyManager        = (*FakeProvider)(nil)
+	_ StorageAudioHeaderReader = (*FakeProvider)(nil)
+)
+
+// DefaultFakeRegion is the default region of a FakeProvider.
+const DefaultFakeRegion = "fake-region-1"
+
+// Call is a recorded call of a FakeProvider method.
+type Call struct {
+	// Method is the name of the called method (e.g. "ExecuteS2TDirect").
+	Method      string
+	Source      string
+	Destination string
+	// Region is the region of the service client at the time of the call.
+	Region  string
+	Options SpeechToTextOptions
+}
+
+// FakeProvider is a scriptable, in-memory S2TProvider. Every transcription returns Transcript (or the result of
+// TranscribeFunc), and all calls are recorded (see Calls).
+// FakeProvider must be used as a pointer, so that all copies of the provider instance share the same state.
+type FakeProvider struct {
+	// Name is the name of the provider, which is set in the returned transcription jobs.
+	Name providers.Provider
+	// StorageUrlPrefix is the URL prefix of files on the provider's own storage service (e.g. "s3://").
+	// If empty, no URL is considered to be on the provider's own storage.
+	StorageUrlPrefix string
+	// StorageFiles contains the content of files on the provider's own storage by URL, which is returned by
+	// ReadStorageAudioHeader.
+	StorageFiles map[string][]byte
+	// StorageProvider is the GoStorage provider that is used when the provider is registered (see Register).
+	StorageProvider gostorage.ProviderType
+	// DefaultRegion is returned by GetDefaultRegion.
+	DefaultRegion string
+	// SupportedFileTypes are the file types (without preceding period) for which SupportsFileType returns true.
+	// If nil, all file types are supported.
+	SupportedFileTypes []string
+	// DirectFileInput is returned by SupportsDirectFileInput.
+	DirectFileInput bool
+	// Transcript is the result of every transcription, unless TranscribeFunc is set.
+	Transcript Transcript
+	// TranscribeFunc is called for every transcription (if it is not nil). It can be used to return errors or
+	// different results per source.
+	TranscribeFunc func(ctx context.Context, source string, options SpeechToTextOptions) (Transcript, error)
+	// JobStatuses are returned by successive GetS2TStatus calls. Once all statuses have been returned,
+	// JobStatusCompleted is returned.
+	JobStatuses []JobStatus
+	// CreateServiceClientErr is returned by CreateServiceClient (if it is not nil).
+	CreateServiceClientErr error
+
+	mutex         sync.Mutex
+	region        string
+	calls         []Call
+	outputs       map[string]string
+	jobCounter    int
+	statusIndex   int
+	streamedAudio []byte
+	vocabularies  map[string]Vocabulary
+}
+
+// NewFakeProvider creates a fake provider with the given name that supports all file types and returns
+// the given text for every transcription.
+func NewFakeProvider(name providers.Provider, text string) *FakeProvider {
+	return &FakeProvider{
+		Name:            name,
+		StorageProvider: gostorage.ProviderAWS,
+		DefaultRegion:   DefaultFakeRegion,
+		Transcript: Transcript{
+			Text:     text,
+			Segments: []TranscriptSegment{{Text: text, EndTime: time.Second}},
+		},
+	}
+}
+
+// Register registers the fake provider with the given capabilities (see providers.Register).
+// The registered factory always returns this instance.
+func (f *FakeProvider) Register(capabilities ...providers.Capability) {
+	providers.Register(f.Name, func() interface{} { return f }, capabilities, f.StorageProvider)
+}
+
+// BasicProvider wraps a provider and only exposes the methods of S2TProvider, i.e. none of the optional interfaces
+// (like StreamingS2TProvider). It can be used to test how code handles providers without optional features.
+type BasicProvider struct {
+	S2TProvider
+}
+
+// CreateServiceClient creates the service client of the wrapped provider and wraps the result again.
+func (b BasicProvider) CreateServiceClient(ctx context.Context, credentials CredentialsHolder, region string) (S2TProvider, error) {
+	instance, err := b.S2TProvider.CreateServiceClient(ctx, credentials, region)
+	return BasicProvider{S2TProvider: instance}, err
+}
+
+// Calls returns all recorded calls in chronological order.
+func (f *FakeProvider) Calls() []Call {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo returns the recorded calls of the given method in chronological order.
+func (f *FakeProvider) CallsTo(method string) []Call {
+	var calls []Call
+	for _, call := range f.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Output returns the text that has been written to the given destination by ExecuteS2T or GetS2TResult.
+func (f *FakeProvider) Output(destination string) (string, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	text, exists := f.outputs[destination]
+	return text, exists
+}
+
+func (f *FakeProvider) record(method string, source string, destination string, options SpeechToTextOptions) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.calls = append(f.calls, Call{
+		Method:      method,
+		Source:      source,
+		Destination: destination,
+		Region:      f.region,
+		Options:     options,
+	})
+}
+
+func (f *FakeProvider) transcribe(ctx context.Context, source string, options SpeechToTextOptions) (Transcript, error) {
+	if ctx.Err() != nil {
+		return Transcript{}, ctx.Err()
+	}
+	if f.TranscribeFunc != nil {
+		return f.TranscribeFunc(ctx, source, options)
+	}
+	return f.Transcript, nil
+}
+
+func (f *FakeProvider) storeOutput(destination string, text string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.outputs == nil {
+		f.outputs = make(map[string]string)
+	}
+	f.outputs[destination] = text
+}
+
+func (f *FakeProvider) TransformOptions(ctx context.Context, sourceUrl string, options SpeechToTextOptions) (string, SpeechToTextOptions, error) {
+	return sourceUrl, options, nil
+}
+
+func (f *FakeProvider) CreateServiceClient(ctx context.Context, credentials CredentialsHolder, region string) (S2TProvider, error) {
+	if f.CreateServiceClientErr != nil {
+		return f, f.CreateServiceClientErr
+	}
+	f.mutex.Lock()
+	f.region = region
+	f.mutex.Unlock()
+	f.record("CreateServiceClient", "", "", SpeechToTextOptions{})
+	return f, nil
+}
+
+func (f *FakeProvider) ExecuteS2TDirect(ctx context.Context, sourceUrl string, options SpeechToTextOptions) <-chan S2TDirectResult {
+	f.record("ExecuteS2TDirect", sourceUrl, "", options)
+	r := make(chan S2TDirectResult, 1)
+	transcript, err := f.transcribe(ctx, sourceUrl, options)
+	if err != nil {
+		r <- S2TDirectResult{Err: err}
+	} else {
+		r <- S2TDirectResult{Text: transcript.Text, Transcript: &transcript}
+	}
+	close(r)
+	return r
+}
+
+func (f *FakeProvider) ExecuteS2T(ctx context.Context, source string, destination string, options SpeechToTextOptions) error {
+	f.record("ExecuteS2T", source, destination, options)
+	transcript, err := f.transcribe(ctx, source, options)
+	if err != nil {
+		return err
+	}
+	f.storeOutput(destination, transcript.Text)
+	return nil
+}
+
+func (f *FakeProvider) StartS2T(ctx context.Context, sourceUrl string, destination string, options SpeechToTextOptions) (TranscriptionJob, error) {
+	f.record("StartS2T", sourceUrl, destination, options)
+	if ctx.Err() != nil {
+		return TranscriptionJob{}, ctx.Err()
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.jobCounter++
+	return TranscriptionJob{
+		Provider:    f.Name,
+		Name:        fmt.Sprintf("fake-job-%d", f.jobCounter),
+		Region:      f.region,
+		Source:      sourceUrl,
+		Destination: destination,
+		Status:      JobStatusQueued,
+		StartTime:   time.Now(),
+	}, nil
+}
+
+func (f *FakeProvider) GetS2TStatus(ctx context.Context, job TranscriptionJob) (TranscriptionJob, error) {
+	f.record("GetS2TStatus", job.Source, job.Destination, SpeechToTextOptions{})
+	if ctx.Err() != nil {
+		return job, ctx.Err()
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	job.Status = JobStatusCompleted
+	if f.statusIndex < len(f.JobStatuses) {
+		job.Status = f.JobStatuses[f.statusIndex]
+		f.statusIndex++
+	}
+	if job.Status == JobStatusFailed {
+		job.FailureReason = "fake failure"
+	}
+	return job, nil
+}
+
+func (f *FakeProvider) GetS2TResult(ctx context.Context, job TranscriptionJob) S2TDirectResult {
+	f.record("GetS2TResult", job.Source, job.Destination, SpeechToTextOptions{})
+	if job.Status != JobStatusCompleted {
+		return S2TDirectResult{Err: errors.New(fmt.Sprintf("The transcription job '%s' hasn't completed.", job.Name))}
+	}
+	transcript, err := f.transcribe(ctx, job.Source, SpeechToTextOptions{})
+	if err != nil {
+		return S2TDirectResult{Err: err}
+	}
+	if !strings.EqualFold(job.Destination, "") {
+		f.storeOutput(job.Destination, transcript.Text)
+	}
+	return S2TDirectResult{Text: transcript.Text, Transcript: &transcript}
+}
+
+// StreamS2T reads the whole audio stream and then returns an interim and a final result for every segment of the
+// transcript.
+func (f *FakeProvider) StreamS2T(ctx context.Context, audio io.Reader, options SpeechToTextOptions) (<-chan PartialResult, error) {
+	f.record("StreamS2T", "", "", options)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r := make(chan PartialResult)
+	go func() {
+		defer close(r)
+		errAudio := ReadAudioStream(ctx, audio, func(chunk []byte) error {
+			f.mutex.Lock()
+			defer f.mutex.Unlock()
+			f.streamedAudio = append(f.streamedAudio, chunk...)
+			return nil
+		})
+		if errAudio != nil {
+			SendPartialResult(ctx, r, PartialResult{Err: errAudio})
+			return
+		}
+		transcript, err := f.transcribe(ctx, "", options)
+		if err != nil {
+			SendPartialResult(ctx, r, PartialResult{Err: err})
+			return
+		}
+		for i, segment := range transcript.Segments {
+			result := PartialResult{
+				ResultId:     strconv.Itoa(i),
+				Text:         segment.Text,
+				Stability:    0.5,
+				StartTime:    segment.StartTime,
+				EndTime:      segment.EndTime,
+				LanguageCode: segment.LanguageCode,
+				Words:        segment.Words,
+			}
+			if !SendPartialResult(ctx, r, result) {
+				return
+			}
+			result.IsFinal = true
+			result.Stability = 1.0
+			if !SendPartialResult(ctx, r, result) {
+				return
+			}
+		}
+	}()
+	return r, nil
+}
+
+// StreamedAudio returns all audio that has been read by StreamS2T.
+func (f *FakeProvider) StreamedAudio() []byte {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]byte(nil), f.streamedAudio...)
+}
+
+// CreateVocabulary stores the given vocabulary in memory. The vocabulary is pending until it is returned by
+// GetVocabulary for the first time.
+func (f *FakeProvider) CreateVocabulary(ctx context.Context, vocabulary Vocabulary) (Vocabulary, error) {
+	f.record("CreateVocabulary", vocabulary.Id, "", SpeechToTextOptions{})
+	if ctx.Err() != nil {
+		return vocabulary, ctx.Err()
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if _, exists := f.vocabularies[vocabulary.Id]; exists {
+		return vocabulary, errors.New(fmt.Sprintf("The vocabulary '%s' already exists.", vocabulary.Id))
+	}
+	if f.vocabularies == nil {
+		f.vocabularies = make(map[string]Vocabulary)
+	}
+	return f.storeVocabulary(vocabulary), nil
+}
+
+func (f *FakeProvider) UpdateVocabulary(ctx context.Context, vocabulary Vocabulary) (Vocabulary, error) {
+	f.record("UpdateVocabulary", vocabulary.Id, "", SpeechToTextOptions{})
+	if ctx.Err() != nil {
+		return vocabulary, ctx.Err()
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if _, exists := f.vocabularies[vocabulary.Id]; !exists {
+		return vocabulary, ErrVocabularyNotFound
+	}
+	return f.storeVocabulary(vocabulary), nil
+}
+
+// storeVocabulary stores the given vocabulary as pending vocabulary. The mutex must be held.
+func (f *FakeProvider) storeVocabulary(vocabulary Vocabulary) Vocabulary {
+	vocabulary.Provider = f.Name
+	vocabulary.Name = vocabulary.Id
+	vocabulary.State = VocabularyStatePending
+	vocabulary.LastModifiedTime = time.Now()
+	f.vocabularies[vocabulary.Id] = vocabulary
+	return vocabulary
+}
+
+func (f *FakeProvider) GetVocabulary(ctx context.Context, id string) (Vocabulary, error) {
+	f.record("GetVocabulary", id, "", SpeechToTextOptions{})
+	if ctx.Err() != nil {
+		return Vocabulary{}, ctx.Err()
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	vocabulary, exists := f.vocabularies[id]
+	if !exists {
+		return Vocabulary{}, ErrVocabularyNotFound
+	}
+	vocabulary.State = VocabularyStateReady
+	f.vocabularies[id] = vocabulary
+	return vocabulary, nil
+}
+
+func (f *FakeProvider) ListVocabularies(ctx context.Context) ([]Vocabulary, error) {
+	f.record("ListVocabularies", "", "", SpeechToTextOptions{})
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	var vocabularies []Vocabulary
+	for _, vocabulary := range f.vocabularies {
+		vocabularies = append(vocabularies, vocabulary)
+	}
+	return vocabularies, nil
+}
+
+func (f *FakeProvider) DeleteVocabulary(ctx context.Context, id string) error {
+	f.record("DeleteVocabulary", id, "", SpeechToTextOptions{})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if _, exists := f.vocabularies[id]; !exists {
+		return ErrVocabularyNotFound
+	}
+	delete(f.vocabularies, id)
+	return nil
+}
+
+func (f *FakeProvider) IsURLonOwnStorage(url string) bool {
+	return !strings.EqualFold(f.StorageUrlPrefix, "") && strings.HasPrefix(url, f.StorageUrlPrefix)
+}
+
+func (f *FakeProvider) ReadStorageAudioHeader(ctx context.Context, url string) ([]byte, error) {
+	f.record("ReadStorageAudioHeader", url, "", SpeechToTextOptions{})
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	content, exists := f.StorageFiles[url]
+	if !exists {
+		return nil, errors.New(fmt.Sprintf("fake provider couldn't find the file '%s'", url))
+	}
+	if len(content) > AudioHeaderSize {
+		content = content[:AudioHeaderSize]
+	}
+	return content, nil
+}
+
+func (f *FakeProvider) CloseServiceClient() error {
+	f.record("CloseServiceClient", "", "", SpeechToTextOptions{})
+	return nil
+}
+
+func (f *FakeProvider) SupportsFileType(fileType string) bool {
+	if f.SupportedFileTypes == nil {
+		return true
+	}
+	for _, supported := range f.SupportedFileTypes {
+		if strings.EqualFold(supported, fileType) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *FakeProvider) SupportsDirectFileInput() bool {
+	return f.DirectFileInput
+}
+
+func (f *FakeProvider) GetDefaultRegion() string {
+	return f.DefaultRegion
+}
+
+func (f *FakeProvider) GetStorageUrl(region string, bucket string, key string) string {
+	prefix := f.StorageUrlPrefix
+	if strings.EqualFold(prefix, "") {
+		prefix = "fake://"
+	}
+	return fmt.Sprintf("%s%s/%s", prefix, bucket, key)
+}
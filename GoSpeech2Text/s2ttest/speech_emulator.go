@@ -0,0 +1,521 @@
+package s2ttest
+
+import (
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
+	speechpb "cloud.google.com/go/speech/apiv2/speechpb"
+	"context"
+	"encoding/json"
+	"fmt"
+	"google.golang.org/api/option"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+// emulatedOperation is the state of a long-running BatchRecognize operation of a SpeechEmulator.
+type emulatedOperation struct {
+	request *speechpb.BatchRecognizeRequest
+	polls   int
+	result  *longrunningpb.Operation
+}
+
+// SpeechEmulator is an in-process stand-in for the GCP Speech-to-Text v2 API (gRPC) and the Google Cloud Storage API
+// (HTTP), which can be used to test the GCP provider without network access. Pass ClientOptions and
+// StorageClientOptions to the GCP provider.
+//
+// Requests must reference a recognizer ("projects/{project}/locations/{location}/recognizers/{recognizer}"), which
+// is either the implicit recognizer "_" or has been created with CreateRecognizer (or AddRecognizer).
+// Recognize returns Results. BatchRecognize starts a long-running operation, which is done after OperationPolls
+// status checks and returns Results for every file, either inline or as result file on the emulated storage
+// (if the request contains a Google Cloud Storage output config).
+// StreamingRecognize collects the streamed audio and returns StreamingResponses after the client has finished sending.
+// PhraseSets and CustomClasses can be created, read, listed, updated and deleted. Their operations are done
+// immediately.
+// The emulated storage supports simple uploads, downloads and deletes of objects.
+type SpeechEmulator struct {
+	// Address is the address of the gRPC server (e.g. "127.0.0.1:12345").
+	Address string
+	// StorageURL is the base URL of the emulated Google Cloud Storage API (e.g. "http://127.0.0.1:12346").
+	StorageURL string
+	// Results are returned by Recognize and by every BatchRecognize operation.
+	Results []*speechpb.SpeechRecognitionResult
+	// RecognizeErr is returned by Recognize (if it is not nil).
+	RecognizeErr error
+	// OperationPolls is the number of status checks after which an operation is done.
+	// If it is 0, operations are done immediately. Default value is 1.
+	OperationPolls int
+	// OperationError is the error of every operation (if it is not nil).
+	OperationError *rpcstatus.Status
+	// StreamingResponses are returned by StreamingRecognize after the client has finished sending audio.
+	StreamingResponses []*speechpb.StreamingRecognizeResponse
+
+	grpcServer    *grpc.Server
+	storageServer *httptest.Server
+
+	mutex              sync.Mutex
+	recognizeRequests  []*speechpb.RecognizeRequest
+	batchRequests      []*speechpb.BatchRecognizeRequest
+	operations         map[string]*emulatedOperation
+	operationCounter   int
+	objects            map[string][]byte
+	deletedObjects     []string
+	getOperationCounts map[string]int
+	recognizers        map[string]*speechpb.Recognizer
+	createRecognizers  []*speechpb.CreateRecognizerRequest
+	streamingConfigs   []*speechpb.StreamingRecognizeRequest
+	streamingAudio     []byte
+	phraseSets         map[string]*speechpb.PhraseSet
+	customClasses      map[string]*speechpb.CustomClass
+}
+
+// NewSpeechEmulator starts a new emulator that returns the given results.
+// The emulator must be closed with Close.
+func NewSpeechEmulator(results ...*speechpb.SpeechRecognitionResult) (*SpeechEmulator, error) {
+	e := &SpeechEmulator{
+		Results:            results,
+		OperationPolls:     1,
+		operations:         make(map[string]*emulatedOperation),
+		objects:            make(map[string][]byte),
+		getOperationCounts: make(map[string]int),
+		recognizers:        make(map[string]*speechpb.Recognizer),
+		phraseSets:         make(map[string]*speechpb.PhraseSet),
+		customClasses:      make(map[string]*speechpb.CustomClass),
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	e.grpcServer = grpc.NewServer()
+	speechpb.RegisterSpeechServer(e.grpcServer, &emulatedSpeechServer{emulator: e})
+	longrunningpb.RegisterOperationsServer(e.grpcServer, &emulatedOperationsServer{emulator: e})
+	go func() {
+		_ = e.grpcServer.Serve(listener)
+	}()
+	e.Address = listener.Addr().String()
+
+	e.storageServer = httptest.NewServer(http.HandlerFunc(e.handleStorage))
+	e.StorageURL = e.storageServer.URL
+	return e, nil
+}
+
+// Close shuts down the emulator.
+func (e *SpeechEmulator) Close() {
+	e.grpcServer.Stop()
+	e.storageServer.Close()
+}
+
+// ClientOptions returns the client options that connect a GCP Speech-to-Text client to the emulator.
+func (e *SpeechEmulator) ClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint(e.Address),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	}
+}
+
+// StorageClientOptions returns the client options that connect a Google Cloud Storage client to the emulator.
+func (e *SpeechEmulator) StorageClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint(e.StorageURL + "/storage/v1/"),
+		option.WithoutAuthentication(),
+	}
+}
+
+// RecognizeRequests returns all recorded Recognize requests in chronological order.
+func (e *SpeechEmulator) RecognizeRequests() []*speechpb.RecognizeRequest {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]*speechpb.RecognizeRequest(nil), e.recognizeRequests...)
+}
+
+// BatchRecognizeRequests returns all recorded BatchRecognize requests in chronological order.
+func (e *SpeechEmulator) BatchRecognizeRequests() []*speechpb.BatchRecognizeRequest {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]*speechpb.BatchRecognizeRequest(nil), e.batchRequests...)
+}
+
+// OperationPollCount returns the number of status checks of the given operation.
+func (e *SpeechEmulator) OperationPollCount(name string) int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.getOperationCounts[name]
+}
+
+// PutObject stores the given content as object on the emulated storage.
+func (e *SpeechEmulator) PutObject(bucket string, key string, content []byte) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.objects[bucket+"/"+key] = content
+}
+
+// Object returns the content of the given object on the emulated storage, if it exists.
+func (e *SpeechEmulator) Object(bucket string, key string) ([]byte, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	content, exists := e.objects[bucket+"/"+key]
+	return content, exists
+}
+
+// DeletedObjects returns the objects ("bucket/key") that have been deleted from the emulated storage,
+// in chronological order.
+func (e *SpeechEmulator) DeletedObjects() []string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]string(nil), e.deletedObjects...)
+}
+
+// AddRecognizer adds the given recognizer to the emulator, as if it had been created with CreateRecognizer.
+func (e *SpeechEmulator) AddRecognizer(recognizer *speechpb.Recognizer) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.recognizers[recognizer.GetName()] = recognizer
+}
+
+// Recognizer returns the recognizer with the given name, if it exists.
+func (e *SpeechEmulator) Recognizer(name string) (*speechpb.Recognizer, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	recognizer, exists := e.recognizers[name]
+	return recognizer, exists
+}
+
+// CreateRecognizerRequests returns all recorded CreateRecognizer requests in chronological order.
+func (e *SpeechEmulator) CreateRecognizerRequests() []*speechpb.CreateRecognizerRequest {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]*speechpb.CreateRecognizerRequest(nil), e.createRecognizers...)
+}
+
+// StreamingConfigRequests returns the first requests (which contain the recognizer and streaming config) of all
+// recorded StreamingRecognize calls in chronological order.
+func (e *SpeechEmulator) StreamingConfigRequests() []*speechpb.StreamingRecognizeRequest {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]*speechpb.StreamingRecognizeRequest(nil), e.streamingConfigs...)
+}
+
+// StreamingAudio returns all audio that has been sent with StreamingRecognize.
+func (e *SpeechEmulator) StreamingAudio() []byte {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]byte(nil), e.streamingAudio...)
+}
+
+// emulatedSpeechServer implements the Speech-to-Text v2 gRPC service of a SpeechEmulator.
+type emulatedSpeechServer struct {
+	speechpb.UnimplementedSpeechServer
+	emulator *SpeechEmulator
+}
+
+// getRecognizerLocation returns the location ("projects/{project}/locations/{location}") of the given recognizer,
+// which must be the implicit recognizer or an existing recognizer. The emulator mutex must be held.
+func (e *SpeechEmulator) getRecognizerLocation(recognizer string) (string, error) {
+	parts := strings.Split(recognizer, "/")
+	if len(parts) != 6 || parts[0] != "projects" || parts[2] != "locations" || parts[4] != "recognizers" {
+		return "", status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid recognizer name '%s'.", recognizer))
+	}
+	if _, exists := e.recognizers[recognizer]; parts[5] != "_" && !exists {
+		return "", status.Error(codes.NotFound, fmt.Sprintf("Recognizer '%s' not found.", recognizer))
+	}
+	return strings.Join(parts[:4], "/"), nil
+}
+
+func (s *emulatedSpeechServer) GetRecognizer(ctx context.Context, req *speechpb.GetRecognizerRequest) (*speechpb.Recognizer, error) {
+	e := s.emulator
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	recognizer, exists := e.recognizers[req.GetName()]
+	if !exists {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("Recognizer '%s' not found.", req.GetName()))
+	}
+	return recognizer, nil
+}
+
+func (s *emulatedSpeechServer) CreateRecognizer(ctx context.Context, req *speechpb.CreateRecognizerRequest) (*longrunningpb.Operation, error) {
+	e := s.emulator
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.createRecognizers = append(e.createRecognizers, req)
+	name := req.GetParent() + "/recognizers/" + req.GetRecognizerId()
+	if _, exists := e.recognizers[name]; exists {
+		return nil, status.Error(codes.AlreadyExists, fmt.Sprintf("Recognizer '%s' already exists.", name))
+	}
+
+	recognizer := proto.Clone(req.GetRecognizer()).(*speechpb.Recognizer)
+	recognizer.Name = name
+	recognizer.State = speechpb.Recognizer_ACTIVE
+	e.recognizers[name] = recognizer
+	return e.newDoneOperation(req.GetParent(), recognizer)
+}
+
+// newDoneOperation creates an operation in the given location that is already done and has the given response.
+// The emulator mutex must be held.
+func (e *SpeechEmulator) newDoneOperation(location string, response proto.Message) (*longrunningpb.Operation, error) {
+	responseAny, err := anypb.New(response)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	e.operationCounter++
+	operation := &emulatedOperation{
+		result: &longrunningpb.Operation{
+			Name:   fmt.Sprintf("%s/operations/%d", location, e.operationCounter),
+			Done:   true,
+			Result: &longrunningpb.Operation_Response{Response: responseAny},
+		},
+	}
+	e.operations[operation.result.Name] = operation
+	return operation.result, nil
+}
+
+func (s *emulatedSpeechServer) Recognize(ctx context.Context, req *speechpb.RecognizeRequest) (*speechpb.RecognizeResponse, error) {
+	e := s.emulator
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.recognizeRequests = append(e.recognizeRequests, req)
+	if _, err := e.getRecognizerLocation(req.GetRecognizer()); err != nil {
+		return nil, err
+	}
+	if e.RecognizeErr != nil {
+		return nil, e.RecognizeErr
+	}
+	return &speechpb.RecognizeResponse{Results: e.Results}, nil
+}
+
+func (s *emulatedSpeechServer) StreamingRecognize(stream speechpb.Speech_StreamingRecognizeServer) error {
+	e := s.emulator
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	e.mutex.Lock()
+	e.streamingConfigs = append(e.streamingConfigs, req)
+	_, errRecognizer := e.getRecognizerLocation(req.GetRecognizer())
+	e.mutex.Unlock()
+	if errRecognizer != nil {
+		return errRecognizer
+	}
+	if req.GetStreamingConfig() == nil {
+		return status.Error(codes.InvalidArgument, "The first request must contain the streaming config.")
+	}
+
+	for {
+		req, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		e.mutex.Lock()
+		e.streamingAudio = append(e.streamingAudio, req.GetAudio()...)
+		e.mutex.Unlock()
+	}
+
+	e.mutex.Lock()
+	responses := e.StreamingResponses
+	e.mutex.Unlock()
+	for _, response := range responses {
+		if err = stream.Send(response); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *emulatedSpeechServer) BatchRecognize(ctx context.Context, req *speechpb.BatchRecognizeRequest) (*longrunningpb.Operation, error) {
+	e := s.emulator
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.batchRequests = append(e.batchRequests, req)
+	location, err := e.getRecognizerLocation(req.GetRecognizer())
+	if err != nil {
+		return nil, err
+	}
+	if len(req.GetFiles()) < 1 {
+		return nil, status.Error(codes.InvalidArgument, "At least one file is required.")
+	}
+
+	e.operationCounter++
+	operation := &emulatedOperation{
+		request: req,
+		result: &longrunningpb.Operation{
+			Name: fmt.Sprintf("%s/operations/%d", location, e.operationCounter),
+		},
+	}
+	e.operations[operation.result.Name] = operation
+	if e.OperationPolls <= 0 {
+		if err := e.completeOperation(operation); err != nil {
+			return nil, err
+		}
+	}
+	return operation.result, nil
+}
+
+// emulatedOperationsServer implements the long-running operations gRPC service of a SpeechEmulator.
+type emulatedOperationsServer struct {
+	longrunningpb.UnimplementedOperationsServer
+	emulator *SpeechEmulator
+}
+
+func (s *emulatedOperationsServer) GetOperation(ctx context.Context, req *longrunningpb.GetOperationRequest) (*longrunningpb.Operation, error) {
+	e := s.emulator
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	operation, exists := e.operations[req.GetName()]
+	if !exists {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("Operation '%s' not found.", req.GetName()))
+	}
+	e.getOperationCounts[req.GetName()]++
+	operation.polls++
+	if !operation.result.GetDone() && operation.polls >= e.OperationPolls {
+		if err := e.completeOperation(operation); err != nil {
+			return nil, err
+		}
+	}
+	return operation.result, nil
+}
+
+// completeOperation marks the given operation as done and sets its result. The emulator mutex must be held.
+func (e *SpeechEmulator) completeOperation(operation *emulatedOperation) error {
+	operation.result.Done = true
+	if e.OperationError != nil {
+		operation.result.Result = &longrunningpb.Operation_Error{Error: e.OperationError}
+		return nil
+	}
+
+	response := &speechpb.BatchRecognizeResponse{
+		Results: make(map[string]*speechpb.BatchRecognizeFileResult),
+	}
+	transcript := &speechpb.BatchRecognizeResults{Results: e.Results}
+	for _, file := range operation.request.GetFiles() {
+		fileResult := &speechpb.BatchRecognizeFileResult{}
+		if outputUri := operation.request.GetRecognitionOutputConfig().GetGcsOutputConfig().GetUri(); outputUri != "" {
+			content, err := protojson.Marshal(transcript)
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			resultUri := strings.TrimSuffix(outputUri, "/") + "/" + strings.TrimSuffix(path.Base(file.GetUri()), path.Ext(file.GetUri())) + "_transcript.json"
+			bucket, key, _ := strings.Cut(strings.TrimPrefix(resultUri, "gs://"), "/")
+			e.objects[bucket+"/"+key] = content
+			fileResult.Uri = resultUri
+		} else {
+			fileResult.Transcript = proto.Clone(transcript).(*speechpb.BatchRecognizeResults)
+		}
+		response.Results[file.GetUri()] = fileResult
+	}
+
+	responseAny, err := anypb.New(response)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	operation.result.Result = &longrunningpb.Operation_Response{Response: responseAny}
+	return nil
+}
+
+// handleStorage handles requests of the Google Cloud Storage JSON API (uploads and deletes) and XML API (downloads).
+func (e *SpeechEmulator) handleStorage(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/upload/storage/v1/b/"):
+		e.uploadObject(w, r)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/storage/v1/b/"):
+		bucket, object, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/storage/v1/b/"), "/o/")
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		if _, exists := e.objects[bucket+"/"+object]; !exists {
+			writeStorageError(w, http.StatusNotFound, "No such object.")
+			return
+		}
+		delete(e.objects, bucket+"/"+object)
+		e.deletedObjects = append(e.deletedObjects, bucket+"/"+object)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet:
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		content, exists := e.objects[strings.TrimPrefix(r.URL.Path, "/")]
+		if !exists {
+			writeStorageError(w, http.StatusNotFound, "No such object.")
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(content)
+	default:
+		writeStorageError(w, http.StatusNotImplemented, fmt.Sprintf("The request '%s %s' is not supported by the emulator.", r.Method, r.URL.Path))
+	}
+}
+
+// uploadObject handles a multipart upload (i.e. metadata and content in a single request).
+func (e *SpeechEmulator) uploadObject(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/upload/storage/v1/b/"), "/o")
+	if r.URL.Query().Get("uploadType") != "multipart" {
+		writeStorageError(w, http.StatusNotImplemented, "Only multipart uploads are supported by the emulator.")
+		return
+	}
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		writeStorageError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reader := multipart.NewReader(r.Body, params["boundary"])
+	var metadata struct {
+		Name string `json:"name"`
+	}
+	metadataPart, err := reader.NextPart()
+	if err == nil {
+		err = json.NewDecoder(metadataPart).Decode(&metadata)
+	}
+	var content []byte
+	if err == nil {
+		var contentPart *multipart.Part
+		contentPart, err = reader.NextPart()
+		if err == nil {
+			content, err = io.ReadAll(contentPart)
+		}
+	}
+	if err != nil {
+		writeStorageError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	name := metadata.Name
+	if name == "" {
+		name = r.URL.Query().Get("name")
+	}
+
+	e.mutex.Lock()
+	e.objects[bucket+"/"+name] = content
+	e.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"kind":   "storage#object",
+		"bucket": bucket,
+		"name":   name,
+		"id":     bucket + "/" + url.PathEscape(name),
+		"size":   fmt.Sprintf("%d", len(content)),
+	})
+}
+
+func writeStorageError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{"code": statusCode, "message": message},
+	})
+}
@@ -0,0 +1,232 @@
+package s2ttest
+
+import (
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
+	speechpb "cloud.google.com/go/speech/apiv2/speechpb"
+	"context"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// customClassReferencePattern matches references to CustomClass resources in phrases.
+var customClassReferencePattern = regexp.MustCompile(`\$\{(projects/[^}]+/customClasses/[^}]+)\}`)
+
+// PhraseSet returns the PhraseSet with the given resource name, if it exists.
+func (e *SpeechEmulator) PhraseSet(name string) (*speechpb.PhraseSet, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	phraseSet, exists := e.phraseSets[name]
+	return phraseSet, exists
+}
+
+// CustomClass returns the CustomClass with the given resource name, if it exists.
+func (e *SpeechEmulator) CustomClass(name string) (*speechpb.CustomClass, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	customClass, exists := e.customClasses[name]
+	return customClass, exists
+}
+
+// checkCustomClassReferences returns an error if the given phrases reference CustomClasses that don't exist.
+// The emulator mutex must be held.
+func (e *SpeechEmulator) checkCustomClassReferences(phrases []*speechpb.PhraseSet_Phrase) error {
+	for _, phrase := range phrases {
+		for _, match := range customClassReferencePattern.FindAllStringSubmatch(phrase.GetValue(), -1) {
+			if _, exists := e.customClasses[match[1]]; !exists {
+				return status.Error(codes.InvalidArgument, fmt.Sprintf("CustomClass '%s' referenced in phrase '%s' not found.", match[1], phrase.GetValue()))
+			}
+		}
+	}
+	return nil
+}
+
+// getResourceLocation returns the location ("projects/{project}/locations/{location}") of the given resource name.
+func getResourceLocation(name string) string {
+	parts := strings.Split(name, "/")
+	if len(parts) < 4 {
+		return name
+	}
+	return strings.Join(parts[:4], "/")
+}
+
+func (s *emulatedSpeechServer) CreatePhraseSet(ctx context.Context, req *speechpb.CreatePhraseSetRequest) (*longrunningpb.Operation, error) {
+	e := s.emulator
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	name := req.GetParent() + "/phraseSets/" + req.GetPhraseSetId()
+	if _, exists := e.phraseSets[name]; exists {
+		return nil, status.Error(codes.AlreadyExists, fmt.Sprintf("PhraseSet '%s' already exists.", name))
+	}
+	if err := e.checkCustomClassReferences(req.GetPhraseSet().GetPhrases()); err != nil {
+		return nil, err
+	}
+
+	phraseSet := proto.Clone(req.GetPhraseSet()).(*speechpb.PhraseSet)
+	phraseSet.Name = name
+	phraseSet.State = speechpb.PhraseSet_ACTIVE
+	phraseSet.CreateTime = timestamppb.Now()
+	phraseSet.UpdateTime = phraseSet.CreateTime
+	e.phraseSets[name] = phraseSet
+	return e.newDoneOperation(req.GetParent(), phraseSet)
+}
+
+func (s *emulatedSpeechServer) GetPhraseSet(ctx context.Context, req *speechpb.GetPhraseSetRequest) (*speechpb.PhraseSet, error) {
+	e := s.emulator
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	phraseSet, exists := e.phraseSets[req.GetName()]
+	if !exists {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("PhraseSet '%s' not found.", req.GetName()))
+	}
+	return phraseSet, nil
+}
+
+func (s *emulatedSpeechServer) ListPhraseSets(ctx context.Context, req *speechpb.ListPhraseSetsRequest) (*speechpb.ListPhraseSetsResponse, error) {
+	e := s.emulator
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	response := &speechpb.ListPhraseSetsResponse{}
+	for name, phraseSet := range e.phraseSets {
+		if strings.HasPrefix(name, req.GetParent()+"/phraseSets/") {
+			response.PhraseSets = append(response.PhraseSets, phraseSet)
+		}
+	}
+	sort.Slice(response.PhraseSets, func(i, j int) bool {
+		return response.PhraseSets[i].GetName() < response.PhraseSets[j].GetName()
+	})
+	return response, nil
+}
+
+func (s *emulatedSpeechServer) UpdatePhraseSet(ctx context.Context, req *speechpb.UpdatePhraseSetRequest) (*longrunningpb.Operation, error) {
+	e := s.emulator
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	name := req.GetPhraseSet().GetName()
+	phraseSet, exists := e.phraseSets[name]
+	if !exists {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("PhraseSet '%s' not found.", name))
+	}
+	if err := e.checkCustomClassReferences(req.GetPhraseSet().GetPhrases()); err != nil {
+		return nil, err
+	}
+
+	phraseSet = proto.Clone(phraseSet).(*speechpb.PhraseSet)
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		switch path {
+		case "phrases":
+			phraseSet.Phrases = req.GetPhraseSet().GetPhrases()
+		case "boost":
+			phraseSet.Boost = req.GetPhraseSet().GetBoost()
+		default:
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("The field '%s' can't be updated by the emulator.", path))
+		}
+	}
+	phraseSet.UpdateTime = timestamppb.Now()
+	e.phraseSets[name] = phraseSet
+	return e.newDoneOperation(getResourceLocation(name), phraseSet)
+}
+
+func (s *emulatedSpeechServer) DeletePhraseSet(ctx context.Context, req *speechpb.DeletePhraseSetRequest) (*longrunningpb.Operation, error) {
+	e := s.emulator
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	phraseSet, exists := e.phraseSets[req.GetName()]
+	if !exists {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("PhraseSet '%s' not found.", req.GetName()))
+	}
+	delete(e.phraseSets, req.GetName())
+
+	phraseSet = proto.Clone(phraseSet).(*speechpb.PhraseSet)
+	phraseSet.State = speechpb.PhraseSet_DELETED
+	phraseSet.DeleteTime = timestamppb.Now()
+	return e.newDoneOperation(getResourceLocation(req.GetName()), phraseSet)
+}
+
+func (s *emulatedSpeechServer) CreateCustomClass(ctx context.Context, req *speechpb.CreateCustomClassRequest) (*longrunningpb.Operation, error) {
+	e := s.emulator
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	name := req.GetParent() + "/customClasses/" + req.GetCustomClassId()
+	if _, exists := e.customClasses[name]; exists {
+		return nil, status.Error(codes.AlreadyExists, fmt.Sprintf("CustomClass '%s' already exists.", name))
+	}
+
+	customClass := proto.Clone(req.GetCustomClass()).(*speechpb.CustomClass)
+	customClass.Name = name
+	customClass.State = speechpb.CustomClass_ACTIVE
+	customClass.CreateTime = timestamppb.Now()
+	customClass.UpdateTime = customClass.CreateTime
+	e.customClasses[name] = customClass
+	return e.newDoneOperation(req.GetParent(), customClass)
+}
+
+func (s *emulatedSpeechServer) GetCustomClass(ctx context.Context, req *speechpb.GetCustomClassRequest) (*speechpb.CustomClass, error) {
+	e := s.emulator
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	customClass, exists := e.customClasses[req.GetName()]
+	if !exists {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("CustomClass '%s' not found.", req.GetName()))
+	}
+	return customClass, nil
+}
+
+func (s *emulatedSpeechServer) ListCustomClasses(ctx context.Context, req *speechpb.ListCustomClassesRequest) (*speechpb.ListCustomClassesResponse, error) {
+	e := s.emulator
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	response := &speechpb.ListCustomClassesResponse{}
+	for name, customClass := range e.customClasses {
+		if strings.HasPrefix(name, req.GetParent()+"/customClasses/") {
+			response.CustomClasses = append(response.CustomClasses, customClass)
+		}
+	}
+	sort.Slice(response.CustomClasses, func(i, j int) bool {
+		return response.CustomClasses[i].GetName() < response.CustomClasses[j].GetName()
+	})
+	return response, nil
+}
+
+func (s *emulatedSpeechServer) UpdateCustomClass(ctx context.Context, req *speechpb.UpdateCustomClassRequest) (*longrunningpb.Operation, error) {
+	e := s.emulator
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	name := req.GetCustomClass().GetName()
+	customClass, exists := e.customClasses[name]
+	if !exists {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("CustomClass '%s' not found.", name))
+	}
+
+	customClass = proto.Clone(customClass).(*speechpb.CustomClass)
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		if path != "items" {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("The field '%s' can't be updated by the emulator.", path))
+		}
+		customClass.Items = req.GetCustomClass().GetItems()
+	}
+	customClass.UpdateTime = timestamppb.Now()
+	e.customClasses[name] = customClass
+	return e.newDoneOperation(getResourceLocation(name), customClass)
+}
+
+func (s *emulatedSpeechServer) DeleteCustomClass(ctx context.Context, req *speechpb.DeleteCustomClassRequest) (*longrunningpb.Operation, error) {
+	e := s.emulator
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	customClass, exists := e.customClasses[req.GetName()]
+	if !exists {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("CustomClass '%s' not found.", req.GetName()))
+	}
+	delete(e.customClasses, req.GetName())
+
+	customClass = proto.Clone(customClass).(*speechpb.CustomClass)
+	customClass.State = speechpb.CustomClass_DELETED
+	customClass.DeleteTime = timestamppb.Now()
+	return e.newDoneOperation(getResourceLocation(req.GetName()), customClass)
+}
@@ -0,0 +1,120 @@
+package s2ttest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/FaaSTools/GoStorage/gostorage"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	"os"
+	"sync"
+)
+
+var _ shared.Storage = (*FakeStorage)(nil)
+
+// CopyOperation is a recorded copy operation of a FakeStorage.
+type CopyOperation struct {
+	Source      gostorage.GoStorageObject
+	Destination gostorage.GoStorageObject
+}
+
+// FakeStorage is an in-memory Storage that records all uploads, copies and deletes.
+// Uploaded local files are read into memory, so their content can be checked with File.
+type FakeStorage struct {
+	// UploadErr is returned by UploadFile (if it is not nil).
+	UploadErr error
+	// BeforeUpload is called at the beginning of every UploadFile call (if it is not nil). It can be used to
+	// delay uploads or to cancel a context while an upload is running.
+	BeforeUpload func(obj gostorage.GoStorageObject)
+	// CopyErr is returned by Copy (if it is not nil).
+	CopyErr error
+	// DeleteErr is returned by DeleteFile (if it is not nil).
+	DeleteErr error
+
+	mutex   sync.Mutex
+	uploads []gostorage.GoStorageObject
+	copies  []CopyOperation
+	deletes []gostorage.GoStorageObject
+	files   map[string][]byte
+}
+
+// NewFakeStorage creates an empty fake storage.
+func NewFakeStorage() *FakeStorage {
+	return &FakeStorage{
+		files: make(map[string][]byte),
+	}
+}
+
+func getFileKey(obj gostorage.GoStorageObject) string {
+	return fmt.Sprintf("%s/%s", obj.Bucket, obj.Key)
+}
+
+func (s *FakeStorage) UploadFile(obj gostorage.GoStorageObject) error {
+	if s.BeforeUpload != nil {
+		s.BeforeUpload(obj)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.uploads = append(s.uploads, obj)
+	if s.UploadErr != nil {
+		return s.UploadErr
+	}
+	content, err := os.ReadFile(obj.LocalFilePath)
+	if err != nil {
+		return errors.Join(errors.New(fmt.Sprintf("fake storage couldn't read local file '%s'", obj.LocalFilePath)), err)
+	}
+	s.files[getFileKey(obj)] = content
+	return nil
+}
+
+func (s *FakeStorage) Copy(source gostorage.GoStorageObject, destination gostorage.GoStorageObject) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.copies = append(s.copies, CopyOperation{Source: source, Destination: destination})
+	if s.CopyErr != nil {
+		return s.CopyErr
+	}
+	if content, exists := s.files[getFileKey(source)]; exists {
+		s.files[getFileKey(destination)] = content
+	}
+	return nil
+}
+
+func (s *FakeStorage) DeleteFile(obj gostorage.GoStorageObject) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.deletes = append(s.deletes, obj)
+	if s.DeleteErr != nil {
+		return s.DeleteErr
+	}
+	delete(s.files, getFileKey(obj))
+	return nil
+}
+
+// Uploads returns all recorded uploads in chronological order.
+func (s *FakeStorage) Uploads() []gostorage.GoStorageObject {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]gostorage.GoStorageObject(nil), s.uploads...)
+}
+
+// Copies returns all recorded copy operations in chronological order.
+func (s *FakeStorage) Copies() []CopyOperation {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]CopyOperation(nil), s.copies...)
+}
+
+// Deletes returns all recorded deletes in chronological order.
+func (s *FakeStorage) Deletes() []gostorage.GoStorageObject {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]gostorage.GoStorageObject(nil), s.deletes...)
+}
+
+// File returns the content of the given file, if it has been uploaded (or copied) and not deleted.
+func (s *FakeStorage) File(bucket string, key string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	content, exists := s.files[fmt.Sprintf("%s/%s", bucket, key)]
+	return content, exists
+}
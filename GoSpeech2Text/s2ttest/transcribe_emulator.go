@@ -0,0 +1,438 @@
+package s2ttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Job statuses of the AWS Transcribe API, as used by TranscribeEmulator.
+const (
+	TranscribeStatusQueued     = "QUEUED"
+	TranscribeStatusInProgress = "IN_PROGRESS"
+	TranscribeStatusCompleted  = "COMPLETED"
+	TranscribeStatusFailed     = "FAILED"
+)
+
+// StartTranscriptionJobRequest is a recorded StartTranscriptionJob request of a TranscribeEmulator.
+type StartTranscriptionJobRequest struct {
+	TranscriptionJobName string
+	LanguageCode         string
+	MediaFileUri         string
+	OutputBucketName     string
+	OutputKey            string
+	// Raw is the complete JSON body of the request.
+	Raw map[string]interface{}
+}
+
+// emulatedJob is the state of a transcription job of a TranscribeEmulator.
+type emulatedJob struct {
+	request      StartTranscriptionJobRequest
+	status       string
+	statusChecks int
+	creationTime time.Time
+}
+
+// TranscribeEmulator is a local stand-in HTTP server for the AWS Transcribe and S3 APIs, which can be used to test the
+// AWS provider without network access. Set the Endpoint of the AWS provider to the URL of the emulator.
+//
+// The emulator supports the Transcribe actions StartTranscriptionJob, GetTranscriptionJob, CreateVocabulary,
+// UpdateVocabulary, GetVocabulary, ListVocabularies, DeleteVocabulary, CreateVocabularyFilter and
+// GetVocabularyFilter, and the S3 operations GetObject, PutObject, CopyObject and DeleteObject (with path-style URLs).
+// Every GetTranscriptionJob request advances the job to the next status in Statuses. As soon as a job has completed,
+// its transcript file is stored at the output location of the job. If the job settings specify a vocabulary filter
+// that has been created on the emulator, the words of the filter are masked, removed or tagged.
+// If the job redacts content, the redacted transcript file (in which RedactedWords are redacted) is stored with the
+// prefix "redacted-" in its file name, and the unredacted transcript file is only stored at the output location if
+// it has been requested. Likewise, every GetVocabulary request advances the vocabulary to the next state in
+// VocabularyStates.
+type TranscribeEmulator struct {
+	// URL is the base URL of the emulator (e.g. "http://127.0.0.1:12345").
+	URL string
+	// Statuses are the job statuses returned by successive GetTranscriptionJob requests for a job. Once all statuses
+	// have been returned, the last one is repeated.
+	// Default value is IN_PROGRESS, followed by COMPLETED.
+	Statuses []string
+	// Text is the transcribed text of every job, unless TranscriptOutput is set.
+	Text string
+	// TranscriptOutput is the content of the transcript file of every job (if it is not nil).
+	TranscriptOutput []byte
+	// RedactedWords are the words of Text that are redacted by jobs that redact content, with their entity types
+	// (e.g. "1234" -> "PIN").
+	RedactedWords map[string]string
+	// FailureReason is the failure reason of failed jobs and vocabularies.
+	FailureReason string
+	// VocabularyStates are the vocabulary states returned by successive GetVocabulary requests for a vocabulary.
+	// Once all states have been returned, the last one is repeated.
+	// Default value is PENDING, followed by READY.
+	VocabularyStates []string
+
+	server  *httptest.Server
+	mutex   sync.Mutex
+	jobs    map[string]*emulatedJob
+	starts  []StartTranscriptionJobRequest
+	objects map[string][]byte
+	deletes []string
+
+	vocabularies        map[string]*emulatedVocabulary
+	vocabularyCreations int
+	deletedVocabularies []string
+
+	vocabularyFilters         map[string]EmulatedVocabularyFilter
+	vocabularyFilterCreations int
+}
+
+// NewTranscribeEmulator starts a new emulator that transcribes every audio file into the given text.
+// The emulator must be closed with Close.
+func NewTranscribeEmulator(text string) *TranscribeEmulator {
+	e := &TranscribeEmulator{
+		Statuses:          []string{TranscribeStatusInProgress, TranscribeStatusCompleted},
+		Text:              text,
+		FailureReason:     "emulated failure",
+		VocabularyStates:  []string{TranscribeVocabularyStatePending, TranscribeVocabularyStateReady},
+		jobs:              make(map[string]*emulatedJob),
+		objects:           make(map[string][]byte),
+		vocabularies:      make(map[string]*emulatedVocabulary),
+		vocabularyFilters: make(map[string]EmulatedVocabularyFilter),
+	}
+	e.server = httptest.NewServer(http.HandlerFunc(e.handle))
+	e.URL = e.server.URL
+	return e
+}
+
+// Close shuts down the emulator.
+func (e *TranscribeEmulator) Close() {
+	e.server.Close()
+}
+
+// StartRequests returns all recorded StartTranscriptionJob requests in chronological order.
+func (e *TranscribeEmulator) StartRequests() []StartTranscriptionJobRequest {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]StartTranscriptionJobRequest(nil), e.starts...)
+}
+
+// StatusChecks returns the number of GetTranscriptionJob requests for the given job.
+func (e *TranscribeEmulator) StatusChecks(jobName string) int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if job, exists := e.jobs[jobName]; exists {
+		return job.statusChecks
+	}
+	return 0
+}
+
+// PutObject stores the given content as S3 object.
+func (e *TranscribeEmulator) PutObject(bucket string, key string, content []byte) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.objects[bucket+"/"+key] = content
+}
+
+// Object returns the content of the given S3 object, if it exists.
+func (e *TranscribeEmulator) Object(bucket string, key string) ([]byte, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	content, exists := e.objects[bucket+"/"+key]
+	return content, exists
+}
+
+// DeletedObjects returns the S3 objects ("bucket/key") that have been deleted, in chronological order.
+func (e *TranscribeEmulator) DeletedObjects() []string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]string(nil), e.deletes...)
+}
+
+func (e *TranscribeEmulator) handle(w http.ResponseWriter, r *http.Request) {
+	target := r.Header.Get("X-Amz-Target")
+	if strings.EqualFold(target, "") {
+		e.handleS3(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", err.Error())
+		return
+	}
+	switch target {
+	case "Transcribe.StartTranscriptionJob":
+		e.startTranscriptionJob(w, body)
+	case "Transcribe.GetTranscriptionJob":
+		e.getTranscriptionJob(w, body)
+	case "Transcribe.CreateVocabulary":
+		e.createVocabulary(w, body)
+	case "Transcribe.UpdateVocabulary":
+		e.updateVocabulary(w, body)
+	case "Transcribe.GetVocabulary":
+		e.getVocabulary(w, body)
+	case "Transcribe.ListVocabularies":
+		e.listVocabularies(w)
+	case "Transcribe.DeleteVocabulary":
+		e.deleteVocabulary(w, body)
+	case "Transcribe.CreateVocabularyFilter":
+		e.createVocabularyFilter(w, body)
+	case "Transcribe.GetVocabularyFilter":
+		e.getVocabularyFilter(w, body)
+	default:
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", fmt.Sprintf("The action '%s' is not supported by the emulator.", target))
+	}
+}
+
+func (e *TranscribeEmulator) startTranscriptionJob(w http.ResponseWriter, body []byte) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", err.Error())
+		return
+	}
+	request := StartTranscriptionJobRequest{
+		TranscriptionJobName: getString(raw, "TranscriptionJobName"),
+		LanguageCode:         getString(raw, "LanguageCode"),
+		OutputBucketName:     getString(raw, "OutputBucketName"),
+		OutputKey:            getString(raw, "OutputKey"),
+		Raw:                  raw,
+	}
+	if media, ok := raw["Media"].(map[string]interface{}); ok {
+		request.MediaFileUri = getString(media, "MediaFileUri")
+	}
+	if strings.EqualFold(request.TranscriptionJobName, "") || strings.EqualFold(request.MediaFileUri, "") {
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", "TranscriptionJobName and Media are required.")
+		return
+	}
+
+	e.mutex.Lock()
+	e.starts = append(e.starts, request)
+	if _, exists := e.jobs[request.TranscriptionJobName]; exists {
+		e.mutex.Unlock()
+		writeTranscribeError(w, http.StatusBadRequest, "ConflictException", "The requested job name already exists. Use a different job name.")
+		return
+	}
+	if settings, ok := raw["Settings"].(map[string]interface{}); ok {
+		// vocabularies that haven't been created on the emulator are assumed to exist
+		vocabulary, exists := e.vocabularies[getString(settings, "VocabularyName")]
+		if exists && vocabulary.vocabulary.State != TranscribeVocabularyStateReady {
+			e.mutex.Unlock()
+			writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", "The requested vocabulary isn't ready yet.")
+			return
+		}
+	}
+	job := &emulatedJob{
+		request:      request,
+		status:       TranscribeStatusInProgress,
+		creationTime: time.Now(),
+	}
+	e.jobs[request.TranscriptionJobName] = job
+	response := e.getJobResponse(job)
+	e.mutex.Unlock()
+
+	writeJSON(w, response)
+}
+
+func (e *TranscribeEmulator) getTranscriptionJob(w http.ResponseWriter, body []byte) {
+	var request struct {
+		TranscriptionJobName string
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", err.Error())
+		return
+	}
+
+	e.mutex.Lock()
+	job, exists := e.jobs[request.TranscriptionJobName]
+	if !exists {
+		e.mutex.Unlock()
+		writeTranscribeError(w, http.StatusBadRequest, "NotFoundException", "The requested job couldn't be found.")
+		return
+	}
+	if len(e.Statuses) > 0 {
+		index := job.statusChecks
+		if index >= len(e.Statuses) {
+			index = len(e.Statuses) - 1
+		}
+		job.status = e.Statuses[index]
+	}
+	job.statusChecks++
+	if job.status == TranscribeStatusCompleted {
+		redactionOutput, redaction := getJobRedactionOutput(job)
+		if redaction {
+			e.objects[job.request.OutputBucketName+"/"+getRedactedOutputKey(job.request.OutputKey)] = e.getTranscriptOutput(job, true)
+		}
+		if !redaction || redactionOutput == "redacted_and_unredacted" {
+			e.objects[job.request.OutputBucketName+"/"+job.request.OutputKey] = e.getTranscriptOutput(job, false)
+		}
+	}
+	response := e.getJobResponse(job)
+	e.mutex.Unlock()
+
+	writeJSON(w, response)
+}
+
+// getJobResponse creates the response body of StartTranscriptionJob and GetTranscriptionJob for the given job.
+func (e *TranscribeEmulator) getJobResponse(job *emulatedJob) map[string]interface{} {
+	transcriptionJob := map[string]interface{}{
+		"TranscriptionJobName":   job.request.TranscriptionJobName,
+		"TranscriptionJobStatus": job.status,
+		"LanguageCode":           job.request.LanguageCode,
+		"Media":                  map[string]interface{}{"MediaFileUri": job.request.MediaFileUri},
+		"CreationTime":           float64(job.creationTime.UnixMilli()) / 1000,
+	}
+	switch job.status {
+	case TranscribeStatusCompleted:
+		// path-style URLs, like the ones returned by AWS
+		transcript := map[string]interface{}{}
+		redactionOutput, redaction := getJobRedactionOutput(job)
+		if redaction {
+			transcript["RedactedTranscriptFileUri"] = fmt.Sprintf("https://s3.us-east-1.amazonaws.com/%s/%s", job.request.OutputBucketName, getRedactedOutputKey(job.request.OutputKey))
+		}
+		if !redaction || redactionOutput == "redacted_and_unredacted" {
+			transcript["TranscriptFileUri"] = fmt.Sprintf("https://s3.us-east-1.amazonaws.com/%s/%s", job.request.OutputBucketName, job.request.OutputKey)
+		}
+		transcriptionJob["Transcript"] = transcript
+	case TranscribeStatusFailed:
+		transcriptionJob["FailureReason"] = e.FailureReason
+	}
+	return map[string]interface{}{"TranscriptionJob": transcriptionJob}
+}
+
+// getJobRedactionOutput returns the redaction output of the given job, and whether the job redacts content.
+func getJobRedactionOutput(job *emulatedJob) (string, bool) {
+	contentRedaction, ok := job.request.Raw["ContentRedaction"].(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	return getString(contentRedaction, "RedactionOutput"), true
+}
+
+// getRedactedOutputKey returns the key of the redacted transcript file for the given output key of a job.
+func getRedactedOutputKey(key string) string {
+	index := strings.LastIndex(key, "/") + 1
+	return key[:index] + "redacted-" + key[index:]
+}
+
+// getTranscriptOutput creates the content of the transcript file of the given job. The words of Text get
+// consecutive timestamps of half a second each. If redacted is true, RedactedWords are replaced by "[PII]".
+func (e *TranscribeEmulator) getTranscriptOutput(job *emulatedJob, redacted bool) []byte {
+	if e.TranscriptOutput != nil {
+		return e.TranscriptOutput
+	}
+	filterWords, filterMethod := e.getJobVocabularyFilter(job)
+	text := e.Text
+	var contents []string
+	var items []map[string]interface{}
+	for i, word := range strings.Fields(e.Text) {
+		item := map[string]interface{}{
+			"start_time": fmt.Sprintf("%.1f", float64(i)*0.5),
+			"end_time":   fmt.Sprintf("%.1f", float64(i+1)*0.5),
+			"type":       "pronunciation",
+		}
+		if filterWords[strings.ToLower(word)] {
+			switch filterMethod {
+			case "remove":
+				continue
+			case "tag":
+				item["vocabulary_filter_match"] = true
+			default:
+				word = "***"
+			}
+		}
+		alternative := map[string]interface{}{"confidence": "1.0", "content": word}
+		if entityType, exists := e.RedactedWords[word]; exists && redacted {
+			word = "[PII]"
+			alternative["content"] = word
+			alternative["redactions"] = []map[string]interface{}{{"confidence": "1.0", "type": entityType, "category": "PII"}}
+		}
+		item["alternatives"] = []map[string]interface{}{alternative}
+		items = append(items, item)
+		contents = append(contents, word)
+	}
+	if filterWords != nil || redacted {
+		text = strings.Join(contents, " ")
+	}
+	output, _ := json.Marshal(map[string]interface{}{
+		"jobName": job.request.TranscriptionJobName,
+		"status":  TranscribeStatusCompleted,
+		"results": map[string]interface{}{
+			"language_code": job.request.LanguageCode,
+			"transcripts":   []map[string]interface{}{{"transcript": text}},
+			"items":         items,
+		},
+	})
+	return output
+}
+
+// handleS3 handles S3 requests with path-style URLs (i.e. "/bucket/key").
+func (e *TranscribeEmulator) handleS3(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if !strings.Contains(path, "/") {
+		writeS3Error(w, http.StatusBadRequest, "InvalidRequest", "Only object requests are supported by the emulator.")
+		return
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		content, exists := e.objects[path]
+		if !exists {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(content)
+	case http.MethodPut:
+		if copySource := r.Header.Get("X-Amz-Copy-Source"); !strings.EqualFold(copySource, "") {
+			source, _ := url.PathUnescape(strings.TrimPrefix(copySource, "/"))
+			content, exists := e.objects[source]
+			if !exists {
+				writeS3Error(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+				return
+			}
+			e.objects[path] = content
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<CopyObjectResult><ETag>\"emulated\"</ETag></CopyObjectResult>")
+			return
+		}
+		content, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeS3Error(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+			return
+		}
+		e.objects[path] = content
+	case http.MethodDelete:
+		delete(e.objects, path)
+		e.deletes = append(e.deletes, path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed.")
+	}
+}
+
+func getString(values map[string]interface{}, key string) string {
+	value, _ := values[key].(string)
+	return value
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeTranscribeError(w http.ResponseWriter, statusCode int, errorType string, message string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.Header().Set("X-Amzn-ErrorType", errorType)
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(map[string]string{"__type": errorType, "Message": message})
+}
+
+func writeS3Error(w http.ResponseWriter, statusCode int, code string, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(statusCode)
+	_, _ = fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Error><Code>%s</Code><Message>%s</Message></Error>", code, message)
+}
@@ -0,0 +1,209 @@
+package s2ttest
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Vocabulary states of the AWS Transcribe API, as used by TranscribeEmulator.
+const (
+	TranscribeVocabularyStatePending = "PENDING"
+	TranscribeVocabularyStateReady   = "READY"
+	TranscribeVocabularyStateFailed  = "FAILED"
+)
+
+// EmulatedVocabulary is a custom vocabulary of a TranscribeEmulator.
+type EmulatedVocabulary struct {
+	VocabularyName    string
+	LanguageCode      string
+	Phrases           []string
+	VocabularyFileUri string
+	State             string
+}
+
+// emulatedVocabulary is the state of a custom vocabulary of a TranscribeEmulator.
+type emulatedVocabulary struct {
+	vocabulary       EmulatedVocabulary
+	stateChecks      int
+	lastModifiedTime time.Time
+}
+
+// Vocabulary returns the custom vocabulary with the given name, if it exists.
+func (e *TranscribeEmulator) Vocabulary(name string) (EmulatedVocabulary, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if vocabulary, exists := e.vocabularies[name]; exists {
+		return vocabulary.vocabulary, true
+	}
+	return EmulatedVocabulary{}, false
+}
+
+// VocabularyCreations returns the number of CreateVocabulary requests that have been successful.
+func (e *TranscribeEmulator) VocabularyCreations() int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.vocabularyCreations
+}
+
+// DeletedVocabularies returns the names of the custom vocabularies that have been deleted, in chronological order.
+func (e *TranscribeEmulator) DeletedVocabularies() []string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]string(nil), e.deletedVocabularies...)
+}
+
+func (e *TranscribeEmulator) createVocabulary(w http.ResponseWriter, body []byte) {
+	var request struct {
+		VocabularyName    string
+		LanguageCode      string
+		Phrases           []string
+		VocabularyFileUri string
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", err.Error())
+		return
+	}
+	if strings.EqualFold(request.VocabularyName, "") || strings.EqualFold(request.LanguageCode, "") {
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", "VocabularyName and LanguageCode are required.")
+		return
+	}
+
+	e.mutex.Lock()
+	if _, exists := e.vocabularies[request.VocabularyName]; exists {
+		e.mutex.Unlock()
+		writeTranscribeError(w, http.StatusBadRequest, "ConflictException", "The requested vocabulary name already exists. Use a different vocabulary name.")
+		return
+	}
+	vocabulary := &emulatedVocabulary{
+		vocabulary: EmulatedVocabulary{
+			VocabularyName:    request.VocabularyName,
+			LanguageCode:      request.LanguageCode,
+			Phrases:           request.Phrases,
+			VocabularyFileUri: request.VocabularyFileUri,
+			State:             TranscribeVocabularyStatePending,
+		},
+		lastModifiedTime: time.Now(),
+	}
+	e.vocabularies[request.VocabularyName] = vocabulary
+	e.vocabularyCreations++
+	response := e.getVocabularyResponse(vocabulary)
+	e.mutex.Unlock()
+
+	writeJSON(w, response)
+}
+
+func (e *TranscribeEmulator) updateVocabulary(w http.ResponseWriter, body []byte) {
+	var request struct {
+		VocabularyName    string
+		LanguageCode      string
+		Phrases           []string
+		VocabularyFileUri string
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", err.Error())
+		return
+	}
+
+	e.mutex.Lock()
+	vocabulary, exists := e.vocabularies[request.VocabularyName]
+	if !exists {
+		e.mutex.Unlock()
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", "The requested vocabulary couldn't be found. Check the vocabulary name and try your request again.")
+		return
+	}
+	vocabulary.vocabulary.LanguageCode = request.LanguageCode
+	vocabulary.vocabulary.Phrases = request.Phrases
+	vocabulary.vocabulary.VocabularyFileUri = request.VocabularyFileUri
+	vocabulary.vocabulary.State = TranscribeVocabularyStatePending
+	vocabulary.stateChecks = 0
+	vocabulary.lastModifiedTime = time.Now()
+	response := e.getVocabularyResponse(vocabulary)
+	e.mutex.Unlock()
+
+	writeJSON(w, response)
+}
+
+func (e *TranscribeEmulator) listVocabularies(w http.ResponseWriter) {
+	e.mutex.Lock()
+	var names []string
+	for name := range e.vocabularies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var vocabularies []map[string]interface{}
+	for _, name := range names {
+		vocabularies = append(vocabularies, e.getVocabularyResponse(e.vocabularies[name]))
+	}
+	e.mutex.Unlock()
+
+	writeJSON(w, map[string]interface{}{"Vocabularies": vocabularies})
+}
+
+func (e *TranscribeEmulator) getVocabulary(w http.ResponseWriter, body []byte) {
+	var request struct {
+		VocabularyName string
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", err.Error())
+		return
+	}
+
+	e.mutex.Lock()
+	vocabulary, exists := e.vocabularies[request.VocabularyName]
+	if !exists {
+		e.mutex.Unlock()
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", "The requested vocabulary couldn't be found. Check the vocabulary name and try your request again.")
+		return
+	}
+	if len(e.VocabularyStates) > 0 {
+		index := vocabulary.stateChecks
+		if index >= len(e.VocabularyStates) {
+			index = len(e.VocabularyStates) - 1
+		}
+		vocabulary.vocabulary.State = e.VocabularyStates[index]
+	}
+	vocabulary.stateChecks++
+	response := e.getVocabularyResponse(vocabulary)
+	e.mutex.Unlock()
+
+	writeJSON(w, response)
+}
+
+func (e *TranscribeEmulator) deleteVocabulary(w http.ResponseWriter, body []byte) {
+	var request struct {
+		VocabularyName string
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", err.Error())
+		return
+	}
+
+	e.mutex.Lock()
+	if _, exists := e.vocabularies[request.VocabularyName]; !exists {
+		e.mutex.Unlock()
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", "The requested vocabulary couldn't be found. Check the vocabulary name and try your request again.")
+		return
+	}
+	delete(e.vocabularies, request.VocabularyName)
+	e.deletedVocabularies = append(e.deletedVocabularies, request.VocabularyName)
+	e.mutex.Unlock()
+
+	writeJSON(w, map[string]interface{}{})
+}
+
+// getVocabularyResponse creates the response body of the vocabulary actions for the given vocabulary.
+func (e *TranscribeEmulator) getVocabularyResponse(vocabulary *emulatedVocabulary) map[string]interface{} {
+	response := map[string]interface{}{
+		"VocabularyName":   vocabulary.vocabulary.VocabularyName,
+		"LanguageCode":     vocabulary.vocabulary.LanguageCode,
+		"VocabularyState":  vocabulary.vocabulary.State,
+		"LastModifiedTime": float64(vocabulary.lastModifiedTime.UnixMilli()) / 1000,
+	}
+	if vocabulary.vocabulary.State == TranscribeVocabularyStateFailed {
+		response["FailureReason"] = e.FailureReason
+	}
+	return response
+}
@@ -0,0 +1,112 @@
+package s2ttest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// EmulatedVocabularyFilter is a vocabulary filter of a TranscribeEmulator.
+type EmulatedVocabularyFilter struct {
+	VocabularyFilterName string
+	LanguageCode         string
+	Words                []string
+	LastModifiedTime     time.Time
+}
+
+// VocabularyFilter returns the vocabulary filter with the given name, if it exists.
+func (e *TranscribeEmulator) VocabularyFilter(name string) (EmulatedVocabularyFilter, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	filter, exists := e.vocabularyFilters[name]
+	return filter, exists
+}
+
+// VocabularyFilterCreations returns the number of CreateVocabularyFilter requests that have been successful.
+func (e *TranscribeEmulator) VocabularyFilterCreations() int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.vocabularyFilterCreations
+}
+
+func (e *TranscribeEmulator) createVocabularyFilter(w http.ResponseWriter, body []byte) {
+	var request struct {
+		VocabularyFilterName string
+		LanguageCode         string
+		Words                []string
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", err.Error())
+		return
+	}
+	if strings.EqualFold(request.VocabularyFilterName, "") || strings.EqualFold(request.LanguageCode, "") || len(request.Words) < 1 {
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", "VocabularyFilterName, LanguageCode and Words are required.")
+		return
+	}
+
+	e.mutex.Lock()
+	if _, exists := e.vocabularyFilters[request.VocabularyFilterName]; exists {
+		e.mutex.Unlock()
+		writeTranscribeError(w, http.StatusBadRequest, "ConflictException", "The requested vocabulary filter name already exists. Use a different vocabulary filter name.")
+		return
+	}
+	filter := EmulatedVocabularyFilter{
+		VocabularyFilterName: request.VocabularyFilterName,
+		LanguageCode:         request.LanguageCode,
+		Words:                request.Words,
+		LastModifiedTime:     time.Now(),
+	}
+	e.vocabularyFilters[filter.VocabularyFilterName] = filter
+	e.vocabularyFilterCreations++
+	e.mutex.Unlock()
+
+	writeJSON(w, getVocabularyFilterResponse(filter))
+}
+
+func (e *TranscribeEmulator) getVocabularyFilter(w http.ResponseWriter, body []byte) {
+	var request struct {
+		VocabularyFilterName string
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeTranscribeError(w, http.StatusBadRequest, "BadRequestException", err.Error())
+		return
+	}
+
+	e.mutex.Lock()
+	filter, exists := e.vocabularyFilters[request.VocabularyFilterName]
+	e.mutex.Unlock()
+	if !exists {
+		writeTranscribeError(w, http.StatusBadRequest, "NotFoundException", "The requested vocabulary filter couldn't be found.")
+		return
+	}
+	writeJSON(w, getVocabularyFilterResponse(filter))
+}
+
+// getVocabularyFilterResponse creates the response body of the vocabulary filter actions for the given filter.
+func getVocabularyFilterResponse(filter EmulatedVocabularyFilter) map[string]interface{} {
+	return map[string]interface{}{
+		"VocabularyFilterName": filter.VocabularyFilterName,
+		"LanguageCode":         filter.LanguageCode,
+		"LastModifiedTime":     float64(filter.LastModifiedTime.UnixMilli()) / 1000,
+	}
+}
+
+// getJobVocabularyFilter returns the words and method of the vocabulary filter in the settings of the given job.
+// If the job has no vocabulary filter or the filter hasn't been created on the emulator, nil is returned.
+// The emulator mutex must be held.
+func (e *TranscribeEmulator) getJobVocabularyFilter(job *emulatedJob) (map[string]bool, string) {
+	settings, ok := job.request.Raw["Settings"].(map[string]interface{})
+	if !ok {
+		return nil, ""
+	}
+	filter, exists := e.vocabularyFilters[getString(settings, "VocabularyFilterName")]
+	if !exists {
+		return nil, ""
+	}
+	words := make(map[string]bool)
+	for _, word := range filter.Words {
+		words[strings.ToLower(word)] = true
+	}
+	return words, getString(settings, "VocabularyFilterMethod")
+}
@@ -0,0 +1,175 @@
+package s2ttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream/eventstreamapi"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// TranscribeStreamingEmulator is an in-process stand-in for AWS Transcribe Streaming (event stream over HTTP/2 with
+// TLS), which can be used to test the streaming transcription of the AWS provider without network access.
+// Set URL as StreamingEndpoint and Client() as HTTPClient of the AWS provider.
+//
+// The emulator checks that every message of the request stream is signed, collects the audio of the audio events
+// until the stream is ended (by an empty signed message) and then returns TranscriptEvents, or an exception if
+// ExceptionType is set. If the request redacts content, RedactedWords are replaced by "[PII]" in the returned events.
+type TranscribeStreamingEmulator struct {
+	// URL is the base URL of the emulator (e.g. "https://127.0.0.1:12345").
+	URL string
+	// TranscriptEvents are the JSON payloads of the TranscriptEvents that are returned for every stream.
+	TranscriptEvents []string
+	// ExceptionType is the type of the exception (e.g. "BadRequestException") that is returned instead of
+	// TranscriptEvents (if it is not empty).
+	ExceptionType    string
+	ExceptionMessage string
+	// RedactedWords are the words of TranscriptEvents that are redacted if the request redacts content, with their
+	// entity types (e.g. "1234" -> "PIN").
+	RedactedWords map[string]string
+
+	server *httptest.Server
+
+	mutex   sync.Mutex
+	headers []http.Header
+	audio   []byte
+}
+
+// NewTranscribeStreamingEmulator starts a new emulator that returns the given TranscriptEvents (JSON payloads).
+// The emulator must be closed with Close.
+func NewTranscribeStreamingEmulator(transcriptEvents ...string) *TranscribeStreamingEmulator {
+	e := &TranscribeStreamingEmulator{
+		TranscriptEvents: transcriptEvents,
+	}
+	e.server = httptest.NewUnstartedServer(http.HandlerFunc(e.handle))
+	e.server.EnableHTTP2 = true
+	e.server.StartTLS()
+	e.URL = e.server.URL
+	return e
+}
+
+// Close shuts down the emulator.
+func (e *TranscribeStreamingEmulator) Close() {
+	e.server.Close()
+}
+
+// Client returns an HTTP client that trusts the TLS certificate of the emulator and uses HTTP/2.
+func (e *TranscribeStreamingEmulator) Client() *http.Client {
+	return e.server.Client()
+}
+
+// RequestHeaders returns the headers of all recorded stream requests in chronological order.
+func (e *TranscribeStreamingEmulator) RequestHeaders() []http.Header {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]http.Header(nil), e.headers...)
+}
+
+// Audio returns all audio that has been sent in audio events.
+func (e *TranscribeStreamingEmulator) Audio() []byte {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]byte(nil), e.audio...)
+}
+
+func (e *TranscribeStreamingEmulator) handle(w http.ResponseWriter, r *http.Request) {
+	e.mutex.Lock()
+	e.headers = append(e.headers, r.Header.Clone())
+	e.mutex.Unlock()
+	if r.URL.Path != "/stream-transcription" || r.Header.Get("Authorization") == "" {
+		writeTranscribeError(w, http.StatusForbidden, "AccessDeniedException", "The request is not signed.")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+
+	decoder := eventstream.NewDecoder()
+	encoder := eventstream.NewEncoder()
+	for {
+		message, err := decoder.Decode(r.Body, nil)
+		if err != nil {
+			return
+		}
+		if message.Headers.Get(eventstreamapi.ChunkSignatureHeader) == nil || message.Headers.Get(eventstreamapi.DateHeader) == nil {
+			writeStreamingException(w, encoder, "BadRequestException", "The message is not signed.")
+			return
+		}
+		if len(message.Payload) == 0 { // end of stream
+			break
+		}
+		event, err := decoder.Decode(bytes.NewReader(message.Payload), nil)
+		if err != nil || event.Headers.Get(eventstreamapi.EventTypeHeader).String() != "AudioEvent" {
+			writeStreamingException(w, encoder, "BadRequestException", "The message doesn't contain an audio event.")
+			return
+		}
+		e.mutex.Lock()
+		e.audio = append(e.audio, event.Payload...)
+		e.mutex.Unlock()
+	}
+
+	if e.ExceptionType != "" {
+		writeStreamingException(w, encoder, e.ExceptionType, e.ExceptionMessage)
+		return
+	}
+	redact := r.Header.Get("x-amzn-transcribe-content-redaction-type") != ""
+	for _, transcriptEvent := range e.TranscriptEvents {
+		if redact {
+			transcriptEvent = e.redactTranscriptEvent(transcriptEvent)
+		}
+		var headers eventstream.Headers
+		headers.Set(eventstreamapi.MessageTypeHeader, eventstream.StringValue(eventstreamapi.EventMessageType))
+		headers.Set(eventstreamapi.EventTypeHeader, eventstream.StringValue("TranscriptEvent"))
+		headers.Set(eventstreamapi.ContentTypeHeader, eventstream.StringValue("application/json"))
+		if err := encoder.Encode(w, eventstream.Message{Headers: headers, Payload: []byte(transcriptEvent)}); err != nil {
+			return
+		}
+		w.(http.Flusher).Flush()
+	}
+}
+
+// writeStreamingException writes an exception message with the given type and message to the response stream.
+func writeStreamingException(w http.ResponseWriter, encoder *eventstream.Encoder, exceptionType string, message string) {
+	var headers eventstream.Headers
+	headers.Set(eventstreamapi.MessageTypeHeader, eventstream.StringValue(eventstreamapi.ExceptionMessageType))
+	headers.Set(eventstreamapi.ExceptionTypeHeader, eventstream.StringValue(exceptionType))
+	payload, _ := json.Marshal(map[string]string{"Message": message})
+	_ = encoder.Encode(w, eventstream.Message{Headers: headers, Payload: payload})
+	w.(http.Flusher).Flush()
+}
+
+// redactTranscriptEvent replaces RedactedWords in the transcripts and items of the given TranscriptEvent payload.
+func (e *TranscribeStreamingEmulator) redactTranscriptEvent(transcriptEvent string) string {
+	var event map[string]interface{}
+	if err := json.Unmarshal([]byte(transcriptEvent), &event); err != nil {
+		return transcriptEvent
+	}
+	transcript, _ := event["Transcript"].(map[string]interface{})
+	results, _ := transcript["Results"].([]interface{})
+	for _, result := range results {
+		alternatives, _ := result.(map[string]interface{})["Alternatives"].([]interface{})
+		for _, alternative := range alternatives {
+			alternative := alternative.(map[string]interface{})
+			words := strings.Fields(getString(alternative, "Transcript"))
+			for i, word := range words {
+				if _, exists := e.RedactedWords[word]; exists {
+					words[i] = "[PII]"
+				}
+			}
+			alternative["Transcript"] = strings.Join(words, " ")
+			items, _ := alternative["Items"].([]interface{})
+			for _, item := range items {
+				item := item.(map[string]interface{})
+				if _, exists := e.RedactedWords[getString(item, "Content")]; exists {
+					item["Content"] = "[PII]"
+				}
+			}
+		}
+	}
+	redacted, _ := json.Marshal(event)
+	return string(redacted)
+}
@@ -0,0 +1,88 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// ErrUnknownAudioDuration is returned by GetAudioDuration if the duration can't be determined from the audio data.
+var ErrUnknownAudioDuration = errors.New("couldn't determine the duration of the audio data")
+
+// GetAudioDuration determines the duration of the given audio file content by parsing its header.
+// Currently, WAV (RIFF/WAVE) and FLAC files are supported.
+// For other formats, or if the header is incomplete, ErrUnknownAudioDuration is returned.
+func GetAudioDuration(content []byte) (time.Duration, error) {
+	switch {
+	case len(content) >= 12 && bytes.Equal(content[0:4], []byte("RIFF")) && bytes.Equal(content[8:12], []byte("WAVE")):
+		return getWavDuration(content)
+	case len(content) >= 4 && bytes.Equal(content[0:4], []byte("fLaC")):
+		return getFlacDuration(content)
+	default:
+		return 0, ErrUnknownAudioDuration
+	}
+}
+
+// GetRawAudioDuration calculates the duration of the given raw (headerless) audio content with the given format.
+// If the format is not raw or the sample rate is undefined, ErrUnknownAudioDuration is returned.
+func GetRawAudioDuration(content []byte, format AudioFormat) (time.Duration, error) {
+	bytesPerSample := 1 // MULAW and ALAW
+	if format.Encoding == AudioEncodingLinear16 {
+		bytesPerSample = 2
+	}
+	if !format.IsRaw() || format.SampleRateHertz <= 0 {
+		return 0, ErrUnknownAudioDuration
+	}
+	byteRate := int64(format.SampleRateHertz) * int64(format.GetChannelCount()) * int64(bytesPerSample)
+	return time.Duration(float64(len(content)) / float64(byteRate) * float64(time.Second)), nil
+}
+
+// getWavDuration calculates the duration of a WAV file from the byte rate in its "fmt " chunk and the size of
+// its "data" chunk.
+func getWavDuration(content []byte) (time.Duration, error) {
+	var byteRate uint32 = 0
+	offset := 12
+	for offset+8 <= len(content) {
+		chunkId := string(content[offset : offset+4])
+		chunkSize := binary.LittleEndian.Uint32(content[offset+4 : offset+8])
+		chunkStart := offset + 8
+		switch chunkId {
+		case "fmt ":
+			if chunkStart+12 > len(content) {
+				return 0, ErrUnknownAudioDuration
+			}
+			byteRate = binary.LittleEndian.Uint32(content[chunkStart+8 : chunkStart+12])
+		case "data":
+			if byteRate == 0 {
+				return 0, ErrUnknownAudioDuration
+			}
+			dataSize := uint64(chunkSize)
+			if available := uint64(len(content) - chunkStart); chunkSize == 0xFFFFFFFF || dataSize > available {
+				// data chunk size is unknown (e.g. streamed WAV files) or larger than the file -> use remaining size
+				dataSize = available
+			}
+			return time.Duration(float64(dataSize) / float64(byteRate) * float64(time.Second)), nil
+		}
+		// chunks are padded to an even size
+		offset = chunkStart + int(chunkSize) + int(chunkSize%2)
+	}
+	return 0, ErrUnknownAudioDuration
+}
+
+// getFlacDuration calculates the duration of a FLAC file from the sample rate and total number of samples in
+// its STREAMINFO metadata block (which is always the first metadata block).
+func getFlacDuration(content []byte) (time.Duration, error) {
+	// "fLaC" + 4 bytes metadata block header + 18 bytes STREAMINFO until total samples
+	if len(content) < 4+4+18 || content[4]&0x7F != 0 {
+		return 0, ErrUnknownAudioDuration
+	}
+	streamInfo := content[8:]
+	// bytes 10-17: sample rate (20 bits), channels (3 bits), bits per sample (5 bits), total samples (36 bits)
+	sampleRate := uint32(streamInfo[10])<<12 | uint32(streamInfo[11])<<4 | uint32(streamInfo[12])>>4
+	totalSamples := uint64(streamInfo[13]&0x0F)<<32 | uint64(binary.BigEndian.Uint32(streamInfo[14:18]))
+	if sampleRate == 0 || totalSamples == 0 {
+		return 0, ErrUnknownAudioDuration
+	}
+	return time.Duration(float64(totalSamples) / float64(sampleRate) * float64(time.Second)), nil
+}
@@ -0,0 +1,109 @@
+package shared
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// AudioHeaderSize is the number of bytes that are read from the beginning of an audio file to detect its format.
+const AudioHeaderSize = 64
+
+// DetectAudioFileType detects the file type of the given audio file content (or its first bytes) from its magic bytes.
+// The file type is returned without preceding period, like the file types of GetFileTypeFromFileName:
+// "wav" (RIFF/WAVE), "flac", "ogg", "mp3" (ID3 tag or MPEG audio frame), "m4a" or "mp4" (ISO base media file),
+// "webm" (EBML) or "amr".
+// If the format isn't recognized, an empty string is returned.
+func DetectAudioFileType(header []byte) string {
+	switch {
+	case len(header) >= 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WAVE")):
+		return "wav"
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		return "flac"
+	case bytes.HasPrefix(header, []byte("OggS")):
+		return "ogg"
+	case bytes.HasPrefix(header, []byte("ID3")):
+		return "mp3"
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0 && header[1]&0x06 != 0:
+		// MPEG audio frame sync (layer bits 00 are reserved, which excludes AAC in ADTS frames)
+		return "mp3"
+	case len(header) >= 12 && bytes.Equal(header[4:8], []byte("ftyp")):
+		if bytes.Equal(header[8:12], []byte("M4A ")) {
+			return "m4a"
+		}
+		return "mp4"
+	case bytes.HasPrefix(header, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return "webm"
+	case bytes.HasPrefix(header, []byte("#!AMR")):
+		return "amr"
+	default:
+		return ""
+	}
+}
+
+// ReadAudioHeader reads the first AudioHeaderSize bytes of the given local file or file from some other URL.
+// If the file is shorter, the whole file is returned.
+// Files on storage services (AWS S3 or Google Cloud Storage) are not supported, because they require a client of the
+// storage service.
+func ReadAudioHeader(ctx context.Context, source string) ([]byte, error) {
+	var reader io.ReadCloser
+	if strings.HasPrefix(source, "http") { // file somewhere else online -> only request header bytes
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, errors.Join(errors.New(fmt.Sprintf("Couldn't read the header of the source file '%s'.", source)), err)
+		}
+		request.Header.Set("Range", fmt.Sprintf("bytes=0-%d", AudioHeaderSize-1))
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return nil, ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("Couldn't read the header of the source file '%s'.", source)), err))
+		}
+		if response.StatusCode >= 300 {
+			_ = response.Body.Close()
+			return nil, errors.New(fmt.Sprintf("Couldn't read the header of the source file '%s'. Status: %s", source, response.Status))
+		}
+		reader = response.Body
+	} else if IsAWSUrl(source) || IsGoogleUrl(source) {
+		return nil, errors.New(fmt.Sprintf("Couldn't read the header of the source file '%s', because files on storage services are not supported.", source))
+	} else { // local file
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, errors.Join(errors.New(fmt.Sprintf("Couldn't read the header of the source file '%s'.", source)), err)
+		}
+		reader = file
+	}
+	defer reader.Close()
+	return ReadAudioHeaderFromReader(ctx, reader)
+}
+
+// ReadAudioHeaderFromReader reads the first AudioHeaderSize bytes of the given reader.
+// If the reader ends earlier, all bytes that have been read are returned.
+func ReadAudioHeaderFromReader(ctx context.Context, reader io.Reader) ([]byte, error) {
+	header := make([]byte, AudioHeaderSize)
+	n, err := io.ReadFull(reader, header)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, ContextError(ctx, errors.Join(errors.New("error while reading the header of the source file"), err))
+	}
+	return header[:n], nil
+}
+
+// GetAudioFileType returns the file type of the given local file or file from some other URL.
+// The file type is detected from the magic bytes of the file (see DetectAudioFileType). If the file can't be read or
+// its format isn't recognized, the file extension is used instead (see GetFileTypeFromFileName).
+// Files on storage services are only detected by their file extension, since they can only be read with the storage
+// client of the provider (see S2TProvider.ReadStorageAudioHeader).
+func GetAudioFileType(ctx context.Context, source string) string {
+	if !IsAWSUrl(source) && !IsGoogleUrl(source) {
+		header, err := ReadAudioHeader(ctx, source)
+		if err == nil {
+			if fileType := DetectAudioFileType(header); !strings.EqualFold(fileType, "") {
+				return fileType
+			}
+		}
+	}
+	return GetFileTypeFromFileName(source)
+}
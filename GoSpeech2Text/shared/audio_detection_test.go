@@ -0,0 +1,66 @@
+package shared
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectAudioFileType(t *testing.T) {
+	headers := map[string][]byte{
+		"wav":  []byte("RIFF\x24\x00\x00\x00WAVEfmt "),
+		"flac": []byte("fLaC\x00\x00\x00\x22"),
+		"ogg":  []byte("OggS\x00\x02"),
+		"mp3":  []byte("ID3\x04\x00"),
+		"m4a":  []byte("\x00\x00\x00\x20ftypM4A \x00\x00\x00\x00"),
+		"mp4":  []byte("\x00\x00\x00\x18ftypisom\x00\x00\x02\x00"),
+		"webm": {0x1A, 0x45, 0xDF, 0xA3, 0x9F},
+		"amr":  []byte("#!AMR\n"),
+		"":     []byte("hello"),
+	}
+	for expected, header := range headers {
+		if fileType := DetectAudioFileType(header); fileType != expected {
+			t.Error("wrong file type for "+expected+": Got ", fileType)
+		}
+	}
+	if fileType := DetectAudioFileType([]byte{0xFF, 0xFB, 0x90, 0x64}); fileType != "mp3" {
+		t.Error("wrong file type for MPEG frame: Got ", fileType)
+	}
+	if fileType := DetectAudioFileType([]byte{0xFF, 0xF1, 0x50, 0x80}); fileType != "" {
+		t.Error("wrong file type for AAC frame: Got ", fileType)
+	}
+}
+
+func TestGetAudioFileTypeLocalFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sample123")
+	if err := os.WriteFile(path, []byte("fLaC\x00\x00\x00\x22"), 0644); err != nil {
+		t.Fatal("couldn't create audio file: ", err)
+	}
+	if fileType := GetAudioFileType(context.Background(), path); fileType != "flac" {
+		t.Error("wrong file type: Got ", fileType)
+	}
+	if fileType := GetAudioFileType(context.Background(), "s3://bucket/audio.ogg"); fileType != "ogg" {
+		t.Error("wrong file type for storage object: Got ", fileType)
+	}
+}
+
+func TestGetAudioFileTypeUrl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := strings.NewReader("OggS" + strings.Repeat("\x00", 1000))
+		http.ServeContent(w, r, "audio", time.Time{}, content)
+	}))
+	defer server.Close()
+
+	if fileType := GetAudioFileType(context.Background(), server.URL+"/audio?X-Amz-Signature=abc.def"); fileType != "ogg" {
+		t.Error("wrong file type: Got ", fileType)
+	}
+	header, err := ReadAudioHeader(context.Background(), server.URL+"/audio")
+	if err != nil || len(header) != AudioHeaderSize {
+		t.Error("wrong header: Got ", len(header), err)
+	}
+}
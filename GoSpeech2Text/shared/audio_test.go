@@ -0,0 +1,84 @@
+package shared
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// createWavHeader creates a WAV file header (44 bytes) for 16 bit PCM audio with the given data size.
+func createWavHeader(sampleRate uint32, channels uint16, dataSize uint32) []byte {
+	header := make([]byte, 44)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], 36+dataSize)
+	copy(header[8:12], "WAVE")
+	copy(header[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16)
+	binary.LittleEndian.PutUint16(header[20:22], 1)
+	binary.LittleEndian.PutUint16(header[22:24], channels)
+	binary.LittleEndian.PutUint32(header[24:28], sampleRate)
+	binary.LittleEndian.PutUint32(header[28:32], sampleRate*uint32(channels)*2)
+	binary.LittleEndian.PutUint16(header[32:34], channels*2)
+	binary.LittleEndian.PutUint16(header[34:36], 16)
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], dataSize)
+	return header
+}
+
+func TestGetAudioDurationWav(t *testing.T) {
+	// 90 seconds of 16 kHz mono audio (header only, data size is taken from header if data is missing)
+	content := createWavHeader(16000, 1, 90*16000*2)
+	content = append(content, make([]byte, 90*16000*2)...)
+	duration, err := GetAudioDuration(content)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if duration != 90*time.Second {
+		t.Error("wrong duration: Got ", duration)
+	}
+}
+
+func TestGetAudioDurationFlac(t *testing.T) {
+	content := make([]byte, 4+4+34)
+	copy(content[0:4], "fLaC")
+	content[4] = 0x80 // last metadata block, type STREAMINFO
+	content[7] = 34
+	streamInfo := content[8:]
+	// sample rate 44100 Hz (20 bits), 2 channels, 16 bits per sample, 441000 samples (10 seconds)
+	sampleRate := uint32(44100)
+	streamInfo[10] = byte(sampleRate >> 12)
+	streamInfo[11] = byte(sampleRate >> 4)
+	streamInfo[12] = byte(sampleRate<<4) | (1 << 1)
+	streamInfo[13] = 15 << 4
+	binary.BigEndian.PutUint32(streamInfo[14:18], 441000)
+
+	duration, err := GetAudioDuration(content)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if duration != 10*time.Second {
+		t.Error("wrong duration: Got ", duration)
+	}
+}
+
+func TestGetAudioDurationUnknown(t *testing.T) {
+	_, err := GetAudioDuration([]byte("ID3 not supported"))
+	if !errors.Is(err, ErrUnknownAudioDuration) {
+		t.Error("expected ErrUnknownAudioDuration: Got ", err)
+	}
+}
+
+func TestGetRawAudioDuration(t *testing.T) {
+	duration, err := GetRawAudioDuration(make([]byte, 32000), AudioFormat{Encoding: AudioEncodingLinear16, SampleRateHertz: 8000, ChannelCount: 2})
+	if err != nil || duration != time.Second {
+		t.Error("wrong duration: Got ", duration, err)
+	}
+	duration, err = GetRawAudioDuration(make([]byte, 16000), AudioFormat{Encoding: AudioEncodingMulaw, SampleRateHertz: 8000})
+	if err != nil || duration != 2*time.Second {
+		t.Error("wrong duration: Got ", duration, err)
+	}
+	if _, err = GetRawAudioDuration(make([]byte, 16000), AudioFormat{SampleRateHertz: 8000}); err != ErrUnknownAudioDuration {
+		t.Error("expected ErrUnknownAudioDuration: Got ", err)
+	}
+}
@@ -0,0 +1,227 @@
+package shared
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// AudioChunk is a part of some audio, as created by SplitAudio.
+type AudioChunk struct {
+	Audio PCMAudio
+	// Offset is the offset of the beginning of the chunk relative to the beginning of the audio.
+	Offset time.Duration
+}
+
+// GetEndTime returns the offset of the end of the chunk relative to the beginning of the audio.
+func (c AudioChunk) GetEndTime() time.Duration {
+	return c.Offset + c.Audio.GetDuration()
+}
+
+// silenceWindowDuration is the duration of the windows whose loudness is compared to find the quietest point at the
+// end of a chunk.
+const silenceWindowDuration = 20 * time.Millisecond
+
+// SplitAudio splits the given audio into overlapping chunks, as configured by the given config.
+// The end of every chunk (except for the last one) is moved to the quietest point in the last quarter of the chunk,
+// so that chunks preferably end in pauses between words. The next chunk starts the configured overlap before that point.
+// If the audio is shorter than the chunk duration, a single chunk is returned.
+func SplitAudio(audio PCMAudio, config ChunkingConfig) []AudioChunk {
+	frames := audio.GetFrameCount()
+	rate := int64(audio.SampleRateHertz)
+	chunkFrames := int(int64(config.GetChunkDuration()) * rate / int64(time.Second))
+	if rate <= 0 || chunkFrames < 1 || frames <= chunkFrames {
+		return []AudioChunk{{Audio: audio, Offset: 0}}
+	}
+	overlapFrames := int(int64(config.GetOverlap()) * rate / int64(time.Second))
+	windowFrames := int(int64(silenceWindowDuration) * rate / int64(time.Second))
+	if windowFrames < 1 {
+		windowFrames = 1
+	}
+
+	var chunks []AudioChunk
+	start := 0
+	for {
+		end := start + chunkFrames
+		if end >= frames {
+			chunks = append(chunks, createAudioChunk(audio, start, frames))
+			return chunks
+		}
+		end = findQuietestFrame(audio, end-chunkFrames/4, end, windowFrames)
+		chunks = append(chunks, createAudioChunk(audio, start, end))
+		start = end - overlapFrames
+	}
+}
+
+// createAudioChunk creates a chunk with the frames from start (inclusive) to end (exclusive) of the given audio.
+func createAudioChunk(audio PCMAudio, start int, end int) AudioChunk {
+	channels := int(audio.ChannelCount)
+	if channels < 1 {
+		channels = 1
+	}
+	return AudioChunk{
+		Audio: PCMAudio{
+			Samples:         audio.Samples[start*channels : end*channels],
+			SampleRateHertz: audio.SampleRateHertz,
+			ChannelCount:    audio.ChannelCount,
+		},
+		Offset: time.Duration(int64(start) * int64(time.Second) / int64(audio.SampleRateHertz)),
+	}
+}
+
+// findQuietestFrame returns the frame in the middle of the quietest window (i.e. the window with the lowest sum of
+// absolute sample values) between the given frames.
+func findQuietestFrame(audio PCMAudio, from int, to int, windowFrames int) int {
+	channels := int(audio.ChannelCount)
+	if channels < 1 {
+		channels = 1
+	}
+	if from < 0 {
+		from = 0
+	}
+	quietest := to
+	lowestLoudness := int64(math.MaxInt64)
+	for window := from; window+windowFrames <= to; window += windowFrames {
+		var loudness int64 = 0
+		for _, sample := range audio.Samples[window*channels : (window+windowFrames)*channels] {
+			if sample < 0 {
+				loudness -= int64(sample)
+			} else {
+				loudness += int64(sample)
+			}
+		}
+		if loudness < lowestLoudness {
+			lowestLoudness = loudness
+			quietest = window + windowFrames/2
+		}
+	}
+	return quietest
+}
+
+// MergeChunkTranscripts merges the transcripts of the given chunks (as created by SplitAudio) into a single transcript.
+// The timestamps of every transcript are shifted by the offset of its chunk.
+// Words in the overlap of two chunks are taken from the first chunk if they start before the middle of the overlap,
+// and from the second chunk otherwise. A word that is equal to the previous word and starts before it ends is
+// considered to be a duplicate and is skipped. Segments without words are assigned to chunks by their middle.
+// Segments that are partially in the overlap lose their alternatives, because those cover the whole segment.
+// Entities are taken from the chunk in which they start, like words.
+func MergeChunkTranscripts(transcripts []Transcript, chunks []AudioChunk) Transcript {
+	var merged Transcript
+	var texts []string
+	var lastWord *TranscriptWord = nil
+	for i, transcript := range transcripts {
+		from := time.Duration(0)
+		if i > 0 {
+			from = getOverlapMiddle(chunks[i-1], chunks[i])
+		}
+		to := time.Duration(math.MaxInt64)
+		if i < len(transcripts)-1 {
+			to = getOverlapMiddle(chunks[i], chunks[i+1])
+		}
+		if strings.EqualFold(merged.LanguageCode, "") {
+			merged.LanguageCode = transcript.LanguageCode
+		}
+
+		for _, entity := range transcript.Entities {
+			entity.StartTime += chunks[i].Offset
+			entity.EndTime += chunks[i].Offset
+			if entity.StartTime >= from && entity.StartTime < to {
+				merged.Entities = append(merged.Entities, entity)
+			}
+		}
+
+		for _, segment := range transcript.Segments {
+			segment = shiftSegment(segment, chunks[i].Offset)
+			if len(segment.Words) < 1 {
+				if middle := (segment.StartTime + segment.EndTime) / 2; middle >= from && middle < to {
+					merged.Segments = append(merged.Segments, segment)
+					texts = appendText(texts, segment.Text)
+				}
+				continue
+			}
+
+			var words []TranscriptWord
+			keep := false
+			for _, word := range segment.Words {
+				if !word.IsPunctuation {
+					// punctuation marks are kept if the preceding word is kept
+					keep = word.StartTime >= from && word.StartTime < to && !isDuplicateWord(lastWord, word)
+				}
+				if keep {
+					words = append(words, word)
+					if !word.IsPunctuation {
+						lastWord = &words[len(words)-1]
+					}
+				}
+			}
+			if len(words) < 1 {
+				continue
+			}
+			if len(words) < len(segment.Words) {
+				segment.Words = words
+				segment.Text = JoinWords(words)
+				segment.StartTime = words[0].StartTime
+				segment.EndTime = lastWord.EndTime
+				segment.Confidence = AverageWordConfidence(words)
+				segment.Alternatives = nil
+			}
+			merged.Segments = append(merged.Segments, segment)
+			texts = appendText(texts, segment.Text)
+		}
+	}
+	merged.Text = strings.Join(texts, " ")
+	return merged
+}
+
+// appendText appends the given text without surrounding whitespace to the given texts, unless it is empty.
+func appendText(texts []string, text string) []string {
+	if text = strings.TrimSpace(text); strings.EqualFold(text, "") {
+		return texts
+	}
+	return append(texts, text)
+}
+
+// getOverlapMiddle returns the middle of the overlap of the given consecutive chunks.
+func getOverlapMiddle(chunk AudioChunk, nextChunk AudioChunk) time.Duration {
+	return (nextChunk.Offset + chunk.GetEndTime()) / 2
+}
+
+// isDuplicateWord returns true if the given word has the same text as the given previous word and starts before the
+// previous word ends.
+func isDuplicateWord(previous *TranscriptWord, word TranscriptWord) bool {
+	return previous != nil && strings.EqualFold(previous.Text, word.Text) && word.StartTime < previous.EndTime
+}
+
+// shiftSegment shifts all timestamps of the given segment by the given offset.
+// Punctuation marks without timestamps are not shifted.
+func shiftSegment(segment TranscriptSegment, offset time.Duration) TranscriptSegment {
+	segment.StartTime += offset
+	segment.EndTime += offset
+	segment.Words = shiftWords(segment.Words, offset)
+	alternatives := make([]TranscriptAlternative, len(segment.Alternatives))
+	for i, alternative := range segment.Alternatives {
+		alternative.Words = shiftWords(alternative.Words, offset)
+		alternatives[i] = alternative
+	}
+	if len(alternatives) > 0 {
+		segment.Alternatives = alternatives
+	}
+	return segment
+}
+
+// shiftWords returns a copy of the given words with all timestamps shifted by the given offset.
+// Punctuation marks without timestamps are not shifted.
+func shiftWords(words []TranscriptWord, offset time.Duration) []TranscriptWord {
+	if words == nil {
+		return nil
+	}
+	shifted := make([]TranscriptWord, len(words))
+	for i, word := range words {
+		if !word.IsPunctuation || word.StartTime != 0 || word.EndTime != 0 {
+			word.StartTime += offset
+			word.EndTime += offset
+		}
+		shifted[i] = word
+	}
+	return shifted
+}
@@ -0,0 +1,103 @@
+package shared
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSplitAudio(t *testing.T) {
+	samples := make([]int16, 10000) // 10 seconds at 1000 Hz
+	for i := range samples {
+		samples[i] = 1000
+		if i >= 3500 && i < 3520 {
+			samples[i] = 0
+		}
+	}
+	audio := PCMAudio{Samples: samples, SampleRateHertz: 1000, ChannelCount: 1}
+
+	chunks := SplitAudio(audio, ChunkingConfig{Enabled: true, ChunkDurationMs: 4000, OverlapMs: 500})
+	if len(chunks) != 4 {
+		t.Fatal("wrong number of chunks: Got ", len(chunks))
+	}
+	if chunks[0].Offset != 0 || chunks[0].GetEndTime() != 3510*time.Millisecond {
+		t.Error("expected first chunk to end in silence: Got ", chunks[0].Offset, chunks[0].GetEndTime())
+	}
+	if chunks[1].Offset != 3010*time.Millisecond {
+		t.Error("wrong overlap: Got ", chunks[1].Offset)
+	}
+	if chunks[3].GetEndTime() != 10*time.Second {
+		t.Error("expected last chunk to end at the end of the audio: Got ", chunks[3].GetEndTime())
+	}
+
+	chunks = SplitAudio(audio, ChunkingConfig{Enabled: true})
+	if len(chunks) != 1 || len(chunks[0].Audio.Samples) != len(samples) {
+		t.Error("expected short audio not to be split: Got ", len(chunks))
+	}
+}
+
+func TestMergeChunkTranscripts(t *testing.T) {
+	chunks := []AudioChunk{
+		{Audio: PCMAudio{Samples: make([]int16, 10), SampleRateHertz: 1, ChannelCount: 1}, Offset: 0},
+		{Audio: PCMAudio{Samples: make([]int16, 10), SampleRateHertz: 1, ChannelCount: 1}, Offset: 8 * time.Second},
+	}
+	transcripts := []Transcript{
+		{LanguageCode: "en-US", Segments: []TranscriptSegment{{
+			Text: "hello big world.", StartTime: 1 * time.Second, EndTime: 9800 * time.Millisecond,
+			Words: []TranscriptWord{
+				{Text: "hello", StartTime: 1 * time.Second, EndTime: 2 * time.Second},
+				{Text: "big", StartTime: 8500 * time.Millisecond, EndTime: 8900 * time.Millisecond},
+				{Text: "world", StartTime: 9200 * time.Millisecond, EndTime: 9800 * time.Millisecond},
+				{Text: ".", IsPunctuation: true},
+			},
+			Alternatives: []TranscriptAlternative{{Text: "hello pig world."}},
+		}}},
+		{LanguageCode: "en-US", Segments: []TranscriptSegment{{
+			Text: "big world. again", StartTime: 500 * time.Millisecond, EndTime: 4 * time.Second,
+			Words: []TranscriptWord{
+				{Text: "big", StartTime: 500 * time.Millisecond, EndTime: 900 * time.Millisecond},
+				{Text: "world", StartTime: 1200 * time.Millisecond, EndTime: 1800 * time.Millisecond},
+				{Text: ".", IsPunctuation: true},
+				{Text: "again", StartTime: 3 * time.Second, EndTime: 4 * time.Second},
+			},
+		}}},
+	}
+
+	merged := MergeChunkTranscripts(transcripts, chunks)
+	if merged.Text != "hello big world. again" {
+		t.Error("wrong text: Got ", merged.Text)
+	}
+	if merged.LanguageCode != "en-US" || len(merged.Segments) != 2 {
+		t.Fatal("wrong merged transcript: Got ", merged)
+	}
+	if segment := merged.Segments[0]; segment.EndTime != 8900*time.Millisecond || segment.Alternatives != nil {
+		t.Error("wrong first segment: Got ", segment)
+	}
+	words := merged.Segments[1].Words
+	if len(words) != 3 || words[0].StartTime != 9200*time.Millisecond || words[2].EndTime != 12*time.Second {
+		t.Error("wrong offsets: Got ", words)
+	}
+	if words[1].StartTime != 0 {
+		t.Error("expected punctuation without timestamps not to be shifted: Got ", words[1])
+	}
+}
+
+func TestMergeChunkTranscriptsDuplicateWord(t *testing.T) {
+	chunks := []AudioChunk{
+		{Audio: PCMAudio{Samples: make([]int16, 10), SampleRateHertz: 1, ChannelCount: 1}, Offset: 0},
+		{Audio: PCMAudio{Samples: make([]int16, 10), SampleRateHertz: 1, ChannelCount: 1}, Offset: 8 * time.Second},
+	}
+	transcripts := []Transcript{
+		{Segments: []TranscriptSegment{{Text: "hello", Words: []TranscriptWord{
+			{Text: "hello", StartTime: 8800 * time.Millisecond, EndTime: 9400 * time.Millisecond},
+		}}}},
+		{Segments: []TranscriptSegment{{Text: "Hello there", Words: []TranscriptWord{
+			{Text: "Hello", StartTime: 1100 * time.Millisecond, EndTime: 1400 * time.Millisecond},
+			{Text: "there", StartTime: 2 * time.Second, EndTime: 3 * time.Second},
+		}}}},
+	}
+
+	merged := MergeChunkTranscripts(transcripts, chunks)
+	if merged.Text != "hello there" {
+		t.Error("expected duplicate word to be skipped: Got ", merged.Text)
+	}
+}
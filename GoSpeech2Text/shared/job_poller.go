@@ -0,0 +1,175 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// JobStatus is the provider-independent status of a transcription job.
+type JobStatus string
+
+const (
+	JobStatusQueued     JobStatus = "QUEUED"
+	JobStatusInProgress JobStatus = "IN_PROGRESS"
+	JobStatusCompleted  JobStatus = "COMPLETED"
+	JobStatusFailed     JobStatus = "FAILED"
+)
+
+// IsDone returns true if the job has either completed or failed, i.e. if its status won't change anymore.
+func (s JobStatus) IsDone() bool {
+	return s == JobStatusCompleted || s == JobStatusFailed
+}
+
+// ErrJobPollingTimeout is returned by JobPoller.Poll if the job isn't done after the maximum total wait time.
+var ErrJobPollingTimeout = errors.New("transcription job didn't finish within the maximum wait time")
+
+// JobPollStatus is passed to the JobPoller.OnPoll callback after every status check.
+type JobPollStatus struct {
+	// JobName is the name (or ID) of the job that is polled.
+	JobName string
+	// Attempt is the number of the status check, starting at 1.
+	Attempt int
+	// Status is the job status returned by the status check.
+	Status JobStatus
+	// Elapsed is the time since polling has started.
+	Elapsed time.Duration
+	// NextCheckIn is the time until the next status check. It is zero if the job is done.
+	NextCheckIn time.Duration
+}
+
+// PollFunc checks the status of a job once.
+// If it returns an error, polling stops and the error is returned by JobPoller.Poll.
+type PollFunc func(ctx context.Context) (JobStatus, error)
+
+// JobPoller periodically checks the status of a transcription job until it is done.
+// The time between two status checks starts at InitialInterval and is multiplied with Multiplier after every check,
+// up to MaxInterval. Every interval is randomly varied by up to +/- Jitter (a fraction of the interval), so that
+// multiple jobs don't check their status at the same time.
+// Create a JobPoller with NewJobPoller to get the values from SpeechToTextOptions.
+type JobPoller struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	// Multiplier of 1 results in a constant interval.
+	Multiplier float64
+	// Jitter is a fraction of the interval between 0 and 1.
+	Jitter float64
+	// MaxWait is the maximum total time that is waited for the job to finish. 0 means no limit.
+	MaxWait time.Duration
+	// OnPoll is called after every status check (if it is not nil).
+	OnPoll func(status JobPollStatus)
+}
+
+const (
+	defaultJobCheckIntervalMs    = 500
+	defaultJobMaxCheckIntervalMs = 10000
+	defaultJobCheckMultiplier    = 1.5
+	defaultJobCheckJitter        = 0.2
+)
+
+// NewJobPoller creates a JobPoller based on the transcription job options in the given SpeechToTextOptions.
+// Options with zero values are replaced by their default values.
+func NewJobPoller(options SpeechToTextOptions) JobPoller {
+	initialInterval := options.TranscriptionJobCheckIntervalMs
+	if initialInterval <= 0 {
+		initialInterval = defaultJobCheckIntervalMs
+	}
+	maxInterval := options.TranscriptionJobMaxCheckIntervalMs
+	if maxInterval <= 0 {
+		maxInterval = defaultJobMaxCheckIntervalMs
+	}
+	if maxInterval < initialInterval {
+		maxInterval = initialInterval
+	}
+	multiplier := options.TranscriptionJobCheckIntervalMultiplier
+	if multiplier <= 0 {
+		multiplier = defaultJobCheckMultiplier
+	}
+	return JobPoller{
+		InitialInterval: time.Duration(initialInterval) * time.Millisecond,
+		MaxInterval:     time.Duration(maxInterval) * time.Millisecond,
+		Multiplier:      multiplier,
+		Jitter:          defaultJobCheckJitter,
+		MaxWait:         time.Duration(options.TranscriptionJobMaxWaitMs) * time.Millisecond,
+		OnPoll:          options.TranscriptionJobStatusCallback,
+	}
+}
+
+// Poll calls the given PollFunc until it returns a status that is done (JobStatusCompleted or JobStatusFailed)
+// and returns that status. The first status check happens after the initial interval, since Poll is usually
+// called right after a job has been started.
+// If the given context is done, ctx.Err() is returned.
+// If MaxWait is exceeded, ErrJobPollingTimeout is returned.
+func (p JobPoller) Poll(ctx context.Context, jobName string, poll PollFunc) (JobStatus, error) {
+	start := time.Now()
+	interval := p.InitialInterval
+	wait := p.withJitter(interval)
+
+	for attempt := 1; ; attempt++ {
+		if p.MaxWait > 0 {
+			remaining := p.MaxWait - time.Since(start)
+			if remaining <= 0 {
+				return "", errors.Join(ErrJobPollingTimeout, errors.New(fmt.Sprintf("job '%s' has been polled for %s", jobName, time.Since(start))))
+			}
+			if wait > remaining {
+				// check one last time when the maximum wait time is reached
+				wait = remaining
+			}
+		}
+
+		if err := sleepWithContext(ctx, wait); err != nil {
+			return "", err
+		}
+
+		status, err := poll(ctx)
+		if err != nil {
+			return status, ContextError(ctx, err)
+		}
+
+		wait = 0
+		if !status.IsDone() {
+			interval = time.Duration(float64(interval) * p.Multiplier)
+			if interval > p.MaxInterval {
+				interval = p.MaxInterval
+			}
+			wait = p.withJitter(interval)
+		}
+
+		if p.OnPoll != nil {
+			p.OnPoll(JobPollStatus{
+				JobName:     jobName,
+				Attempt:     attempt,
+				Status:      status,
+				Elapsed:     time.Since(start),
+				NextCheckIn: wait,
+			})
+		}
+
+		if status.IsDone() {
+			return status, nil
+		}
+	}
+}
+
+// sleepWithContext waits for the given duration. If the given context is done before that, ctx.Err() is returned.
+func sleepWithContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// withJitter randomly varies the given interval by up to +/- Jitter.
+func (p JobPoller) withJitter(interval time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return interval
+	}
+	factor := 1 + p.Jitter*(2*rand.Float64()-1)
+	return time.Duration(float64(interval) * factor)
+}
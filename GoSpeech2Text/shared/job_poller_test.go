@@ -0,0 +1,91 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func getTestJobPoller() JobPoller {
+	return JobPoller{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     4 * time.Millisecond,
+		Multiplier:      2,
+		Jitter:          0,
+	}
+}
+
+func TestJobPollerPoll(t *testing.T) {
+	poller := getTestJobPoller()
+	var statuses []JobPollStatus
+	poller.OnPoll = func(status JobPollStatus) {
+		statuses = append(statuses, status)
+	}
+
+	calls := 0
+	status, err := poller.Poll(context.Background(), "job", func(ctx context.Context) (JobStatus, error) {
+		calls++
+		if calls < 5 {
+			return JobStatusInProgress, nil
+		}
+		return JobStatusCompleted, nil
+	})
+	if err != nil {
+		t.Error("unexpected error: ", err)
+	}
+	if status != JobStatusCompleted {
+		t.Error("wrong status: Got ", status)
+	}
+	if len(statuses) != 5 {
+		t.Fatal("wrong number of callbacks: Got ", len(statuses))
+	}
+	expectedWaits := []time.Duration{2 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond, 0}
+	for i, s := range statuses {
+		if s.Attempt != i+1 || s.NextCheckIn != expectedWaits[i] || s.JobName != "job" {
+			t.Error("wrong poll status: Got ", s)
+		}
+	}
+}
+
+func TestJobPollerPollError(t *testing.T) {
+	pollErr := errors.New("status check failed")
+	_, err := getTestJobPoller().Poll(context.Background(), "job", func(ctx context.Context) (JobStatus, error) {
+		return "", pollErr
+	})
+	if !errors.Is(err, pollErr) {
+		t.Error("expected poll error: Got ", err)
+	}
+}
+
+func TestJobPollerPollTimeout(t *testing.T) {
+	poller := getTestJobPoller()
+	poller.MaxWait = 10 * time.Millisecond
+	_, err := poller.Poll(context.Background(), "job", func(ctx context.Context) (JobStatus, error) {
+		return JobStatusInProgress, nil
+	})
+	if !errors.Is(err, ErrJobPollingTimeout) {
+		t.Error("expected timeout error: Got ", err)
+	}
+}
+
+func TestJobPollerPollCancel(t *testing.T) {
+	poller := getTestJobPoller()
+	poller.InitialInterval = time.Hour
+	poller.MaxInterval = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := poller.Poll(ctx, "job", func(ctx context.Context) (JobStatus, error) {
+		return JobStatusInProgress, nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected deadline exceeded error: Got ", err)
+	}
+}
+
+func TestNewJobPollerDefaults(t *testing.T) {
+	poller := NewJobPoller(SpeechToTextOptions{})
+	if poller.InitialInterval != 500*time.Millisecond || poller.MaxInterval != 10*time.Second || poller.Multiplier != 1.5 || poller.MaxWait != 0 {
+		t.Error("wrong default poller: Got ", poller)
+	}
+}
@@ -15,7 +15,9 @@ type SpeechToTextOptions struct {
 	// This property is ignored on GCP.
 	// See docs for TranscriptionJobNameConfig for more info.
 	TranscriptionJobName TranscriptionJobNameConfig
-	// ContentRedactionConfig is currently only available on AWS.
+	// ContentRedactionConfig specifies which personally identifiable information is redacted.
+	// On AWS, content is redacted natively. On other providers, content is redacted locally after the transcription
+	// (see RedactTranscript), which only supports some entity types.
 	// If undefined, content redaction is deactivated.
 	// See AWS docs: https://docs.aws.amazon.com/sdk-for-go/api/service/transcribeservice/#ContentRedaction
 	ContentRedactionConfig ContentRedactionConfig
@@ -35,28 +37,438 @@ type SpeechToTextOptions struct {
 	// with the corresponding Unicode symbols in the final transcript.
 	// See GCP docs: https://cloud.google.com/speech-to-text/docs/spoken-emoji
 	EnableSpokenEmojis bool
-	// ProfanityFilter is currently only available on GCP.
-	// From GCP docs:
-	// If set to 'true', the server will attempt to filter out
-	// profanities, replacing all but the initial character in each filtered word
-	// with asterisks, e.g. "f***". If set to `false` or omitted, profanities won't be filtered out.
+	// ProfanityFilter enables the filtering of profanities, as configured by ProfanityFilterConfig.
+	// On GCP, profanities are masked by replacing all but the initial character in each filtered word with asterisks,
+	// e.g. "f***". On AWS, a vocabulary filter is used. On other providers (or if the provider doesn't support the
+	// filter method), profanities are filtered locally after the transcription.
+	// If set to `false` or omitted, profanities won't be filtered out.
 	// See GCP docs: https://pkg.go.dev/cloud.google.com/go/speech@v1.15.0/apiv1/speechpb#RecognitionConfig
+	// See AWS docs: https://docs.aws.amazon.com/transcribe/latest/dg/vocabulary-filtering.html
 	ProfanityFilter bool
-	LanguageConfig  LanguageConfig
+	// ProfanityFilterConfig specifies how profanities are filtered if ProfanityFilter is true.
+	// If undefined, profanities are masked with the default word list (see DefaultProfanityWords).
+	ProfanityFilterConfig ProfanityFilterConfig
+	LanguageConfig        LanguageConfig
+	// MaxAlternatives specifies the maximum number of alternative transcriptions per segment.
+	// The alternatives are returned in the segments of the structured Transcript (see TranscriptSegment.Alternatives).
+	// On AWS, alternatives are only returned if MaxAlternatives is at least 2.
+	// If undefined (i.e. 0), only the most likely transcription is returned.
+	MaxAlternatives int32
+	// DiarizationConfig enables speaker diarization, i.e. labeling which speaker said which words.
+	// If enabled, the words and segments of the structured Transcript contain speaker labels
+	// (see TranscriptWord.Speaker and TranscriptSegment.Speaker), and segments are split whenever the speaker changes.
+	// If undefined, speaker diarization is deactivated.
+	// See AWS docs: https://docs.aws.amazon.com/transcribe/latest/dg/diarization.html
+	// See GCP docs: https://cloud.google.com/speech-to-text/v2/docs/multiple-voices
+	DiarizationConfig DiarizationConfig
+	// RecognitionMode is currently only used on GCP.
+	// It specifies if the synchronous Recognize method or the long-running BatchRecognize method is used.
+	// Synchronous recognition is limited to about one minute of audio (and 10 MB of inline content).
+	// If undefined (i.e. RecognitionModeAuto), BatchRecognize is used if the audio file is stored on Google Cloud Storage,
+	// or if its duration or size exceed the limits of synchronous recognition.
+	// In that case, local files and files from other URLs are temporarily uploaded to TempBucket.
+	// See GCP docs: https://cloud.google.com/speech-to-text/v2/docs/batch-recognize
+	RecognitionMode RecognitionMode
+	// RecognizerConfig is currently only used on GCP.
+	// It specifies the recognizer that is used for speech recognition. If a recognizer ID is specified, the recognizer
+	// is created with the given model and language if it doesn't exist yet. Existing recognizers are cached.
+	// If undefined, the implicit recognizer "_" is used, which is configured entirely by the request.
+	// See GCP docs: https://cloud.google.com/speech-to-text/v2/docs/recognizers
+	RecognizerConfig RecognizerConfig
+	// AudioFormat specifies the encoding, sample rate and channel count of the audio.
+	// It is required for raw (headerless) audio, which is currently only supported on GCP.
+	// On AWS, only the sample rate is used.
+	// If undefined, the audio format is detected automatically from the file header.
+	AudioFormat AudioFormat
+	// TranscodingConfig enables the local conversion of audio files that the provider doesn't support
+	// (e.g. raw audio on AWS) into a supported format before they are uploaded.
+	// If undefined, audio files are passed to the provider unchanged.
+	TranscodingConfig TranscodingConfig
+	// ChunkingConfig enables the transcription of long local files (and files from other URLs) in chunks, which are
+	// transcribed concurrently and merged into a single transcript. This allows transcribing audio of any length with
+	// synchronous recognition (e.g. on GCP, where synchronous recognition is limited to about one minute).
+	// Only audio that can be decoded locally (i.e. WAV files and raw audio, see RegisterAudioDecoder) is chunked.
+	// If undefined, files are transcribed as a whole.
+	ChunkingConfig ChunkingConfig
+	// VocabularyConfig specifies phrases (e.g. product names or medical terms) that are likely to occur in the audio,
+	// so that they are recognized more reliably. Phrases can be passed inline or as a named vocabulary that exists
+	// on the provider.
+	// If undefined, no custom vocabulary is used.
+	// See AWS docs: https://docs.aws.amazon.com/transcribe/latest/dg/custom-vocabulary.html
+	// See GCP docs: https://cloud.google.com/speech-to-text/v2/docs/adaptation-model
+	VocabularyConfig VocabularyConfig
 	// TranscriptionJobCheckIntervalMs When using S2TDirect on certain providers (like AWS), GoSpeech2Text needs to
 	// periodically check the status of the transcription job to figure out when the result is ready for download.
 	// TranscriptionJobCheckIntervalMs specifies the time interval in milliseconds in which the job status
 	// request should be executed.
 	// A lower value causes more requests, but also returns the result sooner.
 	// A higher value causes fewer requests, but returns the result later.
+	// TranscriptionJobCheckIntervalMs is the interval before the first check. After every check, the interval is
+	// multiplied by TranscriptionJobCheckIntervalMultiplier (exponential backoff), up to TranscriptionJobMaxCheckIntervalMs.
 	// Default value is 500ms.
 	TranscriptionJobCheckIntervalMs int64
+	// TranscriptionJobMaxCheckIntervalMs is the upper limit for the time interval between two job status checks.
+	// Default value is 10000ms.
+	TranscriptionJobMaxCheckIntervalMs int64
+	// TranscriptionJobCheckIntervalMultiplier is the factor by which the job status check interval is increased
+	// after every check. A value of 1 results in a constant interval.
+	// Default value is 1.5.
+	TranscriptionJobCheckIntervalMultiplier float64
+	// TranscriptionJobMaxWaitMs is the maximum total time in milliseconds that GoSpeech2Text waits for a transcription
+	// job to finish. If the job isn't done by then, ErrJobPollingTimeout is returned.
+	// Default value is 0, which means that there is no limit.
+	TranscriptionJobMaxWaitMs int64
+	// TranscriptionJobStatusCallback is called after every job status check (if it is not nil).
+	// It can be used to report the progress of long-running transcription jobs.
+	TranscriptionJobStatusCallback func(status JobPollStatus)
 	// DefaultTextFileExtension specifies the file extension (without preceding period) that should be used when
 	// GoSpeech2Text creates its own file URLs.
 	// For example: If user executes S2TDirect on AWS, the created text file needs to be stored on AWS S3 first, and
 	// subsequently downloaded and returned. For that, a temporary storage URL is created with DefaultTextFileExtension.
 	DefaultTextFileExtension string
 	TempBucket               string
+	// OutputFormat specifies the format of the file that S2T writes to the destination.
+	// Available formats are plain text (OutputFormatText), the structured Transcript as JSON (OutputFormatJson)
+	// and the subtitle formats SRT (OutputFormatSrt) and WebVTT (OutputFormatVtt).
+	// If undefined (i.e. OutputFormatUnspecified), the format is inferred from the file extension of the destination
+	// (e.g. "transcript.srt" or "transcript.txt"). If the file extension doesn't match any format, the provider writes
+	// its native result file (i.e. the AWS Transcribe JSON file on AWS and plain text on GCP).
+	// Note that formatting requires the transcript to be downloaded, so on AWS, S2T blocks until the transcription has
+	// finished and requires TempBucket if an output format is specified or inferred.
+	OutputFormat OutputFormat
+	// KeepTempTextFile specifies if temporary text files, which GoSpeech2Text creates on a storage service when
+	// executing S2TDirect on certain providers (like AWS), should be kept after their content has been downloaded.
+	// Default value is false, i.e. temporary text files are deleted.
+	KeepTempTextFile bool
+}
+
+// GetRequiredCapabilities returns the provider capabilities that are needed for the features enabled in the options.
+// If a required capability is missing on a provider, the corresponding feature has no effect on that provider.
+func (o SpeechToTextOptions) GetRequiredCapabilities() []providers.Capability {
+	var capabilities []providers.Capability
+	if strings.EqualFold(o.LanguageConfig.LanguageCode, "") {
+		capabilities = append(capabilities, providers.CapabilityLanguageIdentification)
+	}
+	if o.EnableAutomaticPunctuation {
+		capabilities = append(capabilities, providers.CapabilityAutomaticPunctuation)
+	}
+	if o.EnableSpokenPunctuation {
+		capabilities = append(capabilities, providers.CapabilitySpokenPunctuation)
+	}
+	if o.EnableSpokenEmojis {
+		capabilities = append(capabilities, providers.CapabilitySpokenEmojis)
+	}
+	if o.DiarizationConfig.Enabled {
+		capabilities = append(capabilities, providers.CapabilitySpeakerDiarization)
+	}
+	if o.AudioFormat.IsRaw() {
+		capabilities = append(capabilities, providers.CapabilityRawAudio)
+	}
+	return capabilities
+}
+
+// DiarizationConfig Configuration for speaker diarization.
+// This struct is an abstraction for the speaker label settings on AWS and the SpeakerDiarizationConfig on GCP.
+type DiarizationConfig struct {
+	_ struct{}
+	// Enabled specifies if speaker diarization is activated.
+	Enabled bool
+	// MinSpeakerCount is the minimum number of speakers in the audio.
+	// This property is ignored on AWS.
+	// If undefined (i.e. 0), DefaultMinSpeakerCount is used.
+	MinSpeakerCount int32
+	// MaxSpeakerCount is the maximum number of speakers in the audio.
+	// On AWS, the value must be between 2 and 30.
+	// If undefined (i.e. 0), DefaultMaxSpeakerCount is used.
+	MaxSpeakerCount int32
+}
+
+const (
+	DefaultMinSpeakerCount int32 = 2
+	DefaultMaxSpeakerCount int32 = 6
+)
+
+// GetSpeakerCountRange returns the minimum and maximum number of speakers, using the default values for
+// undefined properties.
+func (c DiarizationConfig) GetSpeakerCountRange() (int32, int32) {
+	minCount := c.MinSpeakerCount
+	if minCount <= 0 {
+		minCount = DefaultMinSpeakerCount
+	}
+	maxCount := c.MaxSpeakerCount
+	if maxCount <= 0 {
+		maxCount = DefaultMaxSpeakerCount
+	}
+	if maxCount < minCount {
+		maxCount = minCount
+	}
+	return minCount, maxCount
+}
+
+// AudioFormat Configuration of the audio format.
+// This struct is an abstraction for the ExplicitDecodingConfig on GCP and the media sample rate on AWS.
+type AudioFormat struct {
+	_ struct{}
+	// Encoding is the encoding of raw (headerless) audio.
+	// If undefined (i.e. AudioEncodingUnspecified), the audio must have a header (e.g. WAV or FLAC) from which the
+	// encoding is detected automatically.
+	Encoding AudioEncoding
+	// SampleRateHertz is the sample rate of the audio (e.g. 8000 for telephony audio).
+	// On GCP, it is only used (and required) for raw audio.
+	// If undefined (i.e. 0), the sample rate is detected automatically.
+	SampleRateHertz int32
+	// ChannelCount is the number of channels of raw audio. Channels are interleaved.
+	// This property is ignored on AWS.
+	// If undefined (i.e. 0), mono audio is assumed.
+	ChannelCount int32
+}
+
+type AudioEncoding string
+
+const (
+	AudioEncodingUnspecified AudioEncoding = ""
+	// AudioEncodingLinear16 is headerless 16-bit signed little-endian PCM.
+	AudioEncodingLinear16 AudioEncoding = "LINEAR16"
+	// AudioEncodingMulaw is headerless 8-bit G.711 mu-law.
+	AudioEncodingMulaw AudioEncoding = "MULAW"
+	// AudioEncodingAlaw is headerless 8-bit G.711 A-law.
+	AudioEncodingAlaw AudioEncoding = "ALAW"
+)
+
+func (f AudioFormat) IsEmpty() bool {
+	return f == AudioFormat{}
+}
+
+// IsRaw returns true if the audio is raw (headerless) audio, i.e. if an encoding is specified.
+func (f AudioFormat) IsRaw() bool {
+	return f.Encoding != AudioEncodingUnspecified
+}
+
+// GetChannelCount returns the number of channels, using 1 (mono) if the channel count is undefined.
+func (f AudioFormat) GetChannelCount() int32 {
+	if f.ChannelCount <= 0 {
+		return 1
+	}
+	return f.ChannelCount
+}
+
+// TranscodingConfig Configuration of the local audio conversion (see TranscodeAudio).
+// Local files and files from other URLs are converted if the provider doesn't support their file type or audio format,
+// or if a conversion (sample rate or channels) is requested. Files on the storage services of the providers are
+// never converted.
+// WAV files and raw audio can be decoded out of the box. Decoders for other formats can be added with
+// RegisterAudioDecoder. The target file type is the first file type with an encoder (see RegisterAudioEncoder) that
+// the provider supports.
+type TranscodingConfig struct {
+	_ struct{}
+	// Enabled specifies if audio files are converted.
+	Enabled bool
+	// SampleRateHertz is the sample rate of the converted audio. If it is set, audio files are always converted.
+	// If undefined (i.e. 0), the sample rate is kept.
+	SampleRateHertz int32
+	// ConvertToMono specifies if the channels are mixed into a single channel.
+	// If it is true, audio files are always converted.
+	ConvertToMono bool
+}
+
+// IsConversionRequested returns true if the audio should be converted regardless of its file type,
+// i.e. if a sample rate or mono audio is requested.
+func (c TranscodingConfig) IsConversionRequested() bool {
+	return c.Enabled && (c.SampleRateHertz > 0 || c.ConvertToMono)
+}
+
+// ChunkingConfig Configuration of the transcription in chunks (see SplitAudio and MergeChunkTranscripts).
+// Chunks overlap, so that words at the chunk boundaries aren't cut off. The end of every chunk is moved to the
+// quietest point near the end of the chunk, so that chunks preferably end in pauses between words.
+type ChunkingConfig struct {
+	_ struct{}
+	// Enabled specifies if files are transcribed in chunks.
+	Enabled bool
+	// ChunkDurationMs is the maximum duration of a chunk in milliseconds.
+	// Default value is DefaultChunkDurationMs.
+	ChunkDurationMs int64
+	// OverlapMs is the duration in milliseconds by which consecutive chunks overlap.
+	// It is limited to a quarter of the chunk duration.
+	// Default value is DefaultChunkOverlapMs.
+	OverlapMs int64
+	// MaxConcurrency is the maximum number of chunks that are transcribed at the same time.
+	// Default value is DefaultChunkMaxConcurrency.
+	MaxConcurrency int
+}
+
+const (
+	// DefaultChunkDurationMs is below the limit of synchronous recognition on GCP (one minute).
+	DefaultChunkDurationMs     int64 = 50000
+	DefaultChunkOverlapMs      int64 = 2000
+	DefaultChunkMaxConcurrency       = 4
+)
+
+// GetChunkDuration returns the maximum duration of a chunk, using the default value if it is undefined.
+func (c ChunkingConfig) GetChunkDuration() time.Duration {
+	if c.ChunkDurationMs <= 0 {
+		return time.Duration(DefaultChunkDurationMs) * time.Millisecond
+	}
+	return time.Duration(c.ChunkDurationMs) * time.Millisecond
+}
+
+// GetOverlap returns the overlap of consecutive chunks, using the default value if it is undefined.
+// The overlap is at most a quarter of the chunk duration.
+func (c ChunkingConfig) GetOverlap() time.Duration {
+	overlap := time.Duration(c.OverlapMs) * time.Millisecond
+	if c.OverlapMs <= 0 {
+		overlap = time.Duration(DefaultChunkOverlapMs) * time.Millisecond
+	}
+	if maxOverlap := c.GetChunkDuration() / 4; overlap > maxOverlap {
+		overlap = maxOverlap
+	}
+	return overlap
+}
+
+// GetMaxConcurrency returns the maximum number of concurrently transcribed chunks, using the default value if it is
+// undefined.
+func (c ChunkingConfig) GetMaxConcurrency() int {
+	if c.MaxConcurrency <= 0 {
+		return DefaultChunkMaxConcurrency
+	}
+	return c.MaxConcurrency
+}
+
+// VocabularyConfig Configuration of a custom vocabulary.
+// This struct is an abstraction for the inline PhraseSet and CustomClass adaptation on GCP and custom vocabularies
+// on AWS. Since AWS only supports named vocabularies, a custom vocabulary is created on AWS for inline phrases. It is
+// named after a hash of the language and phrases, so it is only created once and reused by all transcriptions with the
+// same phrases. Creating a vocabulary on AWS can take several minutes, which blocks the first transcription.
+type VocabularyConfig struct {
+	_ struct{}
+	// Phrases are words or short phrases that are likely to occur in the audio.
+	Phrases []VocabularyPhrase
+	// CustomClasses are groups of items (e.g. product names), which can be referenced in phrases as "${id}"
+	// (e.g. "order ${product}").
+	// On AWS, the references are replaced by every item of the class, and all items are added as phrases.
+	CustomClasses []VocabularyClass
+	// VocabularyName is the name of a vocabulary that has been created on the provider beforehand.
+	// On AWS, this is the name of a custom vocabulary. Inline phrases are ignored if a vocabulary name is set.
+	// On GCP, this is the resource name of a PhraseSet (e.g. "projects/my-project/locations/global/phraseSets/my-set"),
+	// which is used in addition to the inline phrases.
+	VocabularyName string
+}
+
+// VocabularyPhrase is a phrase of a custom vocabulary.
+type VocabularyPhrase struct {
+	_      struct{}
+	Phrase string
+	// Boost increases the probability that the phrase is recognized. Higher values also increase the number of
+	// false positives. On GCP, the value must be between 0 and 20.
+	// This property is ignored on AWS.
+	// If undefined (i.e. 0), the phrase isn't boosted.
+	Boost float32
+}
+
+// VocabularyClass is a group of items of a custom vocabulary (see VocabularyConfig.CustomClasses).
+type VocabularyClass struct {
+	_ struct{}
+	// Id is the ID with which the class is referenced in phrases (e.g. "product" for "${product}").
+	Id    string
+	Items []string
+}
+
+func (c VocabularyConfig) IsEmpty() bool {
+	return len(c.Phrases) == 0 && len(c.CustomClasses) == 0 && strings.EqualFold(c.VocabularyName, "")
+}
+
+// HasInlinePhrases returns true if the vocabulary contains inline phrases or custom classes.
+func (c VocabularyConfig) HasInlinePhrases() bool {
+	return len(c.Phrases) > 0 || len(c.CustomClasses) > 0
+}
+
+// GetExpandedPhrases returns the texts of all inline phrases, in which references to custom classes are replaced by
+// every item of the class, followed by the items of all custom classes. Duplicates are removed.
+// This is used for providers that don't support custom classes.
+func (c VocabularyConfig) GetExpandedPhrases() []string {
+	var expanded []string
+	seen := make(map[string]bool)
+	add := func(phrase string) {
+		phrase = strings.TrimSpace(phrase)
+		if !strings.EqualFold(phrase, "") && !seen[phrase] {
+			seen[phrase] = true
+			expanded = append(expanded, phrase)
+		}
+	}
+	for _, phrase := range c.Phrases {
+		texts := []string{phrase.Phrase}
+		for _, class := range c.CustomClasses {
+			reference := "${" + class.Id + "}"
+			var replaced []string
+			for _, text := range texts {
+				if !strings.Contains(text, reference) {
+					replaced = append(replaced, text)
+					continue
+				}
+				for _, item := range class.Items {
+					replaced = append(replaced, strings.ReplaceAll(text, reference, item))
+				}
+			}
+			texts = replaced
+		}
+		for _, text := range texts {
+			add(text)
+		}
+	}
+	for _, class := range c.CustomClasses {
+		for _, item := range class.Items {
+			add(item)
+		}
+	}
+	return expanded
+}
+
+type RecognitionMode string
+
+const (
+	// RecognitionModeAuto chooses the recognition method automatically (see SpeechToTextOptions.RecognitionMode).
+	RecognitionModeAuto RecognitionMode = ""
+	// RecognitionModeSync always uses synchronous recognition.
+	RecognitionModeSync RecognitionMode = "sync"
+	// RecognitionModeBatch always uses long-running batch recognition.
+	RecognitionModeBatch RecognitionMode = "batch"
+)
+
+// RecognizerConfig Configuration of a GCP Speech-to-Text recognizer.
+// Only used on GCP.
+type RecognizerConfig struct {
+	_ struct{}
+	// RecognizerId is the ID of the recognizer (e.g. "my-recognizer").
+	// If undefined (i.e. empty string), the implicit recognizer "_" is used, and Model and LanguageCode are passed
+	// with every request instead.
+	RecognizerId string
+	// ProjectId is the GCP project of the recognizer.
+	// If undefined (i.e. empty string), the project of the service client is used.
+	ProjectId string
+	// Location is the location of the recognizer (e.g. "europe-west4" or "global").
+	// The location must match the region of the service client, unless it is "global".
+	// If undefined (i.e. empty string), the location of the service client is used.
+	Location string
+	// Model is the model of the recognizer, which is set when the recognizer is created (or passed with every
+	// request, if the implicit recognizer is used).
+	// If undefined (i.e. empty string), DefaultRecognizerModel is used.
+	// See GCP docs: https://cloud.google.com/speech-to-text/v2/docs/transcription-model
+	Model string
+	// LanguageCode is the language of the recognizer, which is set when the recognizer is created (or passed with
+	// every request, if the implicit recognizer is used).
+	// If undefined (i.e. empty string), LanguageConfig.LanguageCode is used.
+	LanguageCode string
+}
+
+// DefaultRecognizerModel is the model of recognizers that GoSpeech2Text creates, if no model is specified.
+const DefaultRecognizerModel = "long"
+
+// IsEmpty returns true if none of the properties of the recognizer config are defined.
+func (c RecognizerConfig) IsEmpty() bool {
+	return strings.EqualFold(c.RecognizerId, "") &&
+		strings.EqualFold(c.ProjectId, "") &&
+		strings.EqualFold(c.Location, "") &&
+		strings.EqualFold(c.Model, "") &&
+		strings.EqualFold(c.LanguageCode, "")
 }
 
 type LanguageConfig struct {
@@ -90,8 +502,7 @@ type LanguageConfig struct {
 
 // ContentRedactionConfig Configuration for content redaction.
 // This struct is an abstraction for the ContentRedaction struct in AWS Go SDK
-// (and for a possible configuration struct for GCP, in the future).
-// Currently only available on AWS.
+// and for the local redaction engine, which is used on all other providers (see RedactTranscript).
 // See AWS docs: https://docs.aws.amazon.com/sdk-for-go/api/service/transcribeservice/#ContentRedaction
 type ContentRedactionConfig struct {
 	_ struct{}
@@ -180,6 +591,20 @@ func (jobNameConfig TranscriptionJobNameConfig) GetTranscriptionJobName() string
 	}
 }
 
+// WithIndex returns a copy of the config whose job names are unique for the given index, so that the transcription
+// jobs of multiple items (e.g. the chunks of a file or the items of a batch) that are started with the same options
+// don't have the same name. A fixed job name (i.e. without timestamp) gets the suffix "-{index}", e.g. "job-2".
+// Otherwise, the index is inserted before the timestamp, e.g. "s2t-2-{timestamp}".
+func (jobNameConfig TranscriptionJobNameConfig) WithIndex(index int) TranscriptionJobNameConfig {
+	if !strings.EqualFold(jobNameConfig.TranscriptionJobName, "") && !jobNameConfig.AppendCurrentTimestamp {
+		jobNameConfig.TranscriptionJobName += "-" + strconv.Itoa(index)
+	} else {
+		jobNameConfig.TranscriptionJobName += strconv.Itoa(index) + "-"
+		jobNameConfig.AppendCurrentTimestamp = true
+	}
+	return jobNameConfig
+}
+
 func GetDefaultSpeechToTextOptions() *SpeechToTextOptions {
 	return &SpeechToTextOptions{
 		Provider: providers.ProviderAWS,
@@ -197,8 +622,12 @@ func GetDefaultSpeechToTextOptions() *SpeechToTextOptions {
 			IdentifyMultipleLanguages: false,
 			LanguageOptions:           nil,
 		},
-		TranscriptionJobCheckIntervalMs: 500,
-		DefaultTextFileExtension:        "txt",
+		TranscriptionJobCheckIntervalMs:         defaultJobCheckIntervalMs,
+		TranscriptionJobMaxCheckIntervalMs:      defaultJobMaxCheckIntervalMs,
+		TranscriptionJobCheckIntervalMultiplier: defaultJobCheckMultiplier,
+		TranscriptionJobMaxWaitMs:               0,
+		DefaultTextFileExtension:                "txt",
+		KeepTempTextFile:                        false,
 	}
 }
 
@@ -0,0 +1,149 @@
+package shared
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type OutputFormat string
+
+const (
+	// OutputFormatUnspecified means that the format is inferred from the destination file extension.
+	// If that isn't possible, the provider writes its native result file (see SpeechToTextOptions.OutputFormat).
+	OutputFormatUnspecified OutputFormat = ""
+	// OutputFormatText is the plain transcribed text.
+	OutputFormatText OutputFormat = "txt"
+	// OutputFormatJson is the structured Transcript serialized to JSON.
+	OutputFormatJson OutputFormat = "json"
+	// OutputFormatSrt is the SubRip subtitle format.
+	OutputFormatSrt OutputFormat = "srt"
+	// OutputFormatVtt is the WebVTT subtitle format.
+	OutputFormatVtt OutputFormat = "vtt"
+)
+
+var allOutputFormats = []OutputFormat{OutputFormatText, OutputFormatJson, OutputFormatSrt, OutputFormatVtt}
+
+const (
+	// subtitleCueMaxDuration is the maximum duration of a single subtitle cue.
+	subtitleCueMaxDuration = 7 * time.Second
+	// subtitleCueMaxLength is the maximum number of characters of a single subtitle cue (i.e. two lines of 42 characters).
+	subtitleCueMaxLength = 84
+)
+
+// ResolveOutputFormat returns the output format specified in the given options. If no format is specified,
+// the format is inferred from the file extension of the given destination.
+// If the file extension doesn't match any output format, OutputFormatUnspecified is returned.
+func ResolveOutputFormat(options SpeechToTextOptions, destination string) OutputFormat {
+	if options.OutputFormat != OutputFormatUnspecified {
+		return options.OutputFormat
+	}
+	fileType := GetFileTypeFromFileName(destination)
+	for _, format := range allOutputFormats {
+		if strings.EqualFold(fileType, string(format)) {
+			return format
+		}
+	}
+	return OutputFormatUnspecified
+}
+
+// FormatTranscript converts the given transcript into the given output format.
+// Subtitle formats (SRT and WebVTT) require segment timestamps. Segments are split into multiple subtitle cues
+// if they are too long and word timestamps are available.
+func FormatTranscript(transcript Transcript, format OutputFormat) ([]byte, error) {
+	switch format {
+	case OutputFormatText, OutputFormatUnspecified:
+		return []byte(transcript.Text), nil
+	case OutputFormatJson:
+		return json.MarshalIndent(transcript, "", "  ")
+	case OutputFormatSrt:
+		var builder strings.Builder
+		for i, cue := range getSubtitleCues(transcript) {
+			builder.WriteString(fmt.Sprintf("%d\n%s --> %s\n%s\n\n", i+1, formatSubtitleTime(cue.StartTime, ","), formatSubtitleTime(cue.EndTime, ","), cue.Text))
+		}
+		return []byte(builder.String()), nil
+	case OutputFormatVtt:
+		var builder strings.Builder
+		builder.WriteString("WEBVTT\n\n")
+		for _, cue := range getSubtitleCues(transcript) {
+			builder.WriteString(fmt.Sprintf("%s --> %s\n%s\n\n", formatSubtitleTime(cue.StartTime, "."), formatSubtitleTime(cue.EndTime, "."), cue.Text))
+		}
+		return []byte(builder.String()), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("The output format '%s' is not supported.", format))
+	}
+}
+
+// subtitleCue is a single subtitle that is displayed from StartTime to EndTime.
+type subtitleCue struct {
+	Text      string
+	StartTime time.Duration
+	EndTime   time.Duration
+}
+
+// getSubtitleCues splits the segments of the given transcript into subtitle cues.
+// Segments without words become a single cue. Segments with words are split into cues that don't exceed
+// subtitleCueMaxDuration and subtitleCueMaxLength.
+func getSubtitleCues(transcript Transcript) []subtitleCue {
+	var cues []subtitleCue
+	for _, segment := range transcript.Segments {
+		if len(segment.Words) < 1 {
+			text := strings.TrimSpace(segment.Text)
+			if text != "" {
+				cues = append(cues, subtitleCue{Text: text, StartTime: segment.StartTime, EndTime: segment.EndTime})
+			}
+			continue
+		}
+
+		var cueWords []TranscriptWord
+		var cueStart time.Duration
+		for _, word := range segment.Words {
+			if len(cueWords) > 0 && !word.IsPunctuation {
+				tooLong := len(JoinWords(cueWords))+1+len(word.Text) > subtitleCueMaxLength
+				tooLate := word.EndTime-cueStart > subtitleCueMaxDuration
+				if tooLong || tooLate {
+					cues = append(cues, createSubtitleCue(cueWords, cueStart))
+					cueWords = nil
+				}
+			}
+			if len(cueWords) == 0 {
+				cueStart = word.StartTime
+			}
+			cueWords = append(cueWords, word)
+		}
+		if len(cueWords) > 0 {
+			cues = append(cues, createSubtitleCue(cueWords, cueStart))
+		}
+	}
+	return cues
+}
+
+// createSubtitleCue creates a subtitle cue from the given words. The cue ends with the last word that has a timestamp.
+func createSubtitleCue(words []TranscriptWord, startTime time.Duration) subtitleCue {
+	endTime := startTime
+	for _, word := range words {
+		if word.EndTime > endTime {
+			endTime = word.EndTime
+		}
+	}
+	return subtitleCue{
+		Text:      JoinWords(words),
+		StartTime: startTime,
+		EndTime:   endTime,
+	}
+}
+
+// formatSubtitleTime formats the given time offset as "hh:mm:ss<sep>mmm", as used in SRT (separator ",") and
+// WebVTT (separator ".").
+func formatSubtitleTime(offset time.Duration, millisecondSeparator string) string {
+	if offset < 0 {
+		offset = 0
+	}
+	hours := offset / time.Hour
+	minutes := (offset % time.Hour) / time.Minute
+	seconds := (offset % time.Minute) / time.Second
+	milliseconds := (offset % time.Second) / time.Millisecond
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", hours, minutes, seconds, millisecondSeparator, milliseconds)
+}
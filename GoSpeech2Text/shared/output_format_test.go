@@ -0,0 +1,86 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func getTestTranscript() Transcript {
+	return Transcript{
+		Text: "Hello world. How are you?",
+		Segments: []TranscriptSegment{
+			{
+				Text:      "Hello world.",
+				StartTime: 100 * time.Millisecond,
+				EndTime:   900 * time.Millisecond,
+				Words: []TranscriptWord{
+					{Text: "Hello", StartTime: 100 * time.Millisecond, EndTime: 500 * time.Millisecond},
+					{Text: "world", StartTime: 500 * time.Millisecond, EndTime: 900 * time.Millisecond},
+					{Text: ".", IsPunctuation: true},
+				},
+			},
+			{
+				Text:      "How are you?",
+				StartTime: 61200 * time.Millisecond,
+				EndTime:   61900 * time.Millisecond,
+			},
+		},
+	}
+}
+
+func TestFormatTranscriptSrt(t *testing.T) {
+	content, err := FormatTranscript(getTestTranscript(), OutputFormatSrt)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected := "1\n00:00:00,100 --> 00:00:00,900\nHello world.\n\n2\n00:01:01,200 --> 00:01:01,900\nHow are you?\n\n"
+	if string(content) != expected {
+		t.Error("wrong SRT: Got ", string(content))
+	}
+}
+
+func TestFormatTranscriptVtt(t *testing.T) {
+	content, err := FormatTranscript(getTestTranscript(), OutputFormatVtt)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected := "WEBVTT\n\n00:00:00.100 --> 00:00:00.900\nHello world.\n\n00:01:01.200 --> 00:01:01.900\nHow are you?\n\n"
+	if string(content) != expected {
+		t.Error("wrong WebVTT: Got ", string(content))
+	}
+}
+
+func TestFormatTranscriptLongSegment(t *testing.T) {
+	var words []TranscriptWord
+	for i := 0; i < 20; i++ {
+		words = append(words, TranscriptWord{Text: "word", StartTime: time.Duration(i) * time.Second, EndTime: time.Duration(i+1) * time.Second})
+	}
+	transcript := Transcript{Segments: []TranscriptSegment{{Words: words, EndTime: 20 * time.Second}}}
+	cues := getSubtitleCues(transcript)
+	if len(cues) != 3 {
+		t.Fatal("wrong number of cues: Got ", len(cues))
+	}
+	if cues[1].StartTime != 7*time.Second || cues[1].EndTime != 14*time.Second {
+		t.Error("wrong cue: Got ", cues[1])
+	}
+}
+
+func TestResolveOutputFormat(t *testing.T) {
+	if ResolveOutputFormat(SpeechToTextOptions{}, "s3://bucket/subtitles.SRT") != OutputFormatSrt {
+		t.Error("expected SRT format from file extension")
+	}
+	if ResolveOutputFormat(SpeechToTextOptions{}, "s3://bucket/transcript") != OutputFormatUnspecified {
+		t.Error("expected unspecified format for destination without file extension")
+	}
+	if ResolveOutputFormat(SpeechToTextOptions{}, "s3://bucket/transcript.txt") != OutputFormatText {
+		t.Error("expected text format to be inferred from file extension")
+	}
+	if ResolveOutputFormat(SpeechToTextOptions{OutputFormat: OutputFormatJson}, "s3://bucket/subtitles.vtt") != OutputFormatJson {
+		t.Error("expected explicitly specified format")
+	}
+	content, _ := FormatTranscript(getTestTranscript(), OutputFormatJson)
+	if !strings.Contains(string(content), `"text": "Hello world. How are you?"`) {
+		t.Error("wrong JSON: Got ", string(content))
+	}
+}
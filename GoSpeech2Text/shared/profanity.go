@@ -0,0 +1,208 @@
+package shared
+
+import (
+	_ "embed"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
+	"strings"
+	"unicode"
+)
+
+// ProfanityFilterMethod specifies how profanities are filtered (see ProfanityFilterConfig).
+type ProfanityFilterMethod string
+
+const (
+	// ProfanityFilterMethodMask replaces all but the initial character of profanities with asterisks (e.g. "f***").
+	// On AWS, the whole word is replaced by "***".
+	ProfanityFilterMethodMask ProfanityFilterMethod = "mask"
+	// ProfanityFilterMethodRemove removes profanities from the transcript.
+	ProfanityFilterMethodRemove ProfanityFilterMethod = "remove"
+	// ProfanityFilterMethodTag keeps profanities in the transcript, but marks the words of the structured
+	// Transcript (see TranscriptWord.IsProfanity).
+	ProfanityFilterMethodTag ProfanityFilterMethod = "tag"
+)
+
+// GetCapability returns the provider capability that is needed to filter profanities natively with the method.
+func (m ProfanityFilterMethod) GetCapability() providers.Capability {
+	switch m {
+	case ProfanityFilterMethodRemove:
+		return providers.CapabilityProfanityFilterRemove
+	case ProfanityFilterMethodTag:
+		return providers.CapabilityProfanityFilterTag
+	default:
+		return providers.CapabilityProfanityFilter
+	}
+}
+
+// ProfanityFilterConfig configures the profanity filter, which is enabled by SpeechToTextOptions.ProfanityFilter.
+// Profanities are filtered natively by the provider if it supports the method (see ProfanityFilterMethod.GetCapability).
+// Otherwise, they are filtered locally after the transcription (see FilterProfanity and FilterTranscriptProfanity).
+type ProfanityFilterConfig struct {
+	_ struct{}
+	// Method specifies how profanities are filtered.
+	// Default value is ProfanityFilterMethodMask.
+	Method ProfanityFilterMethod
+	// Words is the list of words that are filtered. It is used by the local filter and for AWS vocabulary filters.
+	// GCP always uses its own list of profanities.
+	// If undefined, the bundled default word list is used (see DefaultProfanityWords).
+	Words []string
+	// VocabularyFilterName is the name of an existing AWS Transcribe vocabulary filter that is used instead of Words.
+	// If undefined, a vocabulary filter with Words is created on AWS if it doesn't exist yet, and reused afterwards.
+	// See AWS docs: https://docs.aws.amazon.com/transcribe/latest/dg/vocabulary-filtering.html
+	VocabularyFilterName string
+	// LocalOnly specifies that profanities are always filtered locally, even if the provider supports the method.
+	// This makes the result independent of the provider.
+	LocalOnly bool
+}
+
+// GetMethod returns the profanity filter method, using the default value if it is undefined.
+func (c ProfanityFilterConfig) GetMethod() ProfanityFilterMethod {
+	if strings.EqualFold(string(c.Method), "") {
+		return ProfanityFilterMethodMask
+	}
+	return c.Method
+}
+
+// GetWords returns the filtered words, using the default word list if they are undefined.
+func (c ProfanityFilterConfig) GetWords() []string {
+	if len(c.Words) < 1 {
+		return DefaultProfanityWords()
+	}
+	return c.Words
+}
+
+//go:embed profanity_words.txt
+var defaultProfanityWordsFile string
+
+// DefaultProfanityWords returns the bundled default word list of the profanity filter, which contains common English
+// profanities.
+func DefaultProfanityWords() []string {
+	var words []string
+	for _, line := range strings.Split(defaultProfanityWordsFile, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.EqualFold(line, "") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words
+}
+
+// getProfanitySet returns the filtered words of the config in lowercase.
+func (c ProfanityFilterConfig) getProfanitySet() map[string]bool {
+	profanities := make(map[string]bool)
+	for _, word := range c.GetWords() {
+		profanities[strings.ToLower(strings.TrimSpace(word))] = true
+	}
+	return profanities
+}
+
+// FilterProfanity filters the profanities in the given text locally, as configured by the given config.
+// Profanities are only matched as whole words (case-insensitively). When a profanity is removed, the whitespace in
+// front of it is removed as well. With ProfanityFilterMethodTag, the text is returned unchanged.
+func FilterProfanity(text string, config ProfanityFilterConfig) string {
+	method := config.GetMethod()
+	if method == ProfanityFilterMethodTag {
+		return text
+	}
+	profanities := config.getProfanitySet()
+
+	var builder strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !isProfanityWordRune(runes[i]) {
+			builder.WriteRune(runes[i])
+			i++
+			continue
+		}
+		end := i
+		for end < len(runes) && isProfanityWordRune(runes[end]) {
+			end++
+		}
+		word := string(runes[i:end])
+		switch {
+		case !profanities[strings.ToLower(word)]:
+			builder.WriteString(word)
+		case method == ProfanityFilterMethodRemove:
+			if trimmed := strings.TrimRight(builder.String(), " \t"); len(trimmed) < builder.Len() || end >= len(runes) {
+				builder.Reset()
+				builder.WriteString(trimmed)
+			} else {
+				// the profanity is at the beginning of a line -> remove the following whitespace instead
+				for end < len(runes) && (runes[end] == ' ' || runes[end] == '\t') {
+					end++
+				}
+			}
+		default:
+			builder.WriteString(maskProfanity(word))
+		}
+		i = end
+	}
+	return builder.String()
+}
+
+// FilterTranscriptProfanity filters the profanities in the texts and words of the given transcript locally, as
+// configured by the given config (see FilterProfanity). With ProfanityFilterMethodTag, the words are marked instead
+// (see TranscriptWord.IsProfanity).
+func FilterTranscriptProfanity(transcript Transcript, config ProfanityFilterConfig) Transcript {
+	transcript.Text = FilterProfanity(transcript.Text, config)
+	segments := make([]TranscriptSegment, len(transcript.Segments))
+	for i, segment := range transcript.Segments {
+		segment.Text = FilterProfanity(segment.Text, config)
+		segment.Words = filterWordsProfanity(segment.Words, config)
+		alternatives := make([]TranscriptAlternative, len(segment.Alternatives))
+		for j, alternative := range segment.Alternatives {
+			alternative.Text = FilterProfanity(alternative.Text, config)
+			alternative.Words = filterWordsProfanity(alternative.Words, config)
+			alternatives[j] = alternative
+		}
+		if segment.Alternatives != nil {
+			segment.Alternatives = alternatives
+		}
+		segments[i] = segment
+	}
+	if transcript.Segments != nil {
+		transcript.Segments = segments
+	}
+	return transcript
+}
+
+// filterWordsProfanity filters the given words, as configured by the given config, and returns the filtered words.
+func filterWordsProfanity(words []TranscriptWord, config ProfanityFilterConfig) []TranscriptWord {
+	if words == nil {
+		return nil
+	}
+	method := config.GetMethod()
+	profanities := config.getProfanitySet()
+	filtered := make([]TranscriptWord, 0, len(words))
+	for _, word := range words {
+		if word.IsPunctuation || !profanities[strings.ToLower(strings.TrimFunc(word.Text, isNotProfanityWordRune))] {
+			filtered = append(filtered, word)
+			continue
+		}
+		switch method {
+		case ProfanityFilterMethodRemove:
+			continue
+		case ProfanityFilterMethodTag:
+			word.IsProfanity = true
+		default:
+			word.Text = FilterProfanity(word.Text, config)
+		}
+		filtered = append(filtered, word)
+	}
+	return filtered
+}
+
+// maskProfanity replaces all but the initial character of the given word with asterisks.
+func maskProfanity(word string) string {
+	runes := []rune(word)
+	return string(runes[0]) + strings.Repeat("*", len(runes)-1)
+}
+
+// isProfanityWordRune checks if the given rune is part of a word (i.e. a letter, digit or apostrophe).
+func isProfanityWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\''
+}
+
+func isNotProfanityWordRune(r rune) bool {
+	return !isProfanityWordRune(r)
+}
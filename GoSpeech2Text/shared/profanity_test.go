@@ -0,0 +1,68 @@
+package shared
+
+import (
+	"testing"
+)
+
+func TestFilterProfanity(t *testing.T) {
+	config := ProfanityFilterConfig{Words: []string{"darn", "heck"}}
+	if text := FilterProfanity("Darn it, what the heck.", config); text != "D*** it, what the h***." {
+		t.Error("wrong masked text: Got ", text)
+	}
+	if text := FilterProfanity("darnation", config); text != "darnation" {
+		t.Error("expected only whole words to be filtered: Got ", text)
+	}
+
+	config.Method = ProfanityFilterMethodRemove
+	if text := FilterProfanity("Darn it, what the heck.", config); text != "it, what the." {
+		t.Error("wrong text with removed profanities: Got ", text)
+	}
+
+	config.Method = ProfanityFilterMethodTag
+	if text := FilterProfanity("Darn it", config); text != "Darn it" {
+		t.Error("expected tagged text to be unchanged: Got ", text)
+	}
+}
+
+func TestFilterTranscriptProfanity(t *testing.T) {
+	transcript := Transcript{
+		Text: "oh heck.",
+		Segments: []TranscriptSegment{{
+			Text: "oh heck.",
+			Words: []TranscriptWord{
+				{Text: "oh"},
+				{Text: "heck"},
+				{Text: ".", IsPunctuation: true},
+			},
+		}},
+	}
+	config := ProfanityFilterConfig{Words: []string{"heck"}, Method: ProfanityFilterMethodRemove}
+	filtered := FilterTranscriptProfanity(transcript, config)
+	if filtered.Text != "oh." || filtered.Segments[0].Text != "oh." || len(filtered.Segments[0].Words) != 2 {
+		t.Error("wrong filtered transcript: Got ", filtered)
+	}
+	if transcript.Segments[0].Words[1].Text != "heck" {
+		t.Error("expected original transcript to be unchanged: Got ", transcript)
+	}
+
+	config.Method = ProfanityFilterMethodTag
+	filtered = FilterTranscriptProfanity(transcript, config)
+	if filtered.Text != "oh heck." || filtered.Segments[0].Words[0].IsProfanity || !filtered.Segments[0].Words[1].IsProfanity {
+		t.Error("wrong tagged transcript: Got ", filtered)
+	}
+}
+
+func TestDefaultProfanityWords(t *testing.T) {
+	words := DefaultProfanityWords()
+	if len(words) < 1 {
+		t.Fatal("expected default profanity words")
+	}
+	for _, word := range words {
+		if word[0] == '#' {
+			t.Error("expected comments to be skipped: Got ", word)
+		}
+	}
+	if len(ProfanityFilterConfig{}.GetWords()) != len(words) {
+		t.Error("expected default words for undefined words")
+	}
+}
@@ -0,0 +1,434 @@
+package shared
+
+import (
+	"math/big"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// RedactionPlaceholder replaces redacted entities in redacted transcripts (like on AWS).
+const RedactionPlaceholder = "[PII]"
+
+// UnredactedFileSuffix is inserted before the file extension of the destination of a redacted transcript, to get the
+// destination of the unredacted transcript (see GetUnredactedDestination).
+const UnredactedFileSuffix = ".unredacted"
+
+// GetUnredactedDestination returns the destination at which the unredacted transcript is stored, if content is
+// redacted with RedactionOutputRedactedAndUnredacted and the redacted transcript is stored at the given destination.
+// UnredactedFileSuffix is inserted before the file extension (e.g. "s3://bucket/transcript.json" becomes
+// "s3://bucket/transcript.unredacted.json"). If the file name has no extension, the suffix is appended.
+func GetUnredactedDestination(destination string) string {
+	extension := path.Ext(destination)
+	return strings.TrimSuffix(destination, extension) + UnredactedFileSuffix + extension
+}
+
+// TranscriptEntity is an entity (i.e. personally identifiable information) that has been found in a transcript
+// (see ContentRedactionConfig).
+type TranscriptEntity struct {
+	// Type is the type of the entity (e.g. RedactionEntityEmail).
+	Type RedactionEntityType `json:"type"`
+	// StartTime is the offset of the beginning of the entity relative to the beginning of the audio.
+	StartTime time.Duration `json:"startTime"`
+	// EndTime is the offset of the end of the entity relative to the beginning of the audio.
+	EndTime time.Duration `json:"endTime"`
+}
+
+// GetEntityTypes returns the entity types that are redacted, where RedactionEntityAll is expanded into all types
+// that are supported by the local redaction engine. Duplicates are removed.
+func (a ContentRedactionConfig) GetEntityTypes() []RedactionEntityType {
+	var entityTypes []RedactionEntityType
+	seen := make(map[RedactionEntityType]bool)
+	for _, entityType := range a.RedactionEntityTypes {
+		if entityType == nil {
+			continue
+		}
+		expanded := []RedactionEntityType{*entityType}
+		if *entityType == RedactionEntityAll {
+			expanded = localRedactionEntityTypes
+		}
+		for _, e := range expanded {
+			if !seen[e] {
+				seen[e] = true
+				entityTypes = append(entityTypes, e)
+			}
+		}
+	}
+	return entityTypes
+}
+
+// localRedactionEntityTypes are the entity types that can be detected by the local redaction engine, in the order in
+// which they are detected. Entities that overlap an entity that has already been detected are ignored.
+var localRedactionEntityTypes = []RedactionEntityType{
+	RedactionEntityEmail,
+	RedactionEntityBankAccountNumber,
+	RedactionEntityCreditDebitNumber,
+	RedactionEntitySsn,
+	// PINs are detected before phone numbers, since PINs with 7 or 8 digits look like phone numbers
+	RedactionEntityPin,
+	RedactionEntityPhone,
+}
+
+// entityDetector finds the entities of a single type in a text. It returns the byte offsets of the entities.
+type entityDetector func(text string) [][2]int
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// ibanStartPattern matches the country code and check digits at the beginning of IBANs.
+	ibanStartPattern = regexp.MustCompile(`\b[A-Z]{2}\d{2}`)
+	// numberPattern matches digit sequences that may be separated by single spaces or hyphens.
+	numberPattern = regexp.MustCompile(`\d(?:[ -]?\d)*`)
+	ssnPattern    = regexp.MustCompile(`\b(\d{3})[- ]?(\d{2})[- ]?(\d{4})\b`)
+	phonePattern  = regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)|\b\d{2,4})(?:[ .-]?\d{2,4}){2,3}\b`)
+	// phoneContextPattern matches words that announce a phone number (e.g. "call me at 5551234567").
+	phoneContextPattern = regexp.MustCompile(`(?i)\b(?:phone|telephone|tel|call|mobile|cell|fax|dial|reach|number)\b`)
+	isoDatePattern      = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	pinPattern          = regexp.MustCompile(`(?i)\bpin(?:[ -]?(?:code|number))?(?:\s+(?:is|was))?\s*:?\s*(\d(?:[ -]?\d){3,7})\b`)
+)
+
+// entityDetectors contains the detectors of the local redaction engine.
+var entityDetectors = map[RedactionEntityType]entityDetector{
+	RedactionEntityEmail: func(text string) [][2]int {
+		return toSpans(emailPattern.FindAllStringIndex(text, -1))
+	},
+	RedactionEntityBankAccountNumber: findIbans,
+	RedactionEntityCreditDebitNumber: func(text string) [][2]int {
+		return filterSpans(text, numberPattern.FindAllStringIndex(text, -1), func(number string) bool {
+			digits := getDigits(number)
+			return len(digits) >= 13 && len(digits) <= 19 && isValidLuhn(digits)
+		})
+	},
+	RedactionEntitySsn: func(text string) [][2]int {
+		var spans [][2]int
+		for _, match := range ssnPattern.FindAllStringSubmatchIndex(text, -1) {
+			area, group, serial := text[match[2]:match[3]], text[match[4]:match[5]], text[match[6]:match[7]]
+			if area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000" {
+				spans = append(spans, [2]int{match[0], match[1]})
+			}
+		}
+		return spans
+	},
+	RedactionEntityPhone: func(text string) [][2]int {
+		var spans [][2]int
+		for _, match := range phonePattern.FindAllStringIndex(text, -1) {
+			if isPhoneNumber(text[match[0]:match[1]], text[:match[0]]) {
+				spans = append(spans, [2]int{match[0], match[1]})
+			}
+		}
+		return spans
+	},
+	RedactionEntityPin: func(text string) [][2]int {
+		var spans [][2]int
+		for _, match := range pinPattern.FindAllStringSubmatchIndex(text, -1) {
+			spans = append(spans, [2]int{match[2], match[3]})
+		}
+		return spans
+	},
+}
+
+// detectedEntity is an entity that has been found at the given byte offsets of a text.
+type detectedEntity struct {
+	entityType RedactionEntityType
+	start      int
+	end        int
+}
+
+// detectEntities finds the entities of the given types in the given text with the local redaction engine and returns
+// their byte offsets in the text (ordered by offset). Entity types that aren't supported by the local redaction
+// engine (e.g. RedactionEntityName and RedactionEntityAddress) are ignored.
+// The engine uses patterns and checksums: credit card numbers must pass the Luhn check, IBANs the mod-97 check,
+// phone numbers must look like phone numbers or be announced (see isPhoneNumber), and PINs must follow the word "PIN"
+// (e.g. "my PIN is 1234").
+func detectEntities(text string, entityTypes []RedactionEntityType) []detectedEntity {
+	var entities []detectedEntity
+	for _, entityType := range localRedactionEntityTypes {
+		if !containsEntityType(entityTypes, entityType) {
+			continue
+		}
+		for _, span := range entityDetectors[entityType](text) {
+			overlaps := false
+			for _, entity := range entities {
+				if span[0] < entity.end && entity.start < span[1] {
+					overlaps = true
+					break
+				}
+			}
+			if !overlaps {
+				entities = append(entities, detectedEntity{entityType: entityType, start: span[0], end: span[1]})
+			}
+		}
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].start < entities[j].start
+	})
+	return entities
+}
+
+// RedactText replaces the entities of the types of the given config in the given text with RedactionPlaceholder,
+// using the local redaction engine (see RedactTranscript).
+func RedactText(text string, config ContentRedactionConfig) string {
+	entities := detectEntities(text, config.GetEntityTypes())
+	var builder strings.Builder
+	offset := 0
+	for _, entity := range entities {
+		builder.WriteString(text[offset:entity.start])
+		builder.WriteString(RedactionPlaceholder)
+		offset = entity.end
+	}
+	builder.WriteString(text[offset:])
+	return builder.String()
+}
+
+// RedactTranscript redacts the entities of the types of the given config in the given transcript locally, and marks
+// their time spans in Transcript.Entities. In the words of the transcript, every entity is replaced by a single word
+// RedactionPlaceholder that spans the time of the entity (see TranscriptWord.IsRedacted). The texts are redacted
+// with RedactText.
+// The local redaction engine supports emails, IBANs (as RedactionEntityBankAccountNumber), credit card numbers, SSNs,
+// phone numbers and PINs. Other entity types are ignored.
+// If RedactionOutputRedactedAndUnredacted is specified in the config, the unredacted transcript (with the same
+// entities) is returned as well. Otherwise, the returned unredacted transcript is nil.
+func RedactTranscript(transcript Transcript, config ContentRedactionConfig) (Transcript, *Transcript) {
+	entityTypes := config.GetEntityTypes()
+	redacted := transcript
+	redacted.Text = RedactText(transcript.Text, config)
+	redacted.Entities = nil
+	redacted.Segments = nil
+	for _, segment := range transcript.Segments {
+		var entities []TranscriptEntity
+		if segment.Words != nil {
+			segment.Words, entities = redactWords(segment.Words, entityTypes)
+		} else {
+			// the segment has no words -> the whole segment is the time span of its entities
+			for _, entity := range detectEntities(segment.Text, entityTypes) {
+				entities = append(entities, TranscriptEntity{Type: entity.entityType, StartTime: segment.StartTime, EndTime: segment.EndTime})
+			}
+		}
+		segment.Text = RedactText(segment.Text, config)
+		var alternatives []TranscriptAlternative
+		for _, alternative := range segment.Alternatives {
+			alternative.Text = RedactText(alternative.Text, config)
+			alternative.Words, _ = redactWords(alternative.Words, entityTypes)
+			alternatives = append(alternatives, alternative)
+		}
+		segment.Alternatives = alternatives
+		redacted.Segments = append(redacted.Segments, segment)
+		redacted.Entities = append(redacted.Entities, entities...)
+	}
+
+	if config.RedactionOutput != RedactionOutputRedactedAndUnredacted {
+		return redacted, nil
+	}
+	unredacted := transcript
+	unredacted.Entities = redacted.Entities
+	return redacted, &unredacted
+}
+
+// redactWords detects the entities of the given types in the given words, and replaces the words of every entity by a
+// single RedactionPlaceholder word. The redacted words and the entities are returned.
+func redactWords(words []TranscriptWord, entityTypes []RedactionEntityType) ([]TranscriptWord, []TranscriptEntity) {
+	if words == nil {
+		return nil, nil
+	}
+	// the text of the words and the byte offset of every word in the text
+	var builder strings.Builder
+	offsets := make([]int, len(words))
+	for i, word := range words {
+		if builder.Len() > 0 && !word.IsPunctuation {
+			builder.WriteString(" ")
+		}
+		offsets[i] = builder.Len()
+		builder.WriteString(word.Text)
+	}
+
+	var redacted []TranscriptWord
+	var entities []TranscriptEntity
+	i := 0
+	for _, entity := range detectEntities(builder.String(), entityTypes) {
+		for i < len(words) && offsets[i]+len(words[i].Text) <= entity.start {
+			redacted = append(redacted, words[i])
+			i++
+		}
+		first := i
+		for i < len(words) && offsets[i] < entity.end {
+			i++
+		}
+		if first == i {
+			continue
+		}
+		placeholder := TranscriptWord{
+			Text:       RedactionPlaceholder,
+			StartTime:  words[first].StartTime,
+			EndTime:    words[i-1].EndTime,
+			Confidence: AverageWordConfidence(words[first:i]),
+			Speaker:    words[first].Speaker,
+			IsRedacted: true,
+		}
+		redacted = append(redacted, placeholder)
+		entities = append(entities, TranscriptEntity{Type: entity.entityType, StartTime: placeholder.StartTime, EndTime: placeholder.EndTime})
+	}
+	redacted = append(redacted, words[i:]...)
+	return redacted, entities
+}
+
+// containsEntityType checks if the given entity types contain the given entity type.
+func containsEntityType(entityTypes []RedactionEntityType, entityType RedactionEntityType) bool {
+	for _, e := range entityTypes {
+		if e == entityType {
+			return true
+		}
+	}
+	return false
+}
+
+// toSpans converts the given regexp match indexes into spans.
+func toSpans(matches [][]int) [][2]int {
+	var spans [][2]int
+	for _, match := range matches {
+		spans = append(spans, [2]int{match[0], match[1]})
+	}
+	return spans
+}
+
+// filterSpans returns the spans of the given regexp matches in the given text that satisfy the given check.
+func filterSpans(text string, matches [][]int, check func(match string) bool) [][2]int {
+	var spans [][2]int
+	for _, match := range matches {
+		if check(text[match[0]:match[1]]) {
+			spans = append(spans, [2]int{match[0], match[1]})
+		}
+	}
+	return spans
+}
+
+// phoneContextLength is the number of bytes before a number in which phoneContextPattern is searched.
+const phoneContextLength = 30
+
+// isPhoneNumber checks if the given number (as matched by phonePattern) is a phone number. The number must have 7 to
+// 15 digits and must not be an ISO date (e.g. "2023-10-17"). Additionally, it must either have an international
+// prefix, an area code in parentheses, a phone-like grouping (see hasPhoneGrouping) or be announced in the given
+// preceding text (see phoneContextPattern).
+func isPhoneNumber(number string, precedingText string) bool {
+	digits := getDigits(number)
+	if len(digits) < 7 || len(digits) > 15 || isoDatePattern.MatchString(number) {
+		return false
+	}
+	if strings.HasPrefix(number, "+") || strings.Contains(number, "(") || hasPhoneGrouping(number) {
+		return true
+	}
+	if len(precedingText) > phoneContextLength {
+		precedingText = precedingText[len(precedingText)-phoneContextLength:]
+	}
+	return phoneContextPattern.MatchString(precedingText)
+}
+
+// hasPhoneGrouping checks if the given number consists of at least three groups of at least two digits, where the
+// last group has at least three digits (e.g. "555-123-4567" or "030 1234 5678", but not "2023 10 17").
+func hasPhoneGrouping(number string) bool {
+	groups := strings.FieldsFunc(number, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '.'
+	})
+	if len(groups) < 3 || len(groups[len(groups)-1]) < 3 {
+		return false
+	}
+	for _, group := range groups {
+		if len(group) < 2 {
+			return false
+		}
+	}
+	return true
+}
+
+// findIbans finds IBANs in the given text. Every candidate starts with a country code and check digits
+// (see ibanStartPattern) and is cut to the IBAN length of its country (see ibanLengths), where single spaces between
+// the characters are skipped. Candidates of unknown countries and candidates that don't pass the mod-97 check are
+// ignored.
+func findIbans(text string) [][2]int {
+	var spans [][2]int
+	for _, match := range ibanStartPattern.FindAllStringIndex(text, -1) {
+		length, exists := ibanLengths[text[match[0]:match[0]+2]]
+		if !exists {
+			continue
+		}
+		characters := 0
+		end := match[0]
+		for end < len(text) && characters < length {
+			if isIbanCharacter(text[end]) {
+				characters++
+			} else if text[end] != ' ' || end+1 >= len(text) || !isIbanCharacter(text[end+1]) {
+				break
+			}
+			end++
+		}
+		// the IBAN must not be followed directly by another IBAN character
+		if characters == length && (end >= len(text) || !isIbanCharacter(text[end])) && isValidIban(text[match[0]:end]) {
+			spans = append(spans, [2]int{match[0], end})
+		}
+	}
+	return spans
+}
+
+// isIbanCharacter checks if the given character can be part of an IBAN.
+func isIbanCharacter(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z')
+}
+
+// ibanLengths are the IBAN lengths by country code, as specified in the IBAN registry.
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22, "BH": 22, "BR": 29, "BY": 28,
+	"CH": 21, "CR": 22, "CY": 28, "CZ": 24, "DE": 22, "DK": 18, "DO": 28, "EE": 20, "EG": 29, "ES": 24, "FI": 18,
+	"FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23, "GL": 18, "GR": 27, "GT": 28, "HR": 21, "HU": 28, "IE": 22,
+	"IL": 23, "IQ": 23, "IS": 26, "IT": 27, "JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32, "LI": 21, "LT": 20,
+	"LU": 20, "LV": 21, "MC": 27, "MD": 24, "ME": 22, "MK": 19, "MR": 27, "MT": 31, "MU": 30, "NL": 18, "NO": 15,
+	"PK": 24, "PL": 28, "PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "SA": 24, "SC": 31, "SE": 24, "SI": 19,
+	"SK": 24, "SM": 27, "ST": 25, "SV": 28, "TL": 23, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20,
+}
+
+// getDigits returns the digits of the given string.
+func getDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// isValidLuhn checks if the given digits pass the Luhn check, which is used by credit card numbers.
+func isValidLuhn(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// isValidIban checks if the given IBAN (which may contain spaces) passes the mod-97 check.
+func isValidIban(iban string) bool {
+	iban = strings.ReplaceAll(iban, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	// move country code and check digits to the end and convert letters into numbers (A = 10, ..., Z = 35)
+	var numeric strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		if unicode.IsDigit(r) {
+			numeric.WriteRune(r)
+		} else {
+			numeric.WriteString(big.NewInt(int64(r-'A') + 10).String())
+		}
+	}
+	value, ok := new(big.Int).SetString(numeric.String(), 10)
+	return ok && new(big.Int).Mod(value, big.NewInt(97)).Int64() == 1
+}
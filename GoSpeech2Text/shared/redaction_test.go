@@ -0,0 +1,113 @@
+package shared
+
+import (
+	"testing"
+	"time"
+)
+
+func getRedactionConfig(entityTypes ...RedactionEntityType) ContentRedactionConfig {
+	config := ContentRedactionConfig{}
+	for i := range entityTypes {
+		config.RedactionEntityTypes = append(config.RedactionEntityTypes, &entityTypes[i])
+	}
+	return config
+}
+
+func TestRedactText(t *testing.T) {
+	config := getRedactionConfig(RedactionEntityAll)
+	tests := map[string]string{
+		"my card is 4111 1111 1111 1111 thanks":      "my card is [PII] thanks",
+		"my card is 4111 1111 1111 1112 thanks":      "my card is 4111 1111 1111 1112 thanks",
+		"my ssn is 123-45-6789":                      "my ssn is [PII]",
+		"call me at +1 555 123 4567 today":           "call me at [PII] today",
+		"write to jane.doe@example.com please":       "write to [PII] please",
+		"the iban is DE89 3704 0044 0532 0130 00":    "the iban is [PII]",
+		"the iban is DE88 3704 0044 0532 0130 00":    "the iban is DE88 3704 0044 0532 0130 00",
+		"my PIN is 4821 okay":                        "my PIN is [PII] okay",
+		"I have 4821 apples":                         "I have 4821 apples",
+		"the iban is DE89 3704 0044 0532 0130 00 OK": "the iban is [PII] OK",
+		"the iban is DE89370400440532013000":         "the iban is [PII]",
+		"call 555-123-4567 now":                      "call [PII] now",
+		"my phone number is 5551234567":              "my phone number is [PII]",
+		"the meeting is on 2023-10-17":               "the meeting is on 2023-10-17",
+		"invoice 20231017":                           "invoice 20231017",
+		"we sold 1234567 units":                      "we sold 1234567 units",
+	}
+	for text, expected := range tests {
+		if redacted := RedactText(text, config); redacted != expected {
+			t.Error("wrong redacted text for '"+text+"': Got ", redacted)
+		}
+	}
+
+	config = getRedactionConfig(RedactionEntityEmail)
+	if redacted := RedactText("jane@example.com, 123-45-6789", config); redacted != "[PII], 123-45-6789" {
+		t.Error("expected only emails to be redacted: Got ", redacted)
+	}
+}
+
+func TestDetectEntitiesPinBeforePhone(t *testing.T) {
+	entities := detectEntities("my PIN is 12345678", []RedactionEntityType{RedactionEntityPhone, RedactionEntityPin})
+	if len(entities) != 1 || entities[0].entityType != RedactionEntityPin {
+		t.Error("wrong entities: Got ", entities)
+	}
+}
+
+func TestGetEntityTypes(t *testing.T) {
+	entityTypes := getRedactionConfig(RedactionEntityPin, RedactionEntityAll).GetEntityTypes()
+	if len(entityTypes) != len(localRedactionEntityTypes) || entityTypes[0] != RedactionEntityPin {
+		t.Error("wrong entity types: Got ", entityTypes)
+	}
+}
+
+func TestRedactTranscript(t *testing.T) {
+	transcript := Transcript{
+		Text: "my pin is 1 2 3 4.",
+		Segments: []TranscriptSegment{{
+			Text: "my pin is 1 2 3 4.",
+			Words: []TranscriptWord{
+				{Text: "my", StartTime: 0, EndTime: time.Second},
+				{Text: "pin", StartTime: time.Second, EndTime: 2 * time.Second},
+				{Text: "is", StartTime: 2 * time.Second, EndTime: 3 * time.Second},
+				{Text: "1", StartTime: 3 * time.Second, EndTime: 4 * time.Second},
+				{Text: "2", StartTime: 4 * time.Second, EndTime: 5 * time.Second},
+				{Text: "3", StartTime: 5 * time.Second, EndTime: 6 * time.Second},
+				{Text: "4", StartTime: 6 * time.Second, EndTime: 7 * time.Second},
+				{Text: ".", IsPunctuation: true},
+			},
+		}},
+	}
+	config := getRedactionConfig(RedactionEntityPin)
+	redacted, unredacted := RedactTranscript(transcript, config)
+	if unredacted != nil {
+		t.Error("expected no unredacted transcript: Got ", unredacted)
+	}
+	if redacted.Text != "my pin is [PII]." || redacted.Segments[0].Text != "my pin is [PII]." {
+		t.Error("wrong redacted text: Got ", redacted.Text)
+	}
+	words := redacted.Segments[0].Words
+	if len(words) != 5 || !words[3].IsRedacted || words[3].Text != RedactionPlaceholder || words[3].StartTime != 3*time.Second || words[3].EndTime != 7*time.Second {
+		t.Error("wrong redacted words: Got ", words)
+	}
+	if len(redacted.Entities) != 1 || redacted.Entities[0] != (TranscriptEntity{Type: RedactionEntityPin, StartTime: 3 * time.Second, EndTime: 7 * time.Second}) {
+		t.Error("wrong entities: Got ", redacted.Entities)
+	}
+
+	config.RedactionOutput = RedactionOutputRedactedAndUnredacted
+	_, unredacted = RedactTranscript(transcript, config)
+	if unredacted == nil || unredacted.Text != transcript.Text || len(unredacted.Entities) != 1 {
+		t.Error("wrong unredacted transcript: Got ", unredacted)
+	}
+}
+
+func TestGetUnredactedDestination(t *testing.T) {
+	tests := map[string]string{
+		"s3://bucket/dir/transcript.json": "s3://bucket/dir/transcript.unredacted.json",
+		"s3://bucket.name/transcript":     "s3://bucket.name/transcript.unredacted",
+		"transcript.srt":                  "transcript.unredacted.srt",
+	}
+	for destination, expected := range tests {
+		if unredacted := GetUnredactedDestination(destination); unredacted != expected {
+			t.Error("wrong unredacted destination for '"+destination+"': Got ", unredacted)
+		}
+	}
+}
@@ -1,17 +1,32 @@
 package shared
 
+import (
+	"context"
+	"io"
+)
+
 type S2TDirectResult struct {
 	Text string
-	Err  error
+	// Transcript is the structured result with segments, words, timestamps and confidences.
+	// Text is equal to Transcript.Text. Transcript is nil if an error occurred.
+	Transcript *Transcript
+	// UnredactedTranscript is the unredacted transcript, if content redaction is enabled with
+	// RedactionOutputRedactedAndUnredacted (see ContentRedactionConfig). Otherwise, it is nil.
+	// Like the redacted transcript, it lists the redacted entities (see Transcript.Entities).
+	UnredactedTranscript *Transcript
+	Err                  error
 }
 
+// S2TProvider is implemented by every supported Speech-to-Text provider.
+// All methods that communicate with the provider receive a context.Context. If the context is cancelled or its
+// deadline is exceeded, the methods return ctx.Err().
 type S2TProvider interface {
 	// TransformOptions Transforms the given options object such that it can be used for the chosen provider.
-	TransformOptions(sourceUrl string, options SpeechToTextOptions) (string, SpeechToTextOptions, error)
+	TransformOptions(ctx context.Context, sourceUrl string, options SpeechToTextOptions) (string, SpeechToTextOptions, error)
 	// CreateServiceClient creates s2t client for the chosen provider and stores it in the struct.
-	CreateServiceClient(credentials CredentialsHolder, region string) (S2TProvider, error)
-	ExecuteS2TDirect(sourceUrl string, options SpeechToTextOptions) <-chan S2TDirectResult
-	ExecuteS2T(source string, destination string, options SpeechToTextOptions) error
+	CreateServiceClient(ctx context.Context, credentials CredentialsHolder, region string) (S2TProvider, error)
+	ExecuteS2TDirect(ctx context.Context, sourceUrl string, options SpeechToTextOptions) <-chan S2TDirectResult
+	ExecuteS2T(ctx context.Context, source string, destination string, options SpeechToTextOptions) error
 	// IsURLonOwnStorage checks if the given URL references a file that is hosted on the provider's own storage service
 	// (i.e. S3 on AWS or Cloud Storage on GCP).
 	IsURLonOwnStorage(url string) bool
@@ -28,3 +43,53 @@ type S2TProvider interface {
 	GetDefaultRegion() string
 	GetStorageUrl(region string, bucket string, key string) string
 }
+
+// TranscriptionJobProvider is implemented by providers that can run transcriptions as jobs, which are started without
+// waiting for them to finish (see TranscriptionJob). It is optional, i.e. GoS2TClient checks if a provider implements it.
+type TranscriptionJobProvider interface {
+	// StartS2T starts a transcription job and returns a handle for it without waiting for the job to finish.
+	// If destination is not empty, the provider stores its result file there.
+	StartS2T(ctx context.Context, sourceUrl string, destination string, options SpeechToTextOptions) (TranscriptionJob, error)
+	// GetS2TStatus checks the status of the given job and returns the updated job.
+	GetS2TStatus(ctx context.Context, job TranscriptionJob) (TranscriptionJob, error)
+	// GetS2TResult returns the result of the given job. The job must have completed.
+	GetS2TResult(ctx context.Context, job TranscriptionJob) S2TDirectResult
+}
+
+// StreamingS2TProvider is implemented by providers that can transcribe audio streams in real time. It is optional,
+// i.e. GoS2TClient checks if a provider implements it. Providers that implement it should be registered with
+// providers.CapabilityStreaming.
+type StreamingS2TProvider interface {
+	// StreamS2T transcribes the audio that is read from the given reader in real time and returns the interim and
+	// final results via the returned channel, which is closed as soon as the transcription has ended.
+	// The transcription ends when the reader returns io.EOF and all results have been delivered, when the context is
+	// done, or when an error occurs (see PartialResult.Err).
+	StreamS2T(ctx context.Context, audio io.Reader, options SpeechToTextOptions) (<-chan PartialResult, error)
+}
+
+// VocabularyManager is implemented by providers that can manage custom vocabularies (see Vocabulary). It is optional,
+// i.e. GoS2TClient checks if a provider implements it.
+type VocabularyManager interface {
+	// CreateVocabulary creates the given custom vocabulary on the provider and returns it without waiting for it to
+	// be ready.
+	CreateVocabulary(ctx context.Context, vocabulary Vocabulary) (Vocabulary, error)
+	// UpdateVocabulary replaces the phrases and custom classes of the existing vocabulary with the ID of the given
+	// vocabulary and returns it without waiting for it to be ready.
+	UpdateVocabulary(ctx context.Context, vocabulary Vocabulary) (Vocabulary, error)
+	// GetVocabulary returns the vocabulary with the given ID. If it doesn't exist, ErrVocabularyNotFound is returned.
+	GetVocabulary(ctx context.Context, id string) (Vocabulary, error)
+	// ListVocabularies returns all vocabularies in the region of the service client.
+	ListVocabularies(ctx context.Context) ([]Vocabulary, error)
+	// DeleteVocabulary deletes the vocabulary with the given ID. If it doesn't exist, ErrVocabularyNotFound is returned.
+	DeleteVocabulary(ctx context.Context, id string) error
+}
+
+// StorageAudioHeaderReader is implemented by providers that can read the header of audio files on their own storage
+// service, so that the format of the files can be detected without downloading them. It is optional, i.e.
+// GoS2TClient checks if a provider implements it. Otherwise, the format is derived from the file extension.
+type StorageAudioHeaderReader interface {
+	// ReadStorageAudioHeader reads the first AudioHeaderSize bytes of the given file on the provider's own storage
+	// service (see S2TProvider.IsURLonOwnStorage) with a ranged read, so that its format can be detected (see DetectAudioFileType).
+	// If the file is shorter, all of its bytes are returned.
+	ReadStorageAudioHeader(ctx context.Context, url string) ([]byte, error)
+}
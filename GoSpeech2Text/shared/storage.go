@@ -0,0 +1,46 @@
+package shared
+
+import "github.com/FaaSTools/GoStorage/gostorage"
+
+// Storage is the subset of the GoStorage API that GoS2TClient uses to upload, copy and delete files on the storage
+// services of the providers. It can be replaced by a fake implementation for testing (see the s2ttest package).
+type Storage interface {
+	// UploadFile uploads the local file obj.LocalFilePath to the given bucket and key.
+	UploadFile(obj gostorage.GoStorageObject) error
+	// Copy copies the file source to destination.
+	Copy(source gostorage.GoStorageObject, destination gostorage.GoStorageObject) error
+	// DeleteFile deletes the given file.
+	DeleteFile(obj gostorage.GoStorageObject) error
+}
+
+// goStorage is the Storage implementation that uses GoStorage.
+type goStorage struct {
+	client *gostorage.GoStorage
+}
+
+// NewGoStorage creates a Storage that uses GoStorage with the given credentials.
+func NewGoStorage(credentials CredentialsHolder) Storage {
+	return goStorage{
+		client: &gostorage.GoStorage{
+			Credentials: credentials,
+		},
+	}
+}
+
+// UploadFile uploads the given file using GoStorage. GoStorage doesn't return errors, so nil is always returned.
+func (s goStorage) UploadFile(obj gostorage.GoStorageObject) error {
+	s.client.UploadFile(obj)
+	return nil
+}
+
+// Copy copies the given file using GoStorage. GoStorage doesn't return errors, so nil is always returned.
+func (s goStorage) Copy(source gostorage.GoStorageObject, destination gostorage.GoStorageObject) error {
+	s.client.Copy(source, destination)
+	return nil
+}
+
+// DeleteFile deletes the given file using GoStorage. GoStorage doesn't return errors, so nil is always returned.
+func (s goStorage) DeleteFile(obj gostorage.GoStorageObject) error {
+	s.client.DeleteFile(obj)
+	return nil
+}
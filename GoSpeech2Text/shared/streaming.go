@@ -0,0 +1,73 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// PartialResult is a result of a streaming transcription (see S2TProvider.StreamS2T).
+// The audio stream is divided into utterances. For every utterance, any number of interim results (IsFinal is false)
+// is delivered, each of which replaces the previous interim result of the same utterance. The last result of an
+// utterance is its final result (IsFinal is true), which won't change anymore.
+type PartialResult struct {
+	// ResultId identifies the utterance that the result belongs to.
+	ResultId string
+	// Text is the (interim) transcript of the utterance.
+	Text string
+	// IsFinal is true if the result is the final result of the utterance.
+	IsFinal bool
+	// Stability is an estimate between 0.0 and 1.0 of the likelihood that an interim result won't change anymore.
+	// Final results always have a stability of 1.0. For interim results, 0.0 means that the stability is unknown.
+	Stability float32
+	// StartTime is the offset of the beginning of the utterance relative to the beginning of the audio stream.
+	StartTime time.Duration
+	// EndTime is the offset of the end of the (interim) result relative to the beginning of the audio stream.
+	EndTime      time.Duration
+	LanguageCode string
+	// Words are the words of the result (if the provider returns them for this result).
+	Words []TranscriptWord
+	// Err is set if the streaming transcription has failed. A result with an error is always the last result.
+	Err error
+}
+
+// StreamingAudioChunkSize is the maximum number of bytes that are read from an audio stream and sent to the provider
+// at once (see ReadAudioStream).
+const StreamingAudioChunkSize = 8192
+
+// ReadAudioStream reads the given audio stream until it ends (i.e. until io.EOF is returned) and passes the read audio
+// chunks (of at most StreamingAudioChunkSize bytes) to the given function. The chunk is only valid during the call.
+// If the context is done, the audio can't be read or the given function returns an error, reading is stopped and
+// the error is returned.
+func ReadAudioStream(ctx context.Context, audio io.Reader, send func(chunk []byte) error) error {
+	buffer := make([]byte, StreamingAudioChunkSize)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		n, errRead := audio.Read(buffer)
+		if n > 0 {
+			if errSend := send(buffer[:n]); errSend != nil {
+				return errSend
+			}
+		}
+		if errors.Is(errRead, io.EOF) {
+			return nil
+		}
+		if errRead != nil {
+			return errors.Join(errors.New("error while reading audio stream"), errRead)
+		}
+	}
+}
+
+// SendPartialResult sends the given result to the given channel. If the context is done before the result has been
+// received, the result is dropped and false is returned.
+func SendPartialResult(ctx context.Context, results chan<- PartialResult, result PartialResult) bool {
+	select {
+	case results <- result:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
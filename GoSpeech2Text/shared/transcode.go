@@ -0,0 +1,386 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PCMAudio is decoded audio, which is the intermediate format of the transcoding pipeline (see TranscodeAudio).
+type PCMAudio struct {
+	// Samples are the 16-bit samples of all channels, interleaved (i.e. one sample per channel for every frame).
+	Samples         []int16
+	SampleRateHertz int32
+	ChannelCount    int32
+}
+
+// AudioDecoder decodes the given audio file content into PCM audio.
+type AudioDecoder func(content []byte) (PCMAudio, error)
+
+// AudioEncoder encodes the given PCM audio into an audio file.
+type AudioEncoder func(audio PCMAudio) ([]byte, error)
+
+// audioEncoderRegistration is a registered encoder together with the file type that it creates.
+type audioEncoderRegistration struct {
+	fileType string
+	encoder  AudioEncoder
+}
+
+var (
+	transcodingMutex sync.RWMutex
+	audioDecoders    = make(map[string]AudioDecoder)
+	audioEncoders    []audioEncoderRegistration
+)
+
+func init() {
+	RegisterAudioDecoder("wav", decodeWav)
+	RegisterAudioEncoder("wav", encodeWav)
+}
+
+// RegisterAudioDecoder adds a decoder for the given file type (without preceding period, e.g. "mp3") to the
+// transcoding pipeline. Decoders for WAV files are built in. Decoders for compressed formats (like MP3) can be
+// registered in an init function, e.g. by wrapping a third-party decoder.
+// If a decoder for the same file type has already been registered, it is replaced.
+func RegisterAudioDecoder(fileType string, decoder AudioDecoder) {
+	transcodingMutex.Lock()
+	defer transcodingMutex.Unlock()
+	audioDecoders[strings.ToLower(fileType)] = decoder
+}
+
+// RegisterAudioEncoder adds an encoder for the given file type to the transcoding pipeline.
+// The target file type of a conversion is the first registered file type that the provider supports,
+// i.e. encoders that are registered earlier are preferred. An encoder for WAV files (16-bit PCM) is built in.
+// If an encoder for the same file type has already been registered, it is replaced.
+func RegisterAudioEncoder(fileType string, encoder AudioEncoder) {
+	transcodingMutex.Lock()
+	defer transcodingMutex.Unlock()
+	fileType = strings.ToLower(fileType)
+	for i, existing := range audioEncoders {
+		if existing.fileType == fileType {
+			audioEncoders[i].encoder = encoder
+			return
+		}
+	}
+	audioEncoders = append(audioEncoders, audioEncoderRegistration{fileType: fileType, encoder: encoder})
+}
+
+// CanDecodeAudio returns true if audio with the given file type and format can be decoded, i.e. if it is raw audio
+// (see AudioFormat.IsRaw) or if a decoder for the file type has been registered.
+func CanDecodeAudio(fileType string, format AudioFormat) bool {
+	if format.IsRaw() {
+		return true
+	}
+	transcodingMutex.RLock()
+	defer transcodingMutex.RUnlock()
+	_, exists := audioDecoders[strings.ToLower(fileType)]
+	return exists
+}
+
+// GetTranscodingTarget returns the first file type with a registered encoder for which the given function returns
+// true (e.g. S2TProvider.SupportsFileType). If there is no such file type, an empty string is returned.
+func GetTranscodingTarget(supportsFileType func(fileType string) bool) string {
+	transcodingMutex.RLock()
+	defer transcodingMutex.RUnlock()
+	for _, registration := range audioEncoders {
+		if supportsFileType(registration.fileType) {
+			return registration.fileType
+		}
+	}
+	return ""
+}
+
+// TranscodeAudio converts the given audio file content with the given file type into the given target file type.
+// If the source is raw audio (see AudioFormat.IsRaw), the given format is used to decode it and the file type is
+// ignored. The decoded audio is converted as specified in the given config (sample rate and channels) before it is
+// encoded.
+func TranscodeAudio(content []byte, fileType string, format AudioFormat, targetFileType string, config TranscodingConfig) ([]byte, error) {
+	audio, err := DecodeAudio(content, fileType, format)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeAudio(ConvertAudio(audio, config), targetFileType)
+}
+
+// DecodeAudio decodes the given audio file content with the given file type into PCM audio.
+// If the source is raw audio (see AudioFormat.IsRaw), the given format is used to decode it and the file type is
+// ignored.
+func DecodeAudio(content []byte, fileType string, format AudioFormat) (PCMAudio, error) {
+	var audio PCMAudio
+	var err error
+	if format.IsRaw() {
+		audio, err = DecodeRawAudio(content, format)
+	} else {
+		transcodingMutex.RLock()
+		decoder, exists := audioDecoders[strings.ToLower(fileType)]
+		transcodingMutex.RUnlock()
+		if !exists {
+			return PCMAudio{}, errors.New(fmt.Sprintf("Couldn't decode audio, because there is no decoder for file type '%s'.", fileType))
+		}
+		audio, err = decoder(content)
+	}
+	if err != nil {
+		return PCMAudio{}, errors.Join(errors.New(fmt.Sprintf("error while decoding audio with file type '%s'", fileType)), err)
+	}
+	return audio, nil
+}
+
+// ConvertAudio converts the given audio as specified in the given config (sample rate and channels).
+func ConvertAudio(audio PCMAudio, config TranscodingConfig) PCMAudio {
+	if config.ConvertToMono {
+		audio = DownmixToMono(audio)
+	}
+	if config.SampleRateHertz > 0 {
+		audio = ResampleAudio(audio, config.SampleRateHertz)
+	}
+	return audio
+}
+
+// EncodeAudio encodes the given PCM audio into an audio file with the given file type.
+func EncodeAudio(audio PCMAudio, fileType string) ([]byte, error) {
+	var encoder AudioEncoder = nil
+	transcodingMutex.RLock()
+	for _, registration := range audioEncoders {
+		if registration.fileType == strings.ToLower(fileType) {
+			encoder = registration.encoder
+		}
+	}
+	transcodingMutex.RUnlock()
+	if encoder == nil {
+		return nil, errors.New(fmt.Sprintf("Couldn't encode audio, because there is no encoder for file type '%s'.", fileType))
+	}
+	return encoder(audio)
+}
+
+// GetDuration returns the duration of the audio.
+func (a PCMAudio) GetDuration() time.Duration {
+	if a.SampleRateHertz <= 0 {
+		return 0
+	}
+	return time.Duration(int64(a.GetFrameCount()) * int64(time.Second) / int64(a.SampleRateHertz))
+}
+
+// GetFrameCount returns the number of frames, i.e. the number of samples per channel.
+func (a PCMAudio) GetFrameCount() int {
+	if a.ChannelCount <= 1 {
+		return len(a.Samples)
+	}
+	return len(a.Samples) / int(a.ChannelCount)
+}
+
+// DecodeRawAudio decodes the given raw (headerless) audio content with the given format.
+func DecodeRawAudio(content []byte, format AudioFormat) (PCMAudio, error) {
+	if format.SampleRateHertz <= 0 {
+		return PCMAudio{}, errors.New("Couldn't decode raw audio, because no sample rate is specified.")
+	}
+	audio := PCMAudio{
+		SampleRateHertz: format.SampleRateHertz,
+		ChannelCount:    format.GetChannelCount(),
+	}
+	switch format.Encoding {
+	case AudioEncodingLinear16:
+		audio.Samples = decodeLinear16(content)
+	case AudioEncodingMulaw:
+		audio.Samples = decodeCompanded(content, decodeMulawSample)
+	case AudioEncodingAlaw:
+		audio.Samples = decodeCompanded(content, decodeAlawSample)
+	default:
+		return PCMAudio{}, errors.New(fmt.Sprintf("Couldn't decode raw audio, because encoding '%s' is not supported.", format.Encoding))
+	}
+	return audio, nil
+}
+
+// DownmixToMono converts the given audio into mono audio by averaging the samples of all channels.
+func DownmixToMono(audio PCMAudio) PCMAudio {
+	channels := int(audio.ChannelCount)
+	if channels <= 1 {
+		return audio
+	}
+	mono := make([]int16, len(audio.Samples)/channels)
+	for frame := range mono {
+		sum := 0
+		for channel := 0; channel < channels; channel++ {
+			sum += int(audio.Samples[frame*channels+channel])
+		}
+		mono[frame] = int16(sum / channels)
+	}
+	return PCMAudio{Samples: mono, SampleRateHertz: audio.SampleRateHertz, ChannelCount: 1}
+}
+
+// ResampleAudio converts the given audio to the given sample rate using linear interpolation.
+func ResampleAudio(audio PCMAudio, sampleRateHertz int32) PCMAudio {
+	channels := int(audio.ChannelCount)
+	if channels < 1 {
+		channels = 1
+	}
+	if sampleRateHertz <= 0 || sampleRateHertz == audio.SampleRateHertz || audio.SampleRateHertz <= 0 {
+		return audio
+	}
+	frames := len(audio.Samples) / channels
+	resampledFrames := int(int64(frames) * int64(sampleRateHertz) / int64(audio.SampleRateHertz))
+	resampled := make([]int16, resampledFrames*channels)
+	ratio := float64(audio.SampleRateHertz) / float64(sampleRateHertz)
+	for frame := 0; frame < resampledFrames; frame++ {
+		position := float64(frame) * ratio
+		index := int(position)
+		fraction := position - float64(index)
+		for channel := 0; channel < channels; channel++ {
+			current := float64(audio.Samples[index*channels+channel])
+			next := current
+			if index+1 < frames {
+				next = float64(audio.Samples[(index+1)*channels+channel])
+			}
+			resampled[frame*channels+channel] = int16(current + (next-current)*fraction)
+		}
+	}
+	return PCMAudio{Samples: resampled, SampleRateHertz: sampleRateHertz, ChannelCount: int32(channels)}
+}
+
+// WAV format codes (see the "fmt " chunk of WAV files).
+const (
+	wavFormatPcm        = 1
+	wavFormatAlaw       = 6
+	wavFormatMulaw      = 7
+	wavFormatExtensible = 0xFFFE
+)
+
+// decodeWav decodes a WAV file with PCM (8, 16, 24 or 32 bits), A-law or mu-law samples.
+func decodeWav(content []byte) (PCMAudio, error) {
+	if len(content) < 12 || !bytes.Equal(content[0:4], []byte("RIFF")) || !bytes.Equal(content[8:12], []byte("WAVE")) {
+		return PCMAudio{}, errors.New("the content is not a WAV file")
+	}
+	var audio PCMAudio
+	var formatCode, bitsPerSample uint16 = 0, 0
+	offset := 12
+	for offset+8 <= len(content) {
+		chunkId := string(content[offset : offset+4])
+		chunkSize := int(binary.LittleEndian.Uint32(content[offset+4 : offset+8]))
+		chunkStart := offset + 8
+		if chunkSize > len(content)-chunkStart {
+			// chunk size is unknown (e.g. streamed WAV files) or larger than the file -> use remaining size
+			chunkSize = len(content) - chunkStart
+		}
+		chunk := content[chunkStart : chunkStart+chunkSize]
+		switch chunkId {
+		case "fmt ":
+			if len(chunk) < 16 {
+				return PCMAudio{}, errors.New("the fmt chunk of the WAV file is incomplete")
+			}
+			formatCode = binary.LittleEndian.Uint16(chunk[0:2])
+			audio.ChannelCount = int32(binary.LittleEndian.Uint16(chunk[2:4]))
+			audio.SampleRateHertz = int32(binary.LittleEndian.Uint32(chunk[4:8]))
+			bitsPerSample = binary.LittleEndian.Uint16(chunk[14:16])
+			if formatCode == wavFormatExtensible && len(chunk) >= 26 {
+				// the actual format code is the first two bytes of the sub format GUID
+				formatCode = binary.LittleEndian.Uint16(chunk[24:26])
+			}
+		case "data":
+			if audio.SampleRateHertz <= 0 {
+				return PCMAudio{}, errors.New("the WAV file has no fmt chunk before its data chunk")
+			}
+			switch {
+			case formatCode == wavFormatAlaw:
+				audio.Samples = decodeCompanded(chunk, decodeAlawSample)
+			case formatCode == wavFormatMulaw:
+				audio.Samples = decodeCompanded(chunk, decodeMulawSample)
+			case formatCode == wavFormatPcm && bitsPerSample == 8:
+				audio.Samples = make([]int16, len(chunk))
+				for i, sample := range chunk { // 8-bit samples are unsigned
+					audio.Samples[i] = (int16(sample) - 128) << 8
+				}
+			case formatCode == wavFormatPcm && bitsPerSample == 16:
+				audio.Samples = decodeLinear16(chunk)
+			case formatCode == wavFormatPcm && (bitsPerSample == 24 || bitsPerSample == 32):
+				bytesPerSample := int(bitsPerSample / 8)
+				audio.Samples = make([]int16, len(chunk)/bytesPerSample)
+				for i := range audio.Samples {
+					// use the two most significant bytes (little-endian)
+					audio.Samples[i] = int16(binary.LittleEndian.Uint16(chunk[i*bytesPerSample+bytesPerSample-2:]))
+				}
+			default:
+				return PCMAudio{}, errors.New(fmt.Sprintf("WAV files with format code %d and %d bits per sample are not supported", formatCode, bitsPerSample))
+			}
+			return audio, nil
+		}
+		// chunks are padded to an even size
+		offset = chunkStart + chunkSize + chunkSize%2
+	}
+	return PCMAudio{}, errors.New("the WAV file has no data chunk")
+}
+
+// encodeWav encodes the given audio into a WAV file with 16-bit PCM samples.
+func encodeWav(audio PCMAudio) ([]byte, error) {
+	channels := audio.ChannelCount
+	if channels < 1 {
+		channels = 1
+	}
+	dataSize := len(audio.Samples) * 2
+	var buffer bytes.Buffer
+	buffer.Grow(44 + dataSize)
+	buffer.WriteString("RIFF")
+	_ = binary.Write(&buffer, binary.LittleEndian, uint32(36+dataSize))
+	buffer.WriteString("WAVEfmt ")
+	for _, value := range []interface{}{
+		uint32(16),                    // size of fmt chunk
+		uint16(wavFormatPcm),          // format code
+		uint16(channels),              // channel count
+		uint32(audio.SampleRateHertz), // sample rate
+		uint32(audio.SampleRateHertz * channels * 2), // byte rate
+		uint16(channels * 2),                         // block align
+		uint16(16),                                   // bits per sample
+	} {
+		_ = binary.Write(&buffer, binary.LittleEndian, value)
+	}
+	buffer.WriteString("data")
+	_ = binary.Write(&buffer, binary.LittleEndian, uint32(dataSize))
+	_ = binary.Write(&buffer, binary.LittleEndian, audio.Samples)
+	return buffer.Bytes(), nil
+}
+
+// decodeLinear16 decodes 16-bit signed little-endian samples.
+func decodeLinear16(content []byte) []int16 {
+	samples := make([]int16, len(content)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(content[i*2:]))
+	}
+	return samples
+}
+
+// decodeCompanded decodes 8-bit companded (A-law or mu-law) samples with the given sample decoder.
+func decodeCompanded(content []byte, decodeSample func(sample byte) int16) []int16 {
+	samples := make([]int16, len(content))
+	for i, sample := range content {
+		samples[i] = decodeSample(sample)
+	}
+	return samples
+}
+
+// decodeMulawSample decodes a G.711 mu-law sample.
+func decodeMulawSample(sample byte) int16 {
+	sample = ^sample
+	exponent := (sample >> 4) & 0x07
+	magnitude := ((int16(sample&0x0F) << 3) + 0x84) << exponent
+	if sample&0x80 != 0 {
+		return 0x84 - magnitude
+	}
+	return magnitude - 0x84
+}
+
+// decodeAlawSample decodes a G.711 A-law sample.
+func decodeAlawSample(sample byte) int16 {
+	sample ^= 0x55
+	exponent := (sample >> 4) & 0x07
+	magnitude := int16(sample&0x0F) << 4
+	if exponent == 0 {
+		magnitude += 8
+	} else {
+		magnitude = (magnitude + 0x108) << (exponent - 1)
+	}
+	if sample&0x80 != 0 {
+		return magnitude
+	}
+	return -magnitude
+}
@@ -0,0 +1,82 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeCompandedSamples(t *testing.T) {
+	if sample := decodeMulawSample(0xFF); sample != 0 {
+		t.Error("wrong mu-law sample: Got ", sample)
+	}
+	if sample := decodeMulawSample(0x00); sample != -32124 {
+		t.Error("wrong mu-law sample: Got ", sample)
+	}
+	if sample := decodeAlawSample(0xD5); sample != 8 {
+		t.Error("wrong A-law sample: Got ", sample)
+	}
+	if sample := decodeAlawSample(0x2A); sample != -32256 {
+		t.Error("wrong A-law sample: Got ", sample)
+	}
+}
+
+func TestTranscodeAudioRawToWav(t *testing.T) {
+	raw := []byte{0x00, 0x10, 0x00, 0x20, 0x00, 0x30, 0x00, 0x40} // 4 LINEAR16 samples
+	format := AudioFormat{Encoding: AudioEncodingLinear16, SampleRateHertz: 8000}
+	wav, err := TranscodeAudio(raw, "", format, "wav", TranscodingConfig{Enabled: true})
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if DetectAudioFileType(wav) != "wav" || len(wav) != 44+len(raw) {
+		t.Fatal("wrong WAV file: Got ", wav)
+	}
+
+	audio, err := decodeWav(wav)
+	if err != nil || audio.SampleRateHertz != 8000 || audio.ChannelCount != 1 || len(audio.Samples) != 4 || audio.Samples[3] != 0x4000 {
+		t.Error("wrong decoded audio: Got ", audio, err)
+	}
+}
+
+func TestTranscodeAudioResampleAndDownmix(t *testing.T) {
+	stereo := PCMAudio{Samples: []int16{100, 300, 200, 400, 300, 500, 400, 600}, SampleRateHertz: 16000, ChannelCount: 2}
+	wav, err := encodeWav(stereo)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+
+	converted, err := TranscodeAudio(wav, "wav", AudioFormat{}, "wav", TranscodingConfig{Enabled: true, SampleRateHertz: 8000, ConvertToMono: true})
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	audio, err := decodeWav(converted)
+	if err != nil || audio.SampleRateHertz != 8000 || audio.ChannelCount != 1 {
+		t.Fatal("wrong converted audio: Got ", audio, err)
+	}
+	if len(audio.Samples) != 2 || audio.Samples[0] != 200 || audio.Samples[1] != 400 {
+		t.Error("wrong converted samples: Got ", audio.Samples)
+	}
+}
+
+func TestTranscodeAudioCustomDecoder(t *testing.T) {
+	if CanDecodeAudio("test-format", AudioFormat{}) {
+		t.Fatal("expected no decoder for test format")
+	}
+	if _, err := TranscodeAudio([]byte("test"), "test-format", AudioFormat{}, "wav", TranscodingConfig{Enabled: true}); err == nil {
+		t.Error("expected error because of missing decoder")
+	}
+
+	RegisterAudioDecoder("test-format", func(content []byte) (PCMAudio, error) {
+		return PCMAudio{Samples: make([]int16, len(content)), SampleRateHertz: 8000, ChannelCount: 1}, nil
+	})
+	wav, err := TranscodeAudio([]byte("test"), "test-format", AudioFormat{}, "wav", TranscodingConfig{Enabled: true})
+	if err != nil || len(wav) != 44+8 {
+		t.Error("wrong WAV file: Got ", len(wav), err)
+	}
+
+	if target := GetTranscodingTarget(func(fileType string) bool { return strings.EqualFold(fileType, "WAV") }); target != "wav" {
+		t.Error("wrong transcoding target: Got ", target)
+	}
+	if target := GetTranscodingTarget(func(fileType string) bool { return false }); target != "" {
+		t.Error("wrong transcoding target: Got ", target)
+	}
+}
@@ -0,0 +1,150 @@
+package shared
+
+import (
+	"strings"
+	"time"
+)
+
+// Transcript is the provider-independent, structured result of a transcription.
+// It consists of segments (i.e. consecutive portions of the audio, like sentences), which in turn consist of words.
+type Transcript struct {
+	// Text is the complete transcribed text.
+	Text string `json:"text"`
+	// LanguageCode is the (identified) language of the transcript, if the provider returns it.
+	LanguageCode string `json:"languageCode,omitempty"`
+	// Segments are the transcribed portions of the audio in chronological order.
+	Segments []TranscriptSegment `json:"segments"`
+	// Entities are the redacted entities in chronological order (see SpeechToTextOptions.ContentRedactionConfig).
+	// On AWS, they are taken from the redacted transcript file. Otherwise, they are found by the local redaction
+	// engine (see RedactTranscript).
+	Entities []TranscriptEntity `json:"entities,omitempty"`
+}
+
+// TranscriptSegment is a transcribed portion of the audio. Its text, words and confidence are those of the most
+// likely alternative. Other, less likely alternatives are listed in Alternatives.
+type TranscriptSegment struct {
+	Text string `json:"text"`
+	// StartTime is the offset of the beginning of the segment relative to the beginning of the audio.
+	StartTime time.Duration `json:"startTime"`
+	// EndTime is the offset of the end of the segment relative to the beginning of the audio.
+	EndTime time.Duration `json:"endTime"`
+	// Confidence is an estimate between 0 and 1 of how likely the transcription is correct.
+	// A value of 0 means that the provider didn't return a confidence.
+	Confidence float32 `json:"confidence"`
+	// LanguageCode is the (identified) language of the segment, if the provider returns it.
+	LanguageCode string `json:"languageCode,omitempty"`
+	// Speaker is the label of the speaker of the segment (e.g. "spk_0" on AWS or "1" on GCP).
+	// It is only set if speaker diarization is enabled (see SpeechToTextOptions.DiarizationConfig).
+	Speaker      string                  `json:"speaker,omitempty"`
+	Words        []TranscriptWord        `json:"words,omitempty"`
+	Alternatives []TranscriptAlternative `json:"alternatives,omitempty"`
+}
+
+// TranscriptAlternative is an alternative (less likely) transcription of a segment.
+type TranscriptAlternative struct {
+	Text       string           `json:"text"`
+	Confidence float32          `json:"confidence"`
+	Words      []TranscriptWord `json:"words,omitempty"`
+}
+
+// TranscriptWord is a single transcribed word or punctuation mark.
+type TranscriptWord struct {
+	Text string `json:"text"`
+	// StartTime is the offset of the beginning of the word relative to the beginning of the audio.
+	// Punctuation marks have no start and end time on some providers.
+	StartTime time.Duration `json:"startTime"`
+	// EndTime is the offset of the end of the word relative to the beginning of the audio.
+	EndTime time.Duration `json:"endTime"`
+	// Confidence is an estimate between 0 and 1 of how likely the transcription is correct.
+	// A value of 0 means that the provider didn't return a confidence.
+	Confidence    float32 `json:"confidence"`
+	IsPunctuation bool    `json:"isPunctuation,omitempty"`
+	// Speaker is the label of the speaker of the word.
+	// It is only set if speaker diarization is enabled (see SpeechToTextOptions.DiarizationConfig).
+	Speaker string `json:"speaker,omitempty"`
+	// IsProfanity is true if the word has been tagged by the profanity filter (see ProfanityFilterMethodTag).
+	IsProfanity bool `json:"isProfanity,omitempty"`
+	// IsRedacted is true if the word replaces a redacted entity (see SpeechToTextOptions.ContentRedactionConfig).
+	IsRedacted bool `json:"isRedacted,omitempty"`
+}
+
+// GetWords returns the words of all segments in chronological order.
+func (t Transcript) GetWords() []TranscriptWord {
+	var words []TranscriptWord
+	for _, segment := range t.Segments {
+		words = append(words, segment.Words...)
+	}
+	return words
+}
+
+// GetDuration returns the end time of the last segment.
+func (t Transcript) GetDuration() time.Duration {
+	if len(t.Segments) < 1 {
+		return 0
+	}
+	return t.Segments[len(t.Segments)-1].EndTime
+}
+
+// AverageWordConfidence returns the average confidence of the given words, ignoring punctuation marks.
+// If there are no words, 0 is returned.
+func AverageWordConfidence(words []TranscriptWord) float32 {
+	var sum float32 = 0
+	count := 0
+	for _, word := range words {
+		if word.IsPunctuation {
+			continue
+		}
+		sum += word.Confidence
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float32(count)
+}
+
+// JoinWords joins the texts of the given words with spaces. Punctuation marks are attached to the preceding word.
+func JoinWords(words []TranscriptWord) string {
+	var builder strings.Builder
+	for _, word := range words {
+		if builder.Len() > 0 && !word.IsPunctuation {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(word.Text)
+	}
+	return builder.String()
+}
+
+// SplitWordsBySpeaker splits the given words into groups of consecutive words with the same speaker.
+// Punctuation marks always belong to the group of the preceding word. Words without speaker label
+// are added to the current group.
+func SplitWordsBySpeaker(words []TranscriptWord) [][]TranscriptWord {
+	var groups [][]TranscriptWord
+	groupStart := 0
+	groupSpeaker := ""
+	for i, word := range words {
+		if word.IsPunctuation || word.Speaker == "" {
+			continue
+		}
+		if groupSpeaker != "" && word.Speaker != groupSpeaker {
+			groups = append(groups, words[groupStart:i])
+			groupStart = i
+		}
+		groupSpeaker = word.Speaker
+	}
+	if groupStart < len(words) {
+		groups = append(groups, words[groupStart:])
+	}
+	return groups
+}
+
+// GetSpeaker returns the speaker label of the first word that has one. If no word has a speaker label,
+// an empty string is returned.
+func GetSpeaker(words []TranscriptWord) string {
+	for _, word := range words {
+		if word.Speaker != "" {
+			return word.Speaker
+		}
+	}
+	return ""
+}
@@ -0,0 +1,27 @@
+package shared
+
+import "testing"
+
+func TestSplitWordsBySpeaker(t *testing.T) {
+	words := []TranscriptWord{
+		{Text: "Hi", Speaker: "spk_0"},
+		{Text: ".", IsPunctuation: true},
+		{Text: "Hello", Speaker: "spk_1"},
+		{Text: "there", Speaker: "spk_1"},
+		{Text: "Bye", Speaker: "spk_0"},
+	}
+	groups := SplitWordsBySpeaker(words)
+	if len(groups) != 3 {
+		t.Fatal("wrong number of groups: Got ", groups)
+	}
+	if JoinWords(groups[0]) != "Hi." || GetSpeaker(groups[1]) != "spk_1" || len(groups[2]) != 1 {
+		t.Error("wrong groups: Got ", groups)
+	}
+
+	if len(SplitWordsBySpeaker([]TranscriptWord{{Text: "a"}, {Text: "b"}})) != 1 {
+		t.Error("expected a single group for words without speaker labels")
+	}
+	if GetSpeaker(nil) != "" {
+		t.Error("expected no speaker")
+	}
+}
@@ -0,0 +1,92 @@
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/FaaSTools/GoStorage/gostorage"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
+	"time"
+)
+
+// TranscriptionJob is a provider-independent handle for an asynchronous transcription job.
+// It is returned when a job is started and can be passed to the status, wait and result functions.
+// The handle can be serialized to JSON (see ToJSON and ParseTranscriptionJob), so that a job can be started in one
+// function invocation and collected in another one.
+type TranscriptionJob struct {
+	// Provider is the provider on which the job is executed.
+	Provider providers.Provider `json:"provider"`
+	// Name identifies the job on the provider.
+	// On AWS, this is the name of the transcription job.
+	// On GCP, this is the name of the long-running operation.
+	Name string `json:"name"`
+	// Region is the region in which the job is executed.
+	Region string `json:"region,omitempty"`
+	// Source is the URL of the audio file that is transcribed.
+	Source string `json:"source"`
+	// Destination is the URL at which the provider stores its result file.
+	// If it is empty, the result is only available via the result functions.
+	Destination string `json:"destination,omitempty"`
+	// Status is the job status at the time of the latest status check.
+	Status JobStatus `json:"status"`
+	// FailureReason contains the reason why the job failed (if Status is JobStatusFailed).
+	FailureReason string `json:"failureReason,omitempty"`
+	// ResultUrl is the URL of the result file created by the provider, once the job has completed.
+	ResultUrl string `json:"resultUrl,omitempty"`
+	// UnredactedResultUrl is the URL of the unredacted result file created by the provider, if content is redacted
+	// natively with RedactionOutputRedactedAndUnredacted (see ContentRedactionConfig). ResultUrl is the URL of the
+	// redacted result file in this case.
+	UnredactedResultUrl string `json:"unredactedResultUrl,omitempty"`
+	// DeleteResultFile specifies if the result file should be deleted after it has been downloaded.
+	// This is the case if the result file has been stored at a temporary location.
+	DeleteResultFile bool `json:"deleteResultFile,omitempty"`
+	// TempSourceFile is the source file that has been temporarily uploaded to a storage service for this job.
+	// It is deleted as soon as the job is done.
+	TempSourceFile *gostorage.GoStorageObject `json:"tempSourceFile,omitempty"`
+	// Redaction is the content redaction config that is applied locally to the result of the job, because the
+	// provider can't redact content natively (see SpeechToTextOptions.ContentRedactionConfig).
+	// The result file at Destination isn't redacted.
+	Redaction *ContentRedactionConfig `json:"redaction,omitempty"`
+	// ProfanityFilter is the profanity filter that is applied locally to the result of the job, because the provider
+	// can't filter profanities natively (see SpeechToTextOptions.ProfanityFilter).
+	// The result file at Destination isn't filtered.
+	ProfanityFilter *ProfanityFilterConfig `json:"profanityFilter,omitempty"`
+	// StartTime is the time at which the job has been started.
+	StartTime time.Time `json:"startTime"`
+}
+
+// ToJSON serializes the job handle to JSON.
+func (job TranscriptionJob) ToJSON() ([]byte, error) {
+	return json.Marshal(job)
+}
+
+// ParseTranscriptionJob deserializes a job handle that has been serialized with TranscriptionJob.ToJSON.
+func ParseTranscriptionJob(data []byte) (TranscriptionJob, error) {
+	var job TranscriptionJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return TranscriptionJob{}, errors.Join(errors.New("error while parsing transcription job"), err)
+	}
+	return job, nil
+}
+
+// WaitForJob polls the status of the given job on the given provider until the job is done, using a JobPoller
+// that is configured by the given options. The updated job is returned.
+// If the job has failed, the job is returned together with an error that contains the failure reason.
+func WaitForJob(ctx context.Context, provider TranscriptionJobProvider, job TranscriptionJob, options SpeechToTextOptions) (TranscriptionJob, error) {
+	if !job.Status.IsDone() {
+		_, errPoll := NewJobPoller(options).Poll(ctx, job.Name, func(ctx context.Context) (JobStatus, error) {
+			var err error
+			job, err = provider.GetS2TStatus(ctx, job)
+			return job.Status, err
+		})
+		if errPoll != nil {
+			return job, errPoll
+		}
+	}
+
+	if job.Status == JobStatusFailed {
+		return job, errors.New(fmt.Sprintf("Error occurred during transcription: %s\n", job.FailureReason))
+	}
+	return job, nil
+}
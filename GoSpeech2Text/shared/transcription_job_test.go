@@ -0,0 +1,53 @@
+package shared
+
+import (
+	"context"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTranscriptionJobJSON(t *testing.T) {
+	job := TranscriptionJob{
+		Provider:    providers.ProviderAWS,
+		Name:        "s2t-123",
+		Region:      "us-east-1",
+		Source:      "s3://bucket/test.mp3",
+		Destination: "s3://bucket/test.json",
+		Status:      JobStatusInProgress,
+		StartTime:   time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC),
+	}
+	data, err := job.ToJSON()
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if !strings.Contains(string(data), `"name":"s2t-123"`) {
+		t.Error("wrong JSON: Got ", string(data))
+	}
+
+	parsedJob, err := ParseTranscriptionJob(data)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if parsedJob != job {
+		t.Error("wrong parsed job: Got ", parsedJob)
+	}
+
+	_, err = ParseTranscriptionJob([]byte("{"))
+	if err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestWaitForJobFailed(t *testing.T) {
+	job := TranscriptionJob{
+		Name:          "s2t-123",
+		Status:        JobStatusFailed,
+		FailureReason: "unsupported media format",
+	}
+	_, err := WaitForJob(context.Background(), nil, job, SpeechToTextOptions{})
+	if err == nil || !strings.Contains(err.Error(), "unsupported media format") {
+		t.Error("expected error with failure reason: Got ", err)
+	}
+}
@@ -1,14 +1,19 @@
 package shared
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/FaaSTools/GoStorage/gostorage"
 	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 func IsAWSUrl(urlString string) bool {
@@ -24,13 +29,19 @@ func IsGoogleUrl(urlString string) bool {
 
 const DefaultAWSRegion = "us-east-1"
 
-// ParseAWSUrl AWS Object URL (with explicit region)
+// ParseAWSUrl AWS Object URL (with explicit region) or S3 URI (starting with "s3://")
 // Taken from GoStorage
 func ParseAWSUrl(urlString string) gostorage.GoStorageObject {
 	var bucket string
 	var key string
 	var region string
 
+	if strings.HasPrefix(urlString, "s3://") { // S3 URI (doesn't contain a region)
+		withoutPrefix := strings.TrimPrefix(urlString, "s3://")
+		bucket, key, _ = strings.Cut(withoutPrefix, "/")
+		return gostorage.GoStorageObject{Bucket: bucket, Key: key, Region: DefaultAWSRegion, ProviderType: gostorage.ProviderAWS}
+	}
+
 	urlString = urlString[strings.Index(urlString, "https://")+len("https://"):]
 	bucket = urlString[:strings.Index(urlString, ".")]
 	urlString = urlString[strings.Index(urlString, ".")+len(".s3."):]
@@ -68,20 +79,9 @@ func ParseGoogleUrl(urlString string) gostorage.GoStorageObject {
 	return gostorage.GoStorageObject{Bucket: bucket, Key: key, ProviderType: gostorage.ProviderGoogle}
 }
 
-// StringToReader Taken from https://code-maven.com/slides/golang/create-io-reader-from-string
+// StringToReader returns a reader that reads the given string from the beginning.
 func StringToReader(str string) (io.Reader, error) {
-	myReader := strings.NewReader(str)
-	buffer := make([]byte, 1024)
-	for {
-		_, err := myReader.Read(buffer)
-		if err != nil {
-			if err != io.EOF {
-				return myReader, err
-			}
-			break
-		}
-	}
-	return myReader, nil
+	return strings.NewReader(str), nil
 }
 
 // from GoStorage
@@ -92,6 +92,20 @@ func checkErr(err interface{}, msg interface{}) {
 	}
 }
 
+var tempKeyCounter atomic.Uint64
+
+// GetTempKey returns an essentially random key for a temporary file (e.g. an uploaded audio file or a transcript
+// file) with the given file type. The key consists of the current time in nanoseconds and a counter, which keeps keys
+// unique if multiple files are created at the same time (e.g. by the concurrent transcription of chunks or batches).
+// If fileType is empty, the key has no file extension.
+func GetTempKey(fileType string) string {
+	key := strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + strconv.FormatUint(tempKeyCounter.Add(1), 10)
+	if !strings.EqualFold(fileType, "") {
+		key += "." + fileType
+	}
+	return key
+}
+
 // ParseUrlToGoStorageObject parses Object/Bucket URLs from AWS and Google to extract information such as bucketName, key, region etc.
 // Taken from GoStorage
 func ParseUrlToGoStorageObject(urlString string) gostorage.GoStorageObject {
@@ -108,9 +122,16 @@ func ParseUrlToGoStorageObject(urlString string) gostorage.GoStorageObject {
 }
 
 // GetFileTypeFromFileName returns the file type (i.e. file extension) if the given fileName.
-// fileName can also be a path or URL.
+// fileName can also be a path or URL. The query and fragment of URLs (e.g. the signature of presigned URLs) are ignored.
 // If there are multiple file extensions (example: 'test_file.tar.gz'), only the last file extension is returned ('gz').
+// Use GetAudioFileType to detect the file type of audio files without (or with wrong) file extension.
 func GetFileTypeFromFileName(fileName string) string {
+	if strings.Contains(fileName, "://") {
+		if parsedUrl, err := url.Parse(fileName); err == nil {
+			fileName = parsedUrl.Path
+		}
+	}
+	fileName = fileName[strings.LastIndexAny(fileName, "/\\")+1:]
 	splits := strings.SplitAfter(fileName, ".")
 	if len(splits) < 2 { // if splits is < 2, it means no file type; if splits is < 1, it means that fileName was empty
 		return ""
@@ -118,18 +139,12 @@ func GetFileTypeFromFileName(fileName string) string {
 	return splits[len(splits)-1]
 }
 
+// StoreAudioToLocalFile writes all data of the given reader into the given file.
+// If an error occurs while writing, the file is removed.
 func StoreAudioToLocalFile(audioData io.Reader, file *os.File) error {
-	var bytes = make([]byte, 1024)
-	for {
-		numBytes, err := audioData.Read(bytes)
-		_, writeErr := file.Write(bytes)
-		if (numBytes < 1) || (err != nil) { // done reading
-			break
-		}
-		if writeErr != nil {
-			_ = os.Remove(file.Name())
-			return writeErr
-		}
+	if _, err := io.Copy(file, audioData); err != nil {
+		_ = os.Remove(file.Name())
+		return err
 	}
 	return nil
 }
@@ -141,13 +156,67 @@ func StoreAudioToLocalFile(audioData io.Reader, file *os.File) error {
 //
 // The returned io.ReadCloser is not automatically closed. Make sure to close it yourself.
 func ReadFromUrl(url string) (io.ReadCloser, error) {
-	response, err := http.Get(url)
+	return ReadFromUrlWithContext(context.Background(), url)
+}
+
+// ReadFromUrlWithContext works like ReadFromUrl, but the download is aborted as soon as the given context is done.
+// In that case, ctx.Err() is returned.
+func ReadFromUrlWithContext(ctx context.Context, url string) (io.ReadCloser, error) {
+	request, errRequest := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if errRequest != nil {
+		return nil, errors.Join(errors.New(fmt.Sprintf("Couldn't download the source file '%s'.", url)), errRequest)
+	}
+	response, err := http.DefaultClient.Do(request)
 	if err != nil {
-		return nil, errors.Join(errors.New(fmt.Sprintf("Couldn't download the source file '%s'.", url)), err)
+		return nil, ContextError(ctx, errors.Join(errors.New(fmt.Sprintf("Couldn't download the source file '%s'.", url)), err))
 	}
 	return response.Body, nil
 }
 
+// ReadSourceContent reads the contents of the given local file or file from some other URL.
+func ReadSourceContent(ctx context.Context, sourceUrl string) ([]byte, error) {
+	if strings.HasPrefix(sourceUrl, "http") { // file somewhere else online
+		reader, errDownload := ReadFromUrlWithContext(ctx, sourceUrl)
+		if errDownload != nil {
+			return nil, errDownload
+		}
+
+		// close reader after function call ended
+		defer func(Reader io.ReadCloser) {
+			errClose := Reader.Close()
+			if errClose != nil {
+				fmt.Printf(errors.Join(errors.New(fmt.Sprintf("A non-fatal error occurred while closing the HTTP response for the source file '%s'.", sourceUrl)), errClose).Error())
+			}
+		}(reader)
+
+		content, errReader := io.ReadAll(reader)
+		if errReader != nil {
+			return nil, ContextError(ctx, errors.Join(errors.New("error while reading contents of file reader from URL"), errReader))
+		}
+		return content, nil
+	}
+
+	// local file
+	content, errReadFile := os.ReadFile(sourceUrl)
+	if errReadFile != nil {
+		return nil, errors.Join(errors.New("error while reading contents of local file"), errReadFile)
+	}
+	return content, nil
+}
+
+// ContextError returns ctx.Err() if err is not nil and the given context is done, and err otherwise.
+// It is used to report cancellations and exceeded deadlines as such, instead of the (wrapped) error that
+// the cancelled operation returned.
+func ContextError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
 // ReadTextFromUrl reads the contents of the file stored at the given URL and returns it as a string.
 // Works on any publicly available URL.
 // If a fatal error occurs, the returned string is empty (i.e. "") and error is returned.
@@ -172,13 +241,12 @@ func ReadTextFromUrl(url string) (string, error) {
 	return text, nil
 }
 
+// ProviderToGoStorageProvider returns the GoStorage provider that the given provider has been registered with
+// (see providers.Register). For unknown providers, gostorage.ProviderAWS is returned.
 func ProviderToGoStorageProvider(provider providers.Provider) gostorage.ProviderType {
-	switch provider {
-	case providers.ProviderGCP:
-		return gostorage.ProviderGoogle
-	case providers.ProviderAWS:
-		fallthrough
-	default:
+	registration, exists := providers.GetRegistration(provider)
+	if !exists || registration.StorageProvider == "" {
 		return gostorage.ProviderAWS
 	}
+	return registration.StorageProvider
 }
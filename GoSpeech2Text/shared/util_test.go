@@ -1,8 +1,14 @@
 package shared
 
 import (
+	"context"
+	"errors"
+	"github.com/FaaSTools/GoStorage/gostorage"
 	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -27,10 +33,108 @@ func TestGetFileTypeFromFileName(t *testing.T) {
 	if !strings.EqualFold(result4, "gz") {
 		t.Error("wrong filetype: Got ", result4)
 	}
+
+	result5 := GetFileTypeFromFileName("https://bucket.s3.amazonaws.com/audio.wav?X-Amz-Signature=abc.def")
+	if !strings.EqualFold(result5, "wav") {
+		t.Error("wrong filetype: Got ", result5)
+	}
+
+	result6 := GetFileTypeFromFileName("/tmp/dir.d/sample123")
+	if !strings.EqualFold(result6, "") {
+		t.Error("wrong filetype: Got ", result6)
+	}
 }
 
 func TestProviderToGoStorageProvider(t *testing.T) {
-	if len(providers.GetAllProviders()) > 2 {
-		t.Error("New provider detected. Update ProviderToGoStorageProvider function.")
+	providers.Register("TEST", func() interface{} { return nil }, nil, gostorage.ProviderGoogle)
+	t.Cleanup(func() { providers.Unregister("TEST") })
+	if ProviderToGoStorageProvider("TEST") != gostorage.ProviderGoogle {
+		t.Error("wrong storage provider: Got ", ProviderToGoStorageProvider("TEST"))
+	}
+	if ProviderToGoStorageProvider("UNKNOWN") != gostorage.ProviderAWS {
+		t.Error("wrong storage provider for unknown provider: Got ", ProviderToGoStorageProvider("UNKNOWN"))
+	}
+}
+
+func TestContextError(t *testing.T) {
+	err := errors.New("request failed")
+	if ContextError(context.Background(), err) != err {
+		t.Error("expected original error if context is not done")
+	}
+	if ContextError(context.Background(), nil) != nil {
+		t.Error("expected nil if there is no error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if !errors.Is(ContextError(ctx, err), context.Canceled) {
+		t.Error("expected context.Canceled if context is cancelled")
+	}
+}
+
+func TestReadFromUrlWithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("audio"))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	reader, err := ReadFromUrlWithContext(ctx, server.URL)
+	if reader != nil || !errors.Is(err, context.Canceled) {
+		t.Error("expected context.Canceled for cancelled context: Got ", err)
+	}
+
+	reader, err = ReadFromUrlWithContext(context.Background(), server.URL)
+	if err != nil {
+		t.Error("unexpected error: ", err)
+	} else {
+		_ = reader.Close()
+	}
+}
+
+func TestParseAWSUrl(t *testing.T) {
+	obj := ParseAWSUrl("s3://test-bucket/folder/test.mp3")
+	if obj.Bucket != "test-bucket" || obj.Key != "folder/test.mp3" || obj.Region != DefaultAWSRegion {
+		t.Error("wrong S3 URI result: Got ", obj)
+	}
+
+	obj = ParseAWSUrl("https://test-bucket.s3.eu-central-1.amazonaws.com/test.mp3")
+	if obj.Bucket != "test-bucket" || obj.Key != "test.mp3" || obj.Region != "eu-central-1" {
+		t.Error("wrong S3 object URL result: Got ", obj)
+	}
+}
+
+func TestGetTempKeyUnique(t *testing.T) {
+	keys := make(chan string, 100)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys <- GetTempKey("json")
+		}()
+	}
+	wg.Wait()
+	close(keys)
+	unique := make(map[string]bool)
+	for key := range keys {
+		if unique[key] || !strings.HasSuffix(key, ".json") {
+			t.Error("wrong or duplicate key: Got ", key)
+		}
+		unique[key] = true
+	}
+}
+
+func TestTranscriptionJobNameWithIndex(t *testing.T) {
+	if name := (TranscriptionJobNameConfig{TranscriptionJobName: "job"}).WithIndex(2).GetTranscriptionJobName(); name != "job-2" {
+		t.Error("wrong job name: Got ", name)
+	}
+	name := (TranscriptionJobNameConfig{TranscriptionJobName: "s2t-", AppendCurrentTimestamp: true}).WithIndex(2).GetTranscriptionJobName()
+	if !strings.HasPrefix(name, "s2t-2-") || len(name) <= len("s2t-2-") {
+		t.Error("wrong job name: Got ", name)
+	}
+	if name = (TranscriptionJobNameConfig{}).WithIndex(3).GetTranscriptionJobName(); !strings.HasPrefix(name, "3-") {
+		t.Error("wrong job name: Got ", name)
 	}
 }
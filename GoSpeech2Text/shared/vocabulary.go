@@ -0,0 +1,142 @@
+package shared
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Vocabulary is a custom vocabulary that is stored on a provider, so that it can be referenced in
+// VocabularyConfig.VocabularyName (see GoS2TClient.CreateVocabulary).
+// On AWS, this is a Transcribe custom vocabulary. On GCP, this is a PhraseSet resource, and every custom class is
+// stored as CustomClass resource with the ID "{vocabulary ID}-{class ID}".
+type Vocabulary struct {
+	_ struct{}
+	// Provider is the provider on which the vocabulary is stored. It is set by the provider.
+	Provider providers.Provider
+	// Id identifies the vocabulary on the provider.
+	// On GCP, the ID (and the IDs of the custom classes) must consist of lowercase letters, digits and hyphens.
+	Id string
+	// Name is the name with which the vocabulary is referenced in VocabularyConfig.VocabularyName.
+	// On AWS, this is equal to Id. On GCP, this is the resource name of the PhraseSet
+	// (i.e. "projects/{project}/locations/{location}/phraseSets/{id}"). It is set by the provider.
+	Name string
+	// LanguageCode is the language of the vocabulary. It is required on AWS and ignored on GCP.
+	LanguageCode string
+	// Phrases are the phrases of the vocabulary.
+	// Phrases and custom classes are only returned by GetVocabulary and ListVocabularies on GCP. The phrases of an AWS
+	// vocabulary can be downloaded from DownloadUrl.
+	Phrases []VocabularyPhrase
+	// CustomClasses are the custom classes of the vocabulary, which can be referenced in phrases as "${id}".
+	// On AWS, the references are replaced by every item of the class, and all items are added as phrases (see
+	// VocabularyConfig.GetExpandedPhrases). On GCP, classes that aren't referenced by any phrase are added as phrase.
+	CustomClasses []VocabularyClass
+	// State is the state of the vocabulary. A vocabulary can only be used once it is ready.
+	// It is set by the provider.
+	State VocabularyState
+	// FailureReason contains the reason why the vocabulary failed (if State is VocabularyStateFailed).
+	FailureReason string
+	// DownloadUrl is the URL from which the vocabulary file can be downloaded (only on AWS).
+	DownloadUrl string
+	// LastModifiedTime is the time at which the vocabulary has been created or updated last.
+	LastModifiedTime time.Time
+}
+
+// VocabularyState is the provider-independent state of a Vocabulary.
+type VocabularyState string
+
+const (
+	VocabularyStatePending VocabularyState = "PENDING"
+	VocabularyStateReady   VocabularyState = "READY"
+	VocabularyStateFailed  VocabularyState = "FAILED"
+)
+
+// IsDone returns true if the vocabulary is either ready or has failed, i.e. if its state won't change anymore.
+func (s VocabularyState) IsDone() bool {
+	return s == VocabularyStateReady || s == VocabularyStateFailed
+}
+
+// ErrVocabularyNotFound is returned (wrapped) by the providers if a vocabulary doesn't exist.
+var ErrVocabularyNotFound = errors.New("vocabulary not found")
+
+// GetVocabularyConfig returns a VocabularyConfig that references the vocabulary.
+func (v Vocabulary) GetVocabularyConfig() VocabularyConfig {
+	return VocabularyConfig{VocabularyName: v.Name}
+}
+
+// ReadVocabularyFile reads the phrases of a vocabulary from the given local file (see ParseVocabularyPhrases).
+func ReadVocabularyFile(path string) ([]VocabularyPhrase, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Join(errors.New(fmt.Sprintf("error while reading vocabulary file '%s'", path)), err)
+	}
+	return ParseVocabularyPhrases(string(content))
+}
+
+// ParseVocabularyPhrases parses a list of phrases, which contains one phrase per line. A phrase can be followed by a
+// tab and its boost (e.g. "GoSpeech2Text\t10"). Empty lines and lines starting with '#' are ignored.
+func ParseVocabularyPhrases(content string) ([]VocabularyPhrase, error) {
+	var phrases []VocabularyPhrase
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.EqualFold(line, "") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		phrase := VocabularyPhrase{Phrase: line}
+		if text, boost, hasBoost := strings.Cut(line, "\t"); hasBoost {
+			boostValue, err := strconv.ParseFloat(strings.TrimSpace(boost), 32)
+			if err != nil {
+				return nil, errors.Join(errors.New(fmt.Sprintf("invalid boost in line %d of vocabulary", lineNumber)), err)
+			}
+			phrase = VocabularyPhrase{Phrase: strings.TrimSpace(text), Boost: float32(boostValue)}
+		}
+		phrases = append(phrases, phrase)
+	}
+	return phrases, scanner.Err()
+}
+
+// getJobStatus converts the vocabulary state into a JobStatus, where a ready vocabulary is completed.
+func (s VocabularyState) getJobStatus() JobStatus {
+	switch s {
+	case VocabularyStateReady:
+		return JobStatusCompleted
+	case VocabularyStateFailed:
+		return JobStatusFailed
+	default:
+		return JobStatusInProgress
+	}
+}
+
+// WaitForVocabulary polls the state of the given vocabulary on the given vocabulary manager until it is ready or has failed,
+// using a JobPoller that is configured by the transcription job options of the given options.
+// The updated vocabulary is returned. If the vocabulary has failed, an error with the failure reason is returned.
+func WaitForVocabulary(ctx context.Context, provider VocabularyManager, vocabulary Vocabulary, options SpeechToTextOptions) (Vocabulary, error) {
+	if !vocabulary.State.IsDone() {
+		poller := NewJobPoller(options)
+		// the status callback only reports transcription jobs
+		poller.OnPoll = nil
+		_, errPoll := poller.Poll(ctx, vocabulary.Id, func(ctx context.Context) (JobStatus, error) {
+			updated, err := provider.GetVocabulary(ctx, vocabulary.Id)
+			if err != nil {
+				return "", err
+			}
+			vocabulary = updated
+			return vocabulary.State.getJobStatus(), nil
+		})
+		if errPoll != nil {
+			return vocabulary, errPoll
+		}
+	}
+
+	if vocabulary.State == VocabularyStateFailed {
+		return vocabulary, errors.New(fmt.Sprintf("Vocabulary '%s' couldn't be created: %s", vocabulary.Id, vocabulary.FailureReason))
+	}
+	return vocabulary, nil
+}
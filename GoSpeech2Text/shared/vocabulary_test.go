@@ -0,0 +1,26 @@
+package shared
+
+import (
+	"testing"
+)
+
+func TestParseVocabularyPhrases(t *testing.T) {
+	content := "# products\nWidget Pro\t10\n\n  GoSpeech2Text  \n"
+	phrases, err := ParseVocabularyPhrases(content)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if len(phrases) != 2 {
+		t.Fatal("wrong number of phrases: Got ", phrases)
+	}
+	if phrases[0].Phrase != "Widget Pro" || phrases[0].Boost != 10 {
+		t.Error("wrong first phrase: Got ", phrases[0])
+	}
+	if phrases[1].Phrase != "GoSpeech2Text" || phrases[1].Boost != 0 {
+		t.Error("wrong second phrase: Got ", phrases[1])
+	}
+
+	if _, err = ParseVocabularyPhrases("Widget Pro\thigh"); err == nil {
+		t.Error("expected error because of invalid boost")
+	}
+}
@@ -0,0 +1,56 @@
+package GoText2Speech
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+	"io"
+)
+
+// StreamingS2T transcribes the audio that is read from the given reader (e.g. a microphone or WebRTC audio stream)
+// in real time. Interim and final results are delivered via the returned channel (see PartialResult), which is closed
+// as soon as the reader returns io.EOF and all results have been delivered, the context is done, or an error occurs
+// (in which case the last result contains the error).
+// Live audio is usually raw audio, which must be described by options.AudioFormat (e.g. LINEAR16 with 16000 Hz).
+// If the given options don't specify a provider, a provider that supports streaming (see StreamingS2TProvider) is
+// chosen based on heuristics.
+// The service is executed in the region of the client or the default region of the provider.
+// If content is redacted (see SpeechToTextOptions.ContentRedactionConfig), only redacted results are delivered.
+func (a GoS2TClient) StreamingS2T(ctx context.Context, audio io.Reader, options SpeechToTextOptions) (<-chan PartialResult, error) {
+	if options.Provider == providers.ProviderUnspecified {
+		var err error
+		options, err = a.determineProvider(ctx, options, "", providers.CapabilityStreaming)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	provider, err := a.getDefaultServiceClient(ctx, options.Provider)
+	if err != nil {
+		return nil, err
+	}
+	streamingProvider, ok := provider.(StreamingS2TProvider)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("The provider '%s' doesn't support streaming transcription.", options.Provider))
+	}
+
+	redaction := getLocalRedaction(options)
+	if redaction != nil {
+		options.ContentRedactionConfig = ContentRedactionConfig{}
+	}
+	profanityFilter := getLocalProfanityFilter(options)
+	if profanityFilter != nil {
+		options.ProfanityFilter = false
+	}
+	_, options, err = provider.TransformOptions(ctx, "", options)
+	if err != nil {
+		return nil, err
+	}
+	results, err := streamingProvider.StreamS2T(ctx, audio, options)
+	if err != nil {
+		return nil, err
+	}
+	return filterStreamProfanity(ctx, redactStream(ctx, results, redaction), profanityFilter), nil
+}
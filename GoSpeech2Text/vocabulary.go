@@ -0,0 +1,128 @@
+package GoText2Speech
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FaaSTools/GoText2Speech/GoSpeech2Text/providers"
+	. "github.com/FaaSTools/GoText2Speech/GoSpeech2Text/shared"
+)
+
+// CreateVocabulary creates the given custom vocabulary on the given provider, so that it can be referenced in
+// SpeechToTextOptions.VocabularyConfig (see Vocabulary.GetVocabularyConfig). The vocabulary is created in the region
+// of the client or the default region of the provider.
+// On AWS, the vocabulary is returned without waiting for it to be ready (see WaitVocabulary).
+func (a GoS2TClient) CreateVocabulary(ctx context.Context, provider providers.Provider, vocabulary Vocabulary) (Vocabulary, error) {
+	instance, err := a.getVocabularyManager(ctx, provider)
+	if err != nil {
+		return vocabulary, err
+	}
+	return instance.CreateVocabulary(ctx, vocabulary)
+}
+
+// UpdateVocabulary replaces the phrases and custom classes of the existing vocabulary with the ID of the given
+// vocabulary on the given provider.
+// On AWS, the vocabulary is returned without waiting for it to be ready (see WaitVocabulary).
+func (a GoS2TClient) UpdateVocabulary(ctx context.Context, provider providers.Provider, vocabulary Vocabulary) (Vocabulary, error) {
+	instance, err := a.getVocabularyManager(ctx, provider)
+	if err != nil {
+		return vocabulary, err
+	}
+	return instance.UpdateVocabulary(ctx, vocabulary)
+}
+
+// GetVocabulary returns the vocabulary with the given ID on the given provider.
+// If the vocabulary doesn't exist, the returned error wraps ErrVocabularyNotFound.
+func (a GoS2TClient) GetVocabulary(ctx context.Context, provider providers.Provider, id string) (Vocabulary, error) {
+	instance, err := a.getVocabularyManager(ctx, provider)
+	if err != nil {
+		return Vocabulary{}, err
+	}
+	return instance.GetVocabulary(ctx, id)
+}
+
+// ListVocabularies returns all vocabularies on the given provider in the region of the client or the default region
+// of the provider.
+func (a GoS2TClient) ListVocabularies(ctx context.Context, provider providers.Provider) ([]Vocabulary, error) {
+	instance, err := a.getVocabularyManager(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	return instance.ListVocabularies(ctx)
+}
+
+// DeleteVocabulary deletes the vocabulary with the given ID on the given provider.
+// If the vocabulary doesn't exist, the returned error wraps ErrVocabularyNotFound.
+func (a GoS2TClient) DeleteVocabulary(ctx context.Context, provider providers.Provider, id string) error {
+	instance, err := a.getVocabularyManager(ctx, provider)
+	if err != nil {
+		return err
+	}
+	return instance.DeleteVocabulary(ctx, id)
+}
+
+// WaitVocabulary waits until the given vocabulary is ready and returns the updated vocabulary.
+// The state is checked periodically, as configured by the transcription job options in the given options
+// (see SpeechToTextOptions.TranscriptionJobCheckIntervalMs).
+// If the vocabulary has failed, the returned error contains the failure reason.
+func (a GoS2TClient) WaitVocabulary(ctx context.Context, vocabulary Vocabulary, options SpeechToTextOptions) (Vocabulary, error) {
+	instance, err := a.getVocabularyManager(ctx, vocabulary.Provider)
+	if err != nil {
+		return vocabulary, err
+	}
+	return WaitForVocabulary(ctx, instance, vocabulary, options)
+}
+
+// SyncVocabulary creates the given vocabulary on the given provider, or updates it if it already exists, and waits
+// until it is ready (see WaitVocabulary). This can be used to keep a vocabulary in sync with a phrases file
+// (see ReadVocabularyFile).
+func (a GoS2TClient) SyncVocabulary(ctx context.Context, provider providers.Provider, vocabulary Vocabulary, options SpeechToTextOptions) (Vocabulary, error) {
+	instance, err := a.getVocabularyManager(ctx, provider)
+	if err != nil {
+		return vocabulary, err
+	}
+
+	_, err = instance.GetVocabulary(ctx, vocabulary.Id)
+	if errors.Is(err, ErrVocabularyNotFound) {
+		vocabulary, err = instance.CreateVocabulary(ctx, vocabulary)
+	} else if err == nil {
+		vocabulary, err = instance.UpdateVocabulary(ctx, vocabulary)
+	}
+	if err != nil {
+		return vocabulary, err
+	}
+	return WaitForVocabulary(ctx, instance, vocabulary, options)
+}
+
+// getVocabularyManager returns the instance of the given provider with a service client for the region of the client
+// or the default region of the provider (see getDefaultServiceClient), if the provider can manage vocabularies
+// (see VocabularyManager).
+func (a GoS2TClient) getVocabularyManager(ctx context.Context, provider providers.Provider) (VocabularyManager, error) {
+	instance, err := a.getDefaultServiceClient(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	manager, ok := instance.(VocabularyManager)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("The provider '%s' doesn't support custom vocabularies.", provider))
+	}
+	return manager, nil
+}
+
+// getDefaultServiceClient returns the instance of the given provider with a service client for the region of the
+// client or (if the client has no region) the default region of the provider.
+func (a GoS2TClient) getDefaultServiceClient(ctx context.Context, provider providers.Provider) (S2TProvider, error) {
+	instance := a.getProviderInstance(provider)
+	if instance == nil {
+		return nil, errors.New(fmt.Sprintf("The provider '%s' is not supported. Register it with providers.Register.", provider))
+	}
+	region := instance.GetDefaultRegion()
+	if a.region != nil {
+		region = *a.region
+	}
+	instance, err := a.getServiceClient(ctx, provider, region)
+	if err != nil {
+		return nil, ContextError(ctx, errors.Join(errors.New("error while creating S2T service client"), err))
+	}
+	return instance, nil
+}
@@ -19,6 +19,8 @@ func main() {
 	options.Provider = providers.ProviderAWS
 
 	bucket := "test"
+	// the transcript is formatted as plain text (see S2T), so it is stored temporarily in TempBucket first
+	options.TempBucket = bucket
 
 	var err error = nil
 	s2tClient, err = s2tClient.S2T("https://"+bucket+".s3.amazonaws.com/testfile.mp3", "https://"+bucket+".s3.amazonaws.com/testfile.txt", *options)